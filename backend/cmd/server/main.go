@@ -82,6 +82,30 @@ func main() {
 	auditLogRepo := repositories.NewAuditLogRepository(db)
 	priceAlertRepo := repositories.NewPriceAlertRepository(db)
 	supportTicketRepo := repositories.NewSupportTicketRepository(db)
+	reportRepo := repositories.NewReportRepository(db)
+	chargeScheduleRepo := repositories.NewChargeScheduleRepository(db)
+	indexRepo := repositories.NewIndexRepository(db)
+	riskParamsRepo := repositories.NewRiskParamsRepository(db)
+	marginCallRepo := repositories.NewMarginCallRepository(db)
+	marginHealthRepo := repositories.NewMarginHealthRepository(db)
+	borrowInventoryRepo := repositories.NewBorrowInventoryRepository(db)
+	borrowRecordRepo := repositories.NewBorrowRecordRepository(db)
+	suitabilityRepo := repositories.NewSuitabilityRepository(db)
+	riskRuleRepo := repositories.NewRiskRuleRepository(db)
+	gttRepo := repositories.NewGTTRepository(db)
+	algoOrderRepo := repositories.NewAlgoOrderRepository(db)
+	basketRepo := repositories.NewBasketRepository(db)
+	rebalanceModelRepo := repositories.NewRebalanceModelRepository(db)
+	sipRepo := repositories.NewSIPRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+
+	// Existing accounts become each user's default account
+	if err := tradingAccountRepo.MigrateToDefaultAccounts(context.Background()); err != nil {
+		log.Printf("Warning: trading account migration failed: %v", err)
+	}
+	if err := orderRepo.MigrateClientOrderIndex(context.Background()); err != nil {
+		log.Printf("Warning: client order ID index migration failed: %v", err)
+	}
 
 	// Initialize basic services
 	otpService := services.NewOTPService(otpRepo)
@@ -97,8 +121,10 @@ func main() {
 	watchlistService := services.NewWatchlistService(watchlistRepo, instrumentRepo)
 	telemetryService := services.NewTelemetryService(telemetryRepo, auditService)
 	userService := services.NewUserService(userRepo, otpService, commProvider)
-	analyticsService := services.NewAnalyticsService(tradeResultRepo, activeUnitRepo, candleRepo)
-	portfolioService := services.NewPortfolioService(portfolioRepo, marketService, tradingAccountService, analyticsService)
+	suitabilityService := services.NewSuitabilityService(suitabilityRepo, auditService)
+	analyticsService := services.NewAnalyticsService(tradeResultRepo, activeUnitRepo, candleRepo, orderRepo, marketDataRepo)
+	marginService := services.NewMarginService(riskParamsRepo, marginCallRepo, marginHealthRepo, portfolioRepo, tradingAccountRepo, marketDataRepo, instrumentRepo, auditService)
+	portfolioService := services.NewPortfolioService(portfolioRepo, marketService, tradingAccountService, analyticsService, marginService, gttRepo)
 	candleService := services.NewCandleService(candleRepo)
 	candleBuilder := services.NewCandleBuilder(candleRepo)
 	tradeService := services.NewTradeService(tradeRepo)
@@ -113,10 +139,16 @@ func main() {
 	notificationService := services.NewNotificationService(notificationRepo, wsHub)
 	priceAlertService := services.NewPriceAlertService(priceAlertRepo, notificationService)
 	supportService := services.NewSupportService(supportTicketRepo, userRepo, auditService, notificationService)
+	indexService := services.NewIndexService(indexRepo, instrumentRepo, marketDataRepo, candleBuilder, priceAlertService, auditService)
+	performanceService := services.NewPerformanceService(portfolioRepo, transactionRepo, tradingAccountService, indexService)
 
 	// Initialize Complex Services (Dependent on NotificationService)
-	matchingService := services.NewMatchingService(cfg, orderRepo, tradeRepo, marketDataRepo, tradingAccountService, portfolioService, notificationService, auditService)
-	orderService := services.NewOrderService(orderRepo, instrumentRepo, tradingAccountRepo, marketDataRepo, matchingService, portfolioService, notificationService, auditService)
+	chargeService := services.NewChargeService(chargeScheduleRepo, cfg, auditService)
+	borrowService := services.NewBorrowService(borrowInventoryRepo, borrowRecordRepo, instrumentRepo, marketDataRepo, tradingAccountService, notificationService, auditService)
+	riskEngineService := services.NewRiskEngineService(riskRuleRepo, orderRepo, portfolioRepo, transactionRepo, marketDataRepo, marginService, auditService)
+	riskEngineService.EnsureDefaultRules(context.Background())
+	matchingService := services.NewMatchingService(cfg, orderRepo, tradeRepo, marketDataRepo, tradingAccountService, portfolioService, notificationService, auditService, chargeService, borrowService)
+	orderService := services.NewOrderService(orderRepo, instrumentRepo, tradingAccountRepo, marketDataRepo, matchingService, portfolioService, notificationService, auditService, marginService, borrowService, suitabilityService, riskEngineService, candleRepo)
 
 	// Configure candle builder to broadcast to WS hub
 	candleBuilder.SetBroadcastFunc(func(instrumentID string, candle *models.Candle) {
@@ -125,9 +157,17 @@ func main() {
 
 	// Initialize pricing engine
 	pricingService := services.NewPricingService(instrumentRepo, marketDataRepo, candleRepo, candleBuilder, priceAlertService)
+
+	// Conditional orders (time, cross-instrument, change-from-open, indicator) are evaluated on every price tick
+	conditionalOrderService := services.NewConditionalOrderService(orderRepo, marketDataRepo, candleRepo, orderService, notificationService, auditService)
+	pricingService.SetTickFunc(conditionalOrderService.EvaluateConditionalOrders)
 	pricingService.Start()
 	defer pricingService.Stop()
 
+	// Initialize benchmark/sector index engine (same cadence as pricing)
+	indexService.Start()
+	defer indexService.Stop()
+
 	// Admin Metrics Broadcast Ticker (SLA: <= 5s)
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
@@ -145,18 +185,33 @@ func main() {
 	candleCleanupService.Start()
 	defer candleCleanupService.Stop()
 
-	// Initialize stop order monitoring service (runs every 3 seconds)
-	stopOrderService := services.NewStopOrderService(orderRepo, marketDataRepo, orderService)
+	// Initialize stop order and GTT monitoring service (runs every 3 seconds)
+	gttService := services.NewGTTService(gttRepo, portfolioRepo, instrumentRepo, marketDataRepo, orderService, notificationService, auditService)
+	stopOrderService := services.NewStopOrderService(orderRepo, marketDataRepo, orderService, gttService)
 	stopOrderService.Start()
 	defer stopOrderService.Stop()
 
+	// Initialize TWAP/VWAP algo order service (runs every 5 seconds)
+	algoOrderService := services.NewAlgoOrderService(algoOrderRepo, orderRepo, instrumentRepo, tradingAccountRepo, marketDataRepo, candleRepo, marketRepo, marketService, orderService, notificationService, auditService)
+	algoOrderService.Start()
+	defer algoOrderService.Stop()
+
+	basketService := services.NewBasketService(basketRepo, instrumentRepo, marketDataRepo, tradingAccountRepo, portfolioService, marginService, orderService, auditService)
+	rebalanceService := services.NewRebalanceService(rebalanceModelRepo, instrumentRepo, marketDataRepo, tradingAccountRepo, portfolioService, chargeService, basketService, notificationService, auditService)
+	rebalanceService.Start()
+	defer rebalanceService.Stop()
+
+	sipService := services.NewSIPService(sipRepo, instrumentRepo, marketDataRepo, marketRepo, tradingAccountRepo, marketService, orderService, notificationService, auditService)
+	sipService.Start()
+	defer sipService.Stop()
+
 	// Initialize notification cleanup service (runs every 5 minutes)
 	notificationCleanupService := services.NewNotificationCleanupService(notificationRepo)
 	notificationCleanupService.Start()
 	defer notificationCleanupService.Stop()
 
 	// Initialize margin monitor service (runs every 3 minutes)
-	marginMonitorService := services.NewMarginMonitorService(tradingAccountRepo, portfolioService, notificationService)
+	marginMonitorService := services.NewMarginMonitorService(tradingAccountRepo, portfolioService, orderService, marketService, marginService, notificationService, auditService)
 	marginMonitorService.Start()
 	defer marginMonitorService.Stop()
 
@@ -164,6 +219,30 @@ func main() {
 	matchingService.Start()
 	defer matchingService.Stop()
 
+	// Initialize daily borrow-fee accrual
+	borrowService.Start()
+	defer borrowService.Stop()
+
+	// Initialize intraday (MIS) square-off scheduler (runs every minute)
+	squareOffService := services.NewSquareOffService(portfolioRepo, marketRepo, orderService, tradingAccountService, notificationService, cfg.MISSquareOffMinutes, cfg.MISSquareOffWarningMinutes, cfg.MISSquareOffFee)
+	squareOffService.Start()
+	defer squareOffService.Stop()
+
+	// Initialize DAY order expiry at session close (runs every minute)
+	orderExpiryService := services.NewOrderExpiryService(orderRepo, marketRepo, orderService)
+	orderExpiryService.Start()
+	defer orderExpiryService.Stop()
+
+	// Initialize portfolio snapshot scheduler (EOD + intraday + backfill)
+	snapshotService := services.NewSnapshotService(portfolioService, marketService, portfolioRepo, tradingAccountRepo, tradeRepo, transactionRepo, candleRepo, marketRepo)
+	snapshotService.Start()
+	defer snapshotService.Stop()
+
+	// Initialize EOD reporting scheduler (contract notes + monthly statements)
+	reportingService := services.NewReportingService(reportRepo, tradeRepo, orderRepo, transactionRepo, tradingAccountRepo, userRepo, commProvider)
+	reportingService.Start()
+	defer reportingService.Stop()
+
 	// Initialize controllers
 	authController := controllers.NewAuthController(authService)
 	instrumentController := controllers.NewInstrumentController(instrumentService)
@@ -171,19 +250,31 @@ func main() {
 	watchlistController := controllers.NewWatchlistController(watchlistService)
 	telemetryController := controllers.NewTelemetryController(telemetryService)
 	userController := controllers.NewUserController(userService)
+	suitabilityController := controllers.NewSuitabilityController(suitabilityService)
 	accountController := controllers.NewAccountController(tradingAccountService)
 	adminController := controllers.NewAdminController(adminService, tradingAccountService)
 	jitController := controllers.NewJITController(jitService)
 	orderController := controllers.NewOrderController(orderService)
 	candleController := controllers.NewCandleController(candleService)
 	tradeController := controllers.NewTradeController(tradeService)
-	portfolioController := controllers.NewPortfolioController(portfolioService)
+	portfolioController := controllers.NewPortfolioController(portfolioService, performanceService)
 	notificationController := controllers.NewNotificationController(notificationService)
 	priceAlertController := controllers.NewPriceAlertController(priceAlertService)
 	dashboardController := controllers.NewDashboardController(dashboardService)
 	analyticsController := controllers.NewAnalyticsController(analyticsService)
 	auditController := controllers.NewAuditController(auditService)
 	supportController := controllers.NewSupportController(supportService)
+	reportController := controllers.NewReportController(reportingService)
+	chargeController := controllers.NewChargeController(chargeService)
+	indexController := controllers.NewIndexController(indexService)
+	marginController := controllers.NewMarginController(marginService)
+	borrowController := controllers.NewBorrowController(borrowService, orderService)
+	gttController := controllers.NewGTTController(gttService)
+	algoOrderController := controllers.NewAlgoOrderController(algoOrderService)
+	basketController := controllers.NewBasketController(basketService)
+	rebalanceController := controllers.NewRebalanceController(rebalanceService)
+	sipController := controllers.NewSIPController(sipService)
+	riskController := controllers.NewRiskController(riskEngineService)
 	
 	abacMiddleware := middleware.NewABACMiddleware(jitService)
 
@@ -214,12 +305,13 @@ func main() {
 	api.HandleFunc("/auth/reset-password", authController.ResetPassword).Methods("POST", "OPTIONS")
 	api.HandleFunc("/auth/step-up", authController.StepUp).Methods("POST", "OPTIONS")
 
-	// Protected routes (require authentication)
-	protected := api.PathPrefix("").Subrouter()
-	protected.Use(middleware.Auth(cfg, userRepo, adminConfigRepo))
+	// Authenticated routes
+	authenticated := api.PathPrefix("").Subrouter()
+	authenticated.Use(middleware.Auth(cfg, userRepo, adminConfigRepo))
+	authenticated.Use(middleware.Idempotency(idempotencyRepo)) // Replays mutating requests sent with an Idempotency-Key
 
 	// Admin routes (require Admin roles + additional ABAC for sensitive actions)
-	adminRouter := protected.PathPrefix("/admin").Subrouter()
+	adminRouter := authenticated.PathPrefix("/admin").Subrouter()
 	adminRouter.Use(middleware.RoleMiddleware(
 		models.RolePlatformAdmin, 
 		models.RoleRiskOfficer, 
@@ -231,6 +323,10 @@ func main() {
 	
 	// Sensitive Actions (JIT Required)
 	adminRouter.Handle("/wallet/adjust", abacMiddleware.Authorize("WALLET_ADJUSTMENT", true)(http.HandlerFunc(adminController.AdjustWallet))).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/charges", abacMiddleware.Authorize("CHARGE_SCHEDULE_UPDATE", true)(middleware.StepUpMiddleware(cfg)(http.HandlerFunc(chargeController.PublishSchedule)))).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/risk-params/{instrumentId}", abacMiddleware.Authorize("RISK_PARAMS_UPDATE", true)(middleware.StepUpMiddleware(cfg)(http.HandlerFunc(marginController.UpdateRiskParams)))).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/borrow-inventory/{instrumentId}", middleware.StepUpMiddleware(cfg)(http.HandlerFunc(borrowController.UpdateInventory))).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/borrows/{id}/buy-in", middleware.StepUpMiddleware(cfg)(http.HandlerFunc(borrowController.BuyIn))).Methods("POST", "OPTIONS")
 	adminRouter.Handle("/config", abacMiddleware.Authorize("CONFIG_UPDATE", true)(middleware.StepUpMiddleware(cfg)(http.HandlerFunc(adminController.UpdateConfig)))).Methods("PUT", "OPTIONS")
 	
 	// General Admin Actions
@@ -239,6 +335,19 @@ func main() {
 	adminRouter.HandleFunc("/wallets", adminController.GetWallets).Methods("GET", "OPTIONS")
 	adminRouter.HandleFunc("/wallet/history", adminController.GetWalletHistory).Methods("GET", "OPTIONS")
 	adminRouter.HandleFunc("/config", adminController.GetConfig).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/charges", chargeController.GetScheduleHistory).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/risk-params", marginController.GetRiskParams).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/borrow-inventory", borrowController.GetInventory).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/borrows", borrowController.ListBorrows).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/borrows/{id}/recall", borrowController.RecallBorrow).Methods("POST", "OPTIONS")
+	riskRoles := middleware.RoleMiddleware(models.RolePlatformAdmin, models.RoleRiskOfficer)
+	adminRouter.Handle("/risk/dashboard", riskRoles(http.HandlerFunc(riskController.GetDashboard))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/risk/rules", riskRoles(http.HandlerFunc(riskController.GetRules))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/risk/rules", riskRoles(middleware.StepUpMiddleware(cfg)(http.HandlerFunc(riskController.UpsertRule)))).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/risk/rules/{id}", riskRoles(middleware.StepUpMiddleware(cfg)(http.HandlerFunc(riskController.DeleteRule)))).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/margin-health", riskRoles(http.HandlerFunc(marginController.GetMarginHealth))).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/indices", indexController.CreateIndex).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/indices/{id}", indexController.UpdateConstituents).Methods("PUT", "OPTIONS")
 	adminRouter.HandleFunc("/metrics", adminController.GetPlatformMetrics).Methods("GET", "OPTIONS")
 	adminRouter.HandleFunc("/audit/logs", auditController.GetLogs).Methods("GET", "OPTIONS")
 	adminRouter.HandleFunc("/audit/justify", adminController.LogJustification).Methods("POST", "OPTIONS")
@@ -267,7 +376,9 @@ func main() {
 	adminRouter.HandleFunc("/tickets/{id}/status", supportController.UpdateStatus).Methods("PUT", "OPTIONS")
 	adminRouter.HandleFunc("/tickets/{id}/comments", supportController.AddComment).Methods("POST", "OPTIONS")
 	
-	// Public Protected Routes
+	// Public Protected Routes, scoped to the caller's selected trading account
+	protected := authenticated.PathPrefix("").Subrouter()
+	protected.Use(middleware.AccountScope(tradingAccountRepo))
 	protected.HandleFunc("/auth/logout", authController.Logout).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/tickets", supportController.CreateTicket).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/tickets/my", supportController.GetMyTickets).Methods("GET", "OPTIONS")
@@ -304,6 +415,8 @@ func main() {
 	protected.HandleFunc("/user/email/initiate", userController.InitiateEmailUpdate).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/user/email/complete", userController.CompleteEmailUpdate).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/user/onboarding-status", userController.UpdateOnboardingStatus).Methods("PATCH", "OPTIONS")
+	protected.HandleFunc("/user/onboarding/suitability", suitabilityController.GetSuitability).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/user/onboarding/suitability", suitabilityController.SubmitSuitability).Methods("POST", "OPTIONS")
 
 	// Account routes
 	protected.HandleFunc("/account/balance", accountController.GetBalance).Methods("GET", "OPTIONS")
@@ -311,26 +424,94 @@ func main() {
 	protected.HandleFunc("/account/deposit/initiate", accountController.InitiateDeposit).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/account/deposit/complete", accountController.CompleteDeposit).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/account/transactions", accountController.GetTransactions).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/accounts", accountController.ListAccounts).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/accounts", accountController.CreateAccount).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/accounts/transfers", accountController.TransferFunds).Methods("POST", "OPTIONS")
 
 	// Order routes
 	protected.HandleFunc("/orders", orderController.PlaceOrder).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/orders", orderController.GetOrders).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/orders/pending-stops", orderController.GetPendingStops).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/orders/basket", basketController.PlaceBasket).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/orders/{id}", orderController.GetOrder).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/orders/{id}", orderController.ModifyOrder).Methods("PUT", "OPTIONS")
 	protected.HandleFunc("/orders/{id}", orderController.CancelOrder).Methods("DELETE", "OPTIONS")
 
+	// GTT routes (position-level stop-loss / target)
+	protected.HandleFunc("/gtt", gttController.GetTriggers).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/gtt", gttController.CreateTrigger).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/gtt/{id}", gttController.ModifyTrigger).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/gtt/{id}", gttController.CancelTrigger).Methods("DELETE", "OPTIONS")
+
+	// Algo order routes (TWAP / VWAP parent orders)
+	protected.HandleFunc("/algo-orders", algoOrderController.GetAlgoOrders).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/algo-orders", algoOrderController.CreateAlgoOrder).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/algo-orders/{id}", algoOrderController.GetAlgoOrder).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/algo-orders/{id}", algoOrderController.CancelAlgoOrder).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/algo-orders/{id}/pause", algoOrderController.PauseAlgoOrder).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/algo-orders/{id}/resume", algoOrderController.ResumeAlgoOrder).Methods("POST", "OPTIONS")
+
+	// Basket orders and templates
+	protected.HandleFunc("/baskets", basketController.GetTemplates).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/baskets", basketController.CreateTemplate).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/baskets/{id}", basketController.GetTemplate).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/baskets/{id}", basketController.UpdateTemplate).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/baskets/{id}", basketController.DeleteTemplate).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/baskets/{id}/place", basketController.PlaceTemplate).Methods("POST", "OPTIONS")
+
+	// Rebalancing to target weights
+	protected.HandleFunc("/rebalance/preview", rebalanceController.Preview).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/rebalance", rebalanceController.Execute).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/rebalance/models", rebalanceController.GetModels).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/rebalance/models", rebalanceController.CreateModel).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/rebalance/models/{id}", rebalanceController.GetModel).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/rebalance/models/{id}", rebalanceController.UpdateModel).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/rebalance/models/{id}", rebalanceController.DeleteModel).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/rebalance/models/{id}/preview", rebalanceController.PreviewModel).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/rebalance/models/{id}/execute", rebalanceController.ExecuteModel).Methods("POST", "OPTIONS")
+
+	// Systematic investment plans
+	protected.HandleFunc("/sips", sipController.GetPlans).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/sips", sipController.CreatePlan).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/sips/{id}", sipController.GetPlan).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/sips/{id}", sipController.CancelPlan).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/sips/{id}/pause", sipController.PausePlan).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/sips/{id}/resume", sipController.ResumePlan).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/sips/{id}/step-up", sipController.StepUpPlan).Methods("POST", "OPTIONS")
+
 	// Trade routes
 	protected.HandleFunc("/trades", tradeController.GetUserTrades).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/trades/order/{orderId}", tradeController.GetTradesByOrder).Methods("GET", "OPTIONS")
 
+	// Charge schedule (read-only for traders, e.g. brokerage calculators)
+	protected.HandleFunc("/charges", chargeController.GetActiveSchedule).Methods("GET", "OPTIONS")
+
+	// Benchmark & sector indices (levels/candles via the instrument's market data endpoints)
+	protected.HandleFunc("/indices", indexController.GetIndices).Methods("GET", "OPTIONS")
+
+	// Report routes (contract notes & statements)
+	protected.HandleFunc("/reports/contract-notes", reportController.GetContractNotes).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/reports/contract-notes", reportController.GenerateContractNote).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/reports/contract-notes/{id}", reportController.GetContractNote).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/reports/contract-notes/{id}/email", reportController.EmailContractNote).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/reports/statements", reportController.GetStatements).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/reports/statements", reportController.GenerateStatement).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/reports/statements/{id}", reportController.GetStatement).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/reports/statements/{id}/email", reportController.EmailStatement).Methods("POST", "OPTIONS")
+
 	// Portfolio routes
 	protected.HandleFunc("/portfolio/holdings", portfolioController.GetHoldings).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/portfolio/summary", portfolioController.GetSummary).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/portfolio/snapshot", portfolioController.CaptureSnapshot).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/portfolio/history", portfolioController.GetHistory).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/portfolio/performance", portfolioController.GetPerformance).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/portfolio/margin", marginController.GetAccountMargin).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/portfolio/margin-calls", marginController.GetMarginCalls).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/portfolio/borrows", borrowController.GetMyBorrows).Methods("GET", "OPTIONS")
 
 	// Analytics/Diagnostics routes
 	protected.HandleFunc("/diagnostics", analyticsController.GetDiagnostics).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/analytics/spreads", analyticsController.GetSpreadPnL).Methods("GET", "OPTIONS")
 
 	// Notification routes
 	protected.HandleFunc("/notifications", notificationController.GetHistory).Methods("GET", "OPTIONS")
@@ -29,6 +29,14 @@ type Config struct {
 	BrevoSenderName    string
 	BrevoSenderEmail   string
 	EnableStepUpMFA    bool
+
+	// Intraday (MIS) square-off
+	// MISSquareOffMinutes is how long before market close open MIS positions are closed
+	MISSquareOffMinutes int
+	// MISSquareOffWarningMinutes is how long before square-off users are warned
+	MISSquareOffWarningMinutes int
+	// MISSquareOffFee is charged per position closed by the square-off job (0 disables)
+	MISSquareOffFee float64
 }
 
 type FeeConfig struct {
@@ -39,6 +47,9 @@ type FeeConfig struct {
 
 func New() *Config {
 	expiryHours, _ := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
+	squareOffMinutes, _ := strconv.Atoi(getEnv("MIS_SQUAREOFF_MINUTES", "15"))
+	squareOffWarningMinutes, _ := strconv.Atoi(getEnv("MIS_SQUAREOFF_WARNING_MINUTES", "10"))
+	squareOffFee, _ := strconv.ParseFloat(getEnv("MIS_SQUAREOFF_FEE", "0"), 64)
 
 	// Load fees from JSON file
 	commissionRate := 0.0003 // Default 0.03%
@@ -74,6 +85,10 @@ func New() *Config {
 		BrevoSenderName:   getEnv("BREVO_SENDER_NAME", "AEQUIT"),
 		BrevoSenderEmail:  getEnv("BREVO_SENDER_EMAIL", ""),
 		EnableStepUpMFA:   getEnv("ENABLE_STEP_UP_MFA", "false") == "true",
+
+		MISSquareOffMinutes:        squareOffMinutes,
+		MISSquareOffWarningMinutes: squareOffWarningMinutes,
+		MISSquareOffFee:            squareOffFee,
 	}
 }
 
@@ -146,3 +146,76 @@ func (c *AccountController) GetTransactions(w http.ResponseWriter, r *http.Reque
 
 	utils.RespondJSON(w, http.StatusOK, transactions, "Transactions retrieved")
 }
+
+// ListAccounts handles GET /api/accounts
+func (c *AccountController) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	accounts, err := c.accountService.ListAccounts(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, accounts, "Trading accounts retrieved")
+}
+
+type CreateAccountRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateAccount handles POST /api/accounts
+func (c *AccountController) CreateAccount(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req CreateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	account, err := c.accountService.CreateAccount(r.Context(), userID, req.Name)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, account, "Trading account created")
+}
+
+type TransferRequest struct {
+	FromAccountID string  `json:"fromAccountId"`
+	ToAccountID   string  `json:"toAccountId"`
+	Amount        float64 `json:"amount"`
+}
+
+// TransferFunds handles POST /api/accounts/transfers
+func (c *AccountController) TransferFunds(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	from, to, err := c.accountService.TransferFunds(r.Context(), userID, req.FromAccountID, req.ToAccountID, req.Amount)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"from": from, "to": to}, "Funds transferred")
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type AlgoOrderController struct {
+	service *services.AlgoOrderService
+}
+
+func NewAlgoOrderController(service *services.AlgoOrderService) *AlgoOrderController {
+	return &AlgoOrderController{service: service}
+}
+
+func (c *AlgoOrderController) GetAlgoOrders(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	algos, err := c.service.GetUserAlgoOrders(r.Context(), userID, r.URL.Query().Get("status"))
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch algo orders")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, algos, "Algo orders retrieved")
+}
+
+func (c *AlgoOrderController) GetAlgoOrder(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	algo, children, err := c.service.GetAlgoOrder(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"algoOrder":   algo,
+		"childOrders": children,
+	}, "Algo order retrieved")
+}
+
+func (c *AlgoOrderController) CreateAlgoOrder(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.AlgoOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	algo, err := c.service.CreateAlgoOrder(r.Context(), userID, req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, algo, "Algo order started")
+}
+
+func (c *AlgoOrderController) PauseAlgoOrder(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	algo, err := c.service.PauseAlgoOrder(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, algo, "Algo order paused")
+}
+
+func (c *AlgoOrderController) ResumeAlgoOrder(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	algo, err := c.service.ResumeAlgoOrder(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, algo, "Algo order resumed")
+}
+
+func (c *AlgoOrderController) CancelAlgoOrder(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	algo, err := c.service.CancelAlgoOrder(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, algo, "Algo order cancelled")
+}
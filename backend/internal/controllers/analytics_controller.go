@@ -27,7 +27,7 @@ func (c *AnalyticsController) GetDiagnostics(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	results, err := c.analyticsService.GetUserTradeDiagnostics(userID)
+	results, err := c.analyticsService.GetUserTradeDiagnostics(r.Context(), userID)
 	if err != nil {
 		log.Printf("[AnalyticsController] Error fetching diagnostics for user %s: %v", userID, err)
 		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch trade diagnostics: "+err.Error())
@@ -36,3 +36,21 @@ func (c *AnalyticsController) GetDiagnostics(w http.ResponseWriter, r *http.Requ
 
 	utils.RespondJSON(w, http.StatusOK, results, "Trade diagnostics fetched successfully")
 }
+
+// GetSpreadPnL retrieves one combined P&L per filled multi-leg order.
+func (c *AnalyticsController) GetSpreadPnL(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	results, err := c.analyticsService.GetSpreadPnL(r.Context(), userID)
+	if err != nil {
+		log.Printf("[AnalyticsController] Error fetching spread P&L for user %s: %v", userID, err)
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch spread P&L: "+err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, results, "Spread P&L fetched successfully")
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/models"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type BasketController struct {
+	service *services.BasketService
+}
+
+func NewBasketController(service *services.BasketService) *BasketController {
+	return &BasketController{service: service}
+}
+
+type BasketOrderRequest struct {
+	Orders    []models.BasketItem `json:"orders"`
+	AllOrNone bool                `json:"allOrNone"`        // Place nothing unless every order can be placed
+	SaveAs    string              `json:"saveAs,omitempty"` // Also save the orders as a template with this name
+}
+
+func (c *BasketController) PlaceBasket(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req BasketOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if req.SaveAs != "" {
+		items := make([]models.BasketItem, len(req.Orders))
+		copy(items, req.Orders)
+		if _, err := c.service.SaveTemplate(r.Context(), userID, "", services.BasketTemplateRequest{Name: req.SaveAs, Items: items}); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	placement, err := c.service.PlaceBasket(r.Context(), userID, req.Orders, req.AllOrNone)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondPlacement(w, placement)
+}
+
+func (c *BasketController) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	baskets, err := c.service.GetTemplates(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch baskets")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, baskets, "Baskets retrieved")
+}
+
+func (c *BasketController) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	basket, err := c.service.GetTemplate(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, basket, "Basket retrieved")
+}
+
+func (c *BasketController) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	c.saveTemplate(w, r, "", http.StatusCreated, "Basket saved")
+}
+
+func (c *BasketController) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	c.saveTemplate(w, r, mux.Vars(r)["id"], http.StatusOK, "Basket updated")
+}
+
+func (c *BasketController) saveTemplate(w http.ResponseWriter, r *http.Request, basketID string, status int, message string) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.BasketTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	basket, err := c.service.SaveTemplate(r.Context(), userID, basketID, req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, status, basket, message)
+}
+
+func (c *BasketController) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := c.service.DeleteTemplate(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, nil, "Basket deleted")
+}
+
+func (c *BasketController) PlaceTemplate(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		AllOrNone bool `json:"allOrNone"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	placement, err := c.service.PlaceTemplate(r.Context(), userID, mux.Vars(r)["id"], req.AllOrNone)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondPlacement(w, placement)
+}
+
+// respondPlacement reports a basket as created when anything was placed, otherwise as unprocessable with its per-order results
+func respondPlacement(w http.ResponseWriter, placement *models.BasketPlacement) {
+	if placement.Placed == 0 {
+		utils.RespondJSON(w, http.StatusUnprocessableEntity, placement, "No orders in the basket were placed")
+		return
+	}
+	message := "Basket placed"
+	if placement.Failed > 0 {
+		message = "Basket partially placed"
+	}
+	utils.RespondJSON(w, http.StatusCreated, placement, message)
+}
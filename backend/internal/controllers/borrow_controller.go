@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/models"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type BorrowController struct {
+	service      *services.BorrowService
+	orderService *services.OrderService
+}
+
+func NewBorrowController(service *services.BorrowService, orderService *services.OrderService) *BorrowController {
+	return &BorrowController{service: service, orderService: orderService}
+}
+
+type borrowActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (c *BorrowController) GetMyBorrows(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	borrows, err := c.service.GetUserBorrows(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch borrows")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, borrows, "Borrows retrieved")
+}
+
+func (c *BorrowController) GetInventory(w http.ResponseWriter, r *http.Request) {
+	inventory, err := c.service.GetInventory(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch borrow inventory")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, inventory, "Borrow inventory retrieved")
+}
+
+func (c *BorrowController) UpdateInventory(w http.ResponseWriter, r *http.Request) {
+	var req models.BorrowInventory
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	inventory, err := c.service.UpdateInventory(r.Context(), mux.Vars(r)["instrumentId"], req, middleware.GetUserID(r))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, inventory, "Borrow inventory updated")
+}
+
+func (c *BorrowController) ListBorrows(w http.ResponseWriter, r *http.Request) {
+	borrows, err := c.service.ListBorrows(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch borrows")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, borrows, "Borrows retrieved")
+}
+
+func (c *BorrowController) RecallBorrow(w http.ResponseWriter, r *http.Request) {
+	var req borrowActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reason == "" {
+		utils.RespondError(w, http.StatusBadRequest, "A reason is required")
+		return
+	}
+
+	borrow, err := c.service.Recall(r.Context(), mux.Vars(r)["id"], req.Reason)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, borrow, "Borrow recalled")
+}
+
+func (c *BorrowController) BuyIn(w http.ResponseWriter, r *http.Request) {
+	var req borrowActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reason == "" {
+		utils.RespondError(w, http.StatusBadRequest, "A reason is required")
+		return
+	}
+
+	order, err := c.orderService.ExecuteBuyIn(r.Context(), mux.Vars(r)["id"], req.Reason)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, order, "Buy-in executed")
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/models"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+)
+
+type ChargeController struct {
+	service *services.ChargeService
+}
+
+func NewChargeController(service *services.ChargeService) *ChargeController {
+	return &ChargeController{service: service}
+}
+
+func (c *ChargeController) GetActiveSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := c.service.GetActiveSchedule(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch charge schedule")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, schedule, "Charge schedule retrieved")
+}
+
+func (c *ChargeController) GetScheduleHistory(w http.ResponseWriter, r *http.Request) {
+	schedules, err := c.service.GetScheduleHistory(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch charge schedule history")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, schedules, "Charge schedule history retrieved")
+}
+
+func (c *ChargeController) PublishSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule models.ChargeSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminID := middleware.GetUserID(r)
+	saved, err := c.service.PublishSchedule(r.Context(), &schedule, adminID)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, saved, "Charge schedule published")
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type GTTController struct {
+	service *services.GTTService
+}
+
+func NewGTTController(service *services.GTTService) *GTTController {
+	return &GTTController{service: service}
+}
+
+func (c *GTTController) GetTriggers(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	triggers, err := c.service.GetUserTriggers(r.Context(), userID, r.URL.Query().Get("status"))
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch GTT triggers")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, triggers, "GTT triggers retrieved")
+}
+
+func (c *GTTController) CreateTrigger(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.GTTRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	trigger, err := c.service.CreateTrigger(r.Context(), userID, req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, trigger, "GTT created")
+}
+
+func (c *GTTController) ModifyTrigger(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.GTTRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	trigger, err := c.service.ModifyTrigger(r.Context(), userID, mux.Vars(r)["id"], req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, trigger, "GTT modified")
+}
+
+func (c *GTTController) CancelTrigger(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	trigger, err := c.service.CancelTrigger(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, trigger, "GTT cancelled")
+}
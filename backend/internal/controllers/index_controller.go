@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type IndexController struct {
+	service *services.IndexService
+}
+
+func NewIndexController(service *services.IndexService) *IndexController {
+	return &IndexController{service: service}
+}
+
+func (c *IndexController) GetIndices(w http.ResponseWriter, r *http.Request) {
+	indices, err := c.service.GetIndices(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch indices")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, indices, "Indices retrieved")
+}
+
+func (c *IndexController) CreateIndex(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateIndexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	index, err := c.service.CreateIndex(r.Context(), req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, index, "Index created")
+}
+
+func (c *IndexController) UpdateConstituents(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateIndexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	index, err := c.service.UpdateConstituents(r.Context(), mux.Vars(r)["id"], req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, index, "Index rebalanced")
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/models"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type MarginController struct {
+	service *services.MarginService
+}
+
+func NewMarginController(service *services.MarginService) *MarginController {
+	return &MarginController{service: service}
+}
+
+func (c *MarginController) GetAccountMargin(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	margin, err := c.service.GetAccountMargin(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, margin, "Margin summary retrieved")
+}
+
+func (c *MarginController) GetMarginCalls(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	calls, err := c.service.GetMarginCalls(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch margin calls")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, calls, "Margin calls retrieved")
+}
+
+func (c *MarginController) GetRiskParams(w http.ResponseWriter, r *http.Request) {
+	params, err := c.service.ListRiskParams(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch risk parameters")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"defaults":  services.DefaultRiskParams(),
+		"overrides": params,
+	}, "Risk parameters retrieved")
+}
+
+func (c *MarginController) UpdateRiskParams(w http.ResponseWriter, r *http.Request) {
+	var params models.InstrumentRiskParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	saved, err := c.service.UpdateRiskParams(r.Context(), mux.Vars(r)["instrumentId"], params, middleware.GetUserID(r))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, saved, "Risk parameters updated")
+}
+
+func (c *MarginController) GetMarginHealth(w http.ResponseWriter, r *http.Request) {
+	health, err := c.service.ListMarginHealth(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, health, "Margin health retrieved")
+}
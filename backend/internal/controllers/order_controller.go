@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
 	"net/http"
 	"strconv"
@@ -34,12 +35,19 @@ type OrderRequest struct {
 	Price         *float64 `json:"price,omitempty"`
 	ClientOrderID string   `json:"clientOrderId"`
 
+	DisclosedQuantity int `json:"disclosedQuantity,omitempty"` // Iceberg LIMIT orders: quantity shown at a time
+
 	// Stop Order Fields
 	StopPrice   *float64 `json:"stopPrice,omitempty"`
 	LimitPrice  *float64 `json:"limitPrice,omitempty"`
 	TrailAmount *float64 `json:"trailAmount,omitempty"`
 	TrailType   string   `json:"trailType,omitempty"`
 	Intent      string   `json:"intent,omitempty"`
+	Netting     bool     `json:"netting,omitempty"` // Close an opposite position and open the remainder in one order
+	ProductType string   `json:"productType,omitempty"`
+
+	Condition *models.OrderCondition `json:"condition,omitempty"` // CONDITIONAL orders: fires a MARKET order, or LIMIT at limitPrice
+	Legs      []models.OrderLeg      `json:"legs,omitempty"`      // MULTI_LEG orders: quantity is spread units, price the net limit per unit
 }
 
 func (c *OrderController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
@@ -57,7 +65,7 @@ func (c *OrderController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 
 	// Convert DTO to model
 	instrID, err := primitive.ObjectIDFromHex(req.InstrumentID)
-	if err != nil {
+	if err != nil && req.OrderType != "MULTI_LEG" { // Multi-leg orders name their instruments per leg
 		utils.RespondError(w, http.StatusBadRequest, "Invalid instrument ID")
 		return
 	}
@@ -72,20 +80,31 @@ func (c *OrderController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 		ClientOrderID: req.ClientOrderID,
 		Source:        "UI",
 
+		DisclosedQuantity: req.DisclosedQuantity,
+
 		// Stop Order Fields
 		StopPrice:   req.StopPrice,
 		LimitPrice:  req.LimitPrice,
 		TrailAmount: req.TrailAmount,
 		TrailType:   req.TrailType,
 		Intent:      req.Intent,
+		Netting:     req.Netting,
+		ProductType: req.ProductType,
+		Condition:   req.Condition,
+		Legs:        req.Legs,
 	}
 
 	res, err := c.orderService.PlaceOrder(r.Context(), userID, order)
 	if err != nil {
 		// Map errors to appropriate status codes
 		errMsg := err.Error()
-		if strings.Contains(errMsg, "insufficient balance") {
+		var riskErr *services.RiskRejectionError
+		if errors.As(err, &riskErr) {
+			utils.RespondJSON(w, http.StatusUnprocessableEntity, riskErr, errMsg)
+		} else if strings.Contains(errMsg, "insufficient balance") {
 			utils.RespondError(w, http.StatusForbidden, errMsg)
+		} else if strings.Contains(errMsg, "client order ID") {
+			utils.RespondError(w, http.StatusConflict, errMsg)
 		} else if strings.Contains(errMsg, "duplicate") || strings.Contains(errMsg, "E11000") {
 			utils.RespondError(w, http.StatusConflict, "Duplicate order detected (Idempotency check failed)")
 		} else {
@@ -94,6 +113,10 @@ func (c *OrderController) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if res.Replayed {
+		utils.RespondJSON(w, http.StatusOK, res, "Order already placed for this client order ID")
+		return
+	}
 	utils.RespondJSON(w, http.StatusCreated, res, "Order placed successfully")
 }
 
@@ -162,6 +185,27 @@ func (c *OrderController) GetOrders(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, response, "Orders fetched successfully")
 }
 
+func (c *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	detail, err := c.orderService.GetOrder(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		switch err.Error() {
+		case "order not found", "unauthorized":
+			utils.RespondError(w, http.StatusNotFound, "Order not found")
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch order timeline")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, detail, "Order fetched successfully")
+}
+
 func (c *OrderController) CancelOrder(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r)
 	if userID == "" {
@@ -188,6 +232,12 @@ func (c *OrderController) CancelOrder(w http.ResponseWriter, r *http.Request) {
 type ModifyOrderRequest struct {
 	Quantity int      `json:"quantity"`
 	Price    *float64 `json:"price,omitempty"`
+
+	// Pending stop orders
+	StopPrice   *float64 `json:"stopPrice,omitempty"`
+	LimitPrice  *float64 `json:"limitPrice,omitempty"`
+	TrailAmount *float64 `json:"trailAmount,omitempty"`
+	TrailType   string   `json:"trailType,omitempty"`
 }
 
 func (c *OrderController) ModifyOrder(w http.ResponseWriter, r *http.Request) {
@@ -210,7 +260,14 @@ func (c *OrderController) ModifyOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := c.orderService.ModifyOrder(r.Context(), userID, orderID, req.Quantity, req.Price)
+	order, err := c.orderService.ModifyOrder(r.Context(), userID, orderID, services.OrderAmendment{
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		StopPrice:   req.StopPrice,
+		LimitPrice:  req.LimitPrice,
+		TrailAmount: req.TrailAmount,
+		TrailType:   req.TrailType,
+	})
 	if err != nil {
 		utils.RespondError(w, http.StatusBadRequest, err.Error())
 		return
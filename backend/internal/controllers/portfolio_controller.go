@@ -3,6 +3,8 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"aequitas/internal/middleware"
 	"aequitas/internal/services"
@@ -10,12 +12,14 @@ import (
 )
 
 type PortfolioController struct {
-	portfolioService *services.PortfolioService
+	portfolioService   *services.PortfolioService
+	performanceService *services.PerformanceService
 }
 
-func NewPortfolioController(portfolioService *services.PortfolioService) *PortfolioController {
+func NewPortfolioController(portfolioService *services.PortfolioService, performanceService *services.PerformanceService) *PortfolioController {
 	return &PortfolioController{
-		portfolioService: portfolioService,
+		portfolioService:   portfolioService,
+		performanceService: performanceService,
 	}
 }
 
@@ -180,3 +184,57 @@ func (c *PortfolioController) GetHistory(w http.ResponseWriter, r *http.Request)
 
 	utils.RespondJSON(w, http.StatusOK, history, "Portfolio history retrieved")
 }
+
+// GetPerformance handles GET /api/portfolio/performance?from=YYYY-MM-DD&to=YYYY-MM-DD&window=20&riskFree=0.065
+func (c *PortfolioController) GetPerformance(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	now := utils.GetISTTime()
+	query := services.PerformanceQuery{
+		From:         now.AddDate(-1, 0, 0),
+		To:           now,
+		Window:       20,
+		RiskFreeRate: 0.065,
+	}
+
+	params := r.URL.Query()
+	if fromStr := params.Get("from"); fromStr != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromStr, now.Location())
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		query.From = from
+	}
+	if toStr := params.Get("to"); toStr != "" {
+		to, err := time.ParseInLocation("2006-01-02", toStr, now.Location())
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		query.To = to.AddDate(0, 0, 1).Add(-time.Nanosecond) // Inclusive of the whole day
+	}
+	if windowStr := params.Get("window"); windowStr != "" {
+		if window, err := strconv.Atoi(windowStr); err == nil && window > 1 {
+			query.Window = window
+		}
+	}
+	if rfStr := params.Get("riskFree"); rfStr != "" {
+		if rf, err := strconv.ParseFloat(rfStr, 64); err == nil {
+			query.RiskFreeRate = rf
+		}
+	}
+	query.Benchmark = params.Get("benchmark")
+
+	report, err := c.performanceService.GetPerformance(r.Context(), userID, query)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report, "Portfolio performance retrieved")
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type RebalanceController struct {
+	service *services.RebalanceService
+}
+
+func NewRebalanceController(service *services.RebalanceService) *RebalanceController {
+	return &RebalanceController{service: service}
+}
+
+type RebalanceExecuteRequest struct {
+	services.RebalanceRequest
+	AllOrNone bool `json:"allOrNone"`
+}
+
+func (c *RebalanceController) Preview(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.RebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	plan, err := c.service.Preview(r.Context(), userID, req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan, "Rebalance preview computed")
+}
+
+func (c *RebalanceController) Execute(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req RebalanceExecuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	plan, placement, err := c.service.Rebalance(r.Context(), userID, req.RebalanceRequest, req.AllOrNone)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{"plan": plan, "basket": placement}, "Rebalance submitted")
+}
+
+func (c *RebalanceController) GetModels(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	rebalanceModels, err := c.service.GetModels(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch rebalance models")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, rebalanceModels, "Rebalance models retrieved")
+}
+
+func (c *RebalanceController) GetModel(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	model, err := c.service.GetModel(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, model, "Rebalance model retrieved")
+}
+
+func (c *RebalanceController) CreateModel(w http.ResponseWriter, r *http.Request) {
+	c.saveModel(w, r, "", http.StatusCreated, "Rebalance model saved")
+}
+
+func (c *RebalanceController) UpdateModel(w http.ResponseWriter, r *http.Request) {
+	c.saveModel(w, r, mux.Vars(r)["id"], http.StatusOK, "Rebalance model updated")
+}
+
+func (c *RebalanceController) saveModel(w http.ResponseWriter, r *http.Request, modelID string, status int, message string) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.RebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	model, err := c.service.SaveModel(r.Context(), userID, modelID, req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, status, model, message)
+}
+
+func (c *RebalanceController) DeleteModel(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := c.service.DeleteModel(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, nil, "Rebalance model deleted")
+}
+
+func (c *RebalanceController) PreviewModel(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	plan, err := c.service.PreviewModel(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan, "Rebalance preview computed")
+}
+
+func (c *RebalanceController) ExecuteModel(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		AllOrNone bool `json:"allOrNone"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	plan, placement, err := c.service.ExecuteModel(r.Context(), userID, mux.Vars(r)["id"], req.AllOrNone)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{"plan": plan, "basket": placement}, "Rebalance submitted")
+}
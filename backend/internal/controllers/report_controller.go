@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type ReportController struct {
+	service *services.ReportingService
+}
+
+func NewReportController(service *services.ReportingService) *ReportController {
+	return &ReportController{service: service}
+}
+
+func (c *ReportController) GetContractNotes(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	notes, err := c.service.GetContractNotes(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, notes, "Contract notes fetched successfully")
+}
+
+// GetContractNote returns a note as JSON, or as a download when ?format=html|pdf|csv
+func (c *ReportController) GetContractNote(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	note, err := c.service.GetContractNote(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondReportError(w, err)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "html":
+		writeDownload(w, "text/html; charset=utf-8", note.NoteNumber+".html", []byte(services.RenderContractNoteHTML(note)))
+	case "pdf":
+		writeDownload(w, "application/pdf", note.NoteNumber+".pdf", services.RenderContractNotePDF(note))
+	case "csv":
+		writeDownload(w, "text/csv", note.NoteNumber+".csv", services.RenderContractNoteCSV(note))
+	default:
+		utils.RespondJSON(w, http.StatusOK, note, "Contract note fetched successfully")
+	}
+}
+
+// GenerateContractNote (re)builds the caller's note for a date (defaults to today)
+func (c *ReportController) GenerateContractNote(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	day := utils.GetISTTime()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, day.Location())
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+		day = parsed
+	}
+
+	note, err := c.service.GenerateContractNote(r.Context(), userID, day)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if note == nil {
+		utils.RespondError(w, http.StatusNotFound, "No trades executed on this date")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, note, "Contract note generated successfully")
+}
+
+func (c *ReportController) EmailContractNote(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	note, err := c.service.GetContractNote(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondReportError(w, err)
+		return
+	}
+
+	if err := c.service.EmailContractNote(r.Context(), note); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to send email: "+err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, nil, "Contract note emailed successfully")
+}
+
+func (c *ReportController) GetStatements(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	statements, err := c.service.GetStatements(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, statements, "Statements fetched successfully")
+}
+
+// GenerateStatement builds the caller's statement for a month (body: {"period": "YYYY-MM"}, defaults to current month)
+func (c *ReportController) GenerateStatement(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Period string `json:"period"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	month := utils.GetISTTime()
+	if req.Period != "" {
+		parsed, err := time.ParseInLocation("2006-01", req.Period, month.Location())
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid period, expected YYYY-MM")
+			return
+		}
+		month = parsed
+	}
+
+	stmt, err := c.service.GenerateStatement(r.Context(), userID, month)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, stmt, "Statement generated successfully")
+}
+
+// GetStatement returns a statement as JSON, or as a download when ?format=html|pdf|csv
+func (c *ReportController) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	stmt, err := c.service.GetStatement(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondReportError(w, err)
+		return
+	}
+
+	filename := "statement-" + stmt.Period
+	switch r.URL.Query().Get("format") {
+	case "html":
+		writeDownload(w, "text/html; charset=utf-8", filename+".html", []byte(services.RenderStatementHTML(stmt)))
+	case "pdf":
+		writeDownload(w, "application/pdf", filename+".pdf", services.RenderStatementPDF(stmt))
+	case "csv":
+		writeDownload(w, "text/csv", filename+".csv", services.RenderStatementCSV(stmt))
+	default:
+		utils.RespondJSON(w, http.StatusOK, stmt, "Statement fetched successfully")
+	}
+}
+
+func (c *ReportController) EmailStatement(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	stmt, err := c.service.GetStatement(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondReportError(w, err)
+		return
+	}
+
+	if err := c.service.EmailStatement(r.Context(), stmt); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to send email: "+err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, nil, "Statement emailed successfully")
+}
+
+func respondReportError(w http.ResponseWriter, err error) {
+	if err.Error() == "unauthorized" {
+		utils.RespondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+	utils.RespondError(w, http.StatusNotFound, err.Error())
+}
+
+func writeDownload(w http.ResponseWriter, contentType, filename string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
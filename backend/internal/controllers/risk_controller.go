@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/models"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type RiskController struct {
+	service *services.RiskEngineService
+}
+
+func NewRiskController(service *services.RiskEngineService) *RiskController {
+	return &RiskController{service: service}
+}
+
+func (c *RiskController) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := c.service.GetDashboard(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to load risk dashboard")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, dashboard, "Risk dashboard retrieved")
+}
+
+func (c *RiskController) GetRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := c.service.ListRules(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch risk rules")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, rules, "Risk rules retrieved")
+}
+
+func (c *RiskController) UpsertRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.RiskRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	saved, err := c.service.UpsertRule(r.Context(), rule, middleware.GetUserID(r))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, saved, "Risk rule saved")
+}
+
+func (c *RiskController) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := c.service.DeleteRule(r.Context(), mux.Vars(r)["id"]); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, nil, "Risk rule deleted")
+}
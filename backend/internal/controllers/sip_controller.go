@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type SIPController struct {
+	service *services.SIPService
+}
+
+func NewSIPController(service *services.SIPService) *SIPController {
+	return &SIPController{service: service}
+}
+
+func (c *SIPController) GetPlans(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	plans, err := c.service.GetUserPlans(r.Context(), userID, r.URL.Query().Get("status"))
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch SIPs")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plans, "SIPs retrieved")
+}
+
+func (c *SIPController) GetPlan(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	plan, executions, err := c.service.GetPlan(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"sip":        plan,
+		"executions": executions,
+	}, "SIP retrieved")
+}
+
+func (c *SIPController) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.SIPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	plan, err := c.service.CreatePlan(r.Context(), userID, req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, plan, "SIP created")
+}
+
+func (c *SIPController) PausePlan(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	plan, err := c.service.PausePlan(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan, "SIP paused")
+}
+
+func (c *SIPController) ResumePlan(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	plan, err := c.service.ResumePlan(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan, "SIP resumed")
+}
+
+func (c *SIPController) StepUpPlan(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req services.SIPStepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	plan, err := c.service.StepUpPlan(r.Context(), userID, mux.Vars(r)["id"], req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan, "SIP stepped up")
+}
+
+func (c *SIPController) CancelPlan(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	plan, err := c.service.CancelPlan(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan, "SIP cancelled")
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aequitas/internal/middleware"
+	"aequitas/internal/models"
+	"aequitas/internal/services"
+	"aequitas/internal/utils"
+)
+
+type SuitabilityController struct {
+	service *services.SuitabilityService
+}
+
+func NewSuitabilityController(service *services.SuitabilityService) *SuitabilityController {
+	return &SuitabilityController{service: service}
+}
+
+type SubmitSuitabilityRequest struct {
+	Answers           models.SuitabilityAnswers `json:"answers"`
+	DisclosureVersion string                    `json:"disclosureVersion"` // Version of the disclosure the user accepted
+}
+
+// GetSuitability handles GET /api/user/onboarding/suitability
+func (c *SuitabilityController) GetSuitability(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := c.service.GetStatus(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status, "Suitability status retrieved")
+}
+
+// SubmitSuitability handles POST /api/user/onboarding/suitability
+func (c *SuitabilityController) SubmitSuitability(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r)
+	if userID == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req SubmitSuitabilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	profile, err := c.service.Submit(r.Context(), userID, req.Answers, req.DisclosureVersion, r.RemoteAddr)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, profile, "Risk disclosure accepted")
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+)
+
+// AccountIDHeader selects which of the user's trading accounts a request acts on
+const AccountIDHeader = "X-Account-ID"
+
+// AccountScope scopes authenticated requests to one of the caller's trading accounts, taken from the
+// X-Account-ID header or accountId query parameter and defaulting to the user's default account
+func AccountScope(accountRepo *repositories.TradingAccountRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := GetUserID(r)
+			if userID == "" || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			accountID := r.Header.Get(AccountIDHeader)
+			if accountID == "" {
+				accountID = r.URL.Query().Get("accountId")
+			}
+
+			if accountID == "" {
+				// No explicit account: pin the default so reads and writes agree. Users without any
+				// account yet stay unscoped and get one created lazily.
+				account, err := accountRepo.FindByUserID(r.Context(), userID)
+				if err != nil {
+					utils.RespondError(w, http.StatusInternalServerError, "Failed to resolve trading account")
+					return
+				}
+				if account != nil {
+					r = r.WithContext(utils.WithAccountID(r.Context(), account.ID.Hex()))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if _, err := primitive.ObjectIDFromHex(accountID); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "Invalid account ID")
+				return
+			}
+			account, err := accountRepo.FindByID(r.Context(), accountID)
+			if err != nil {
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to resolve trading account")
+				return
+			}
+			if account == nil || account.UserID.Hex() != userID {
+				utils.RespondError(w, http.StatusNotFound, "Trading account not found")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(utils.WithAccountID(r.Context(), account.ID.Hex())))
+		})
+	}
+}
+
+// GetAccountID returns the trading account the request is scoped to
+func GetAccountID(r *http.Request) string {
+	return utils.AccountIDFromContext(r.Context())
+}
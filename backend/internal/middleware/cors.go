@@ -25,7 +25,7 @@ func CORS(allowedOrigins []string) mux.MiddlewareFunc {
 				// log.Printf("[CORS] Match: %s %s", r.Method, origin)
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Account-ID")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			} else {
 				// log.Printf("[CORS] Mismatch: %s %s (Allowed: %v)", r.Method, origin, allowedOrigins)
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+)
+
+// IdempotencyKeyHeader lets clients retry a mutating request safely
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency replays the stored response when an authenticated mutating request repeats an Idempotency-Key
+// for the same trading account within the retention window. A key reused with a different request is rejected; server errors and
+// authorization failures free the key so the request can be retried once fixed.
+func Idempotency(repo *repositories.IdempotencyRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, err := primitive.ObjectIDFromHex(GetUserID(r))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				utils.RespondError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Runs ahead of AccountScope, so take the account as requested; the default account is its own scope
+			accountScope := r.Header.Get(AccountIDHeader)
+			if accountScope == "" {
+				accountScope = r.URL.Query().Get("accountId")
+			}
+			sum := sha256.Sum256(append([]byte(accountScope+" "+r.Method+" "+r.URL.Path+"\n"), body...))
+
+			record := &models.IdempotencyKey{
+				UserID:       userID,
+				AccountScope: accountScope,
+				Key:          key,
+				Method:       r.Method,
+				Path:         r.URL.Path,
+				RequestHash:  hex.EncodeToString(sum[:]),
+			}
+			reserved, err := repo.Reserve(r.Context(), record)
+			if err != nil {
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to record Idempotency-Key")
+				return
+			}
+			if !reserved {
+				replay(w, r, repo, record)
+				return
+			}
+
+			// A panicking handler frees the key before ErrorHandler recovers, so retries aren't stuck behind it
+			defer func() {
+				if p := recover(); p != nil {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					if err := repo.Release(ctx, record.ID); err != nil {
+						log.Printf("Failed to release Idempotency-Key %s after panic: %v", key, err)
+					}
+					panic(p)
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// The request's own context may already be cancelled once the response is written
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if rec.status >= http.StatusInternalServerError || rec.status == http.StatusUnauthorized ||
+				rec.status == http.StatusForbidden || rec.status == http.StatusTooManyRequests {
+				err = repo.Release(ctx, record.ID)
+			} else {
+				err = repo.Complete(ctx, record.ID, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes())
+			}
+			if err != nil {
+				log.Printf("Failed to store response for Idempotency-Key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+// replay answers a repeated key with the original response
+func replay(w http.ResponseWriter, r *http.Request, repo *repositories.IdempotencyRepository, record *models.IdempotencyKey) {
+	existing, err := repo.Find(r.Context(), record.UserID, record.AccountScope, record.Key)
+	if err != nil || existing == nil {
+		utils.RespondError(w, http.StatusConflict, "Idempotency-Key is being processed; retry shortly")
+		return
+	}
+	if existing.RequestHash != record.RequestHash {
+		utils.RespondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if existing.Status != models.IdempotencyCompleted {
+		utils.RespondError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	if existing.ContentType != "" {
+		w.Header().Set("Content-Type", existing.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(existing.ResponseCode)
+	_, _ = w.Write(existing.ResponseBody)
+}
+
+// responseRecorder captures the status and body written through it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
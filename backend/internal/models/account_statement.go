@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StatementEntry is a single ledger movement within a statement period
+type StatementEntry struct {
+	Date      time.Time `bson:"date" json:"date"`
+	Type      string    `bson:"type" json:"type"` // DEPOSIT, WITHDRAWAL, TRANSFER_IN, TRANSFER_OUT, TRADE, ADJUSTMENT, FEE
+	Reference string    `bson:"reference" json:"reference"`
+	Amount    float64   `bson:"amount" json:"amount"`   // Signed
+	Balance   float64   `bson:"balance" json:"balance"` // Running balance after this entry
+}
+
+// AccountStatement reconciles the cash ledger for one calendar month
+type AccountStatement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID   primitive.ObjectID `bson:"account_id" json:"accountId"`
+	Period      string             `bson:"period" json:"period"` // YYYY-MM
+	PeriodStart time.Time          `bson:"period_start" json:"periodStart"`
+	PeriodEnd   time.Time          `bson:"period_end" json:"periodEnd"` // Exclusive
+
+	// Reconciliation: Opening + Deposits - Withdrawals + TransfersIn - TransfersOut + Settlements + Adjustments - Fees + Other = Closing
+	OpeningBalance   float64 `bson:"opening_balance" json:"openingBalance"`
+	Deposits         float64 `bson:"deposits" json:"deposits"`
+	Withdrawals      float64 `bson:"withdrawals" json:"withdrawals"`
+	TransfersIn      float64 `bson:"transfers_in" json:"transfersIn"`   // From the user's other trading accounts
+	TransfersOut     float64 `bson:"transfers_out" json:"transfersOut"` // To the user's other trading accounts
+	TradeSettlements float64 `bson:"trade_settlements" json:"tradeSettlements"`
+	Adjustments      float64 `bson:"adjustments" json:"adjustments"`
+	Fees             float64 `bson:"fees" json:"fees"`
+	Other            float64 `bson:"other" json:"other"` // Signed total of ledger types the statement has no line for
+	ClosingBalance   float64 `bson:"closing_balance" json:"closingBalance"`
+
+	Entries []StatementEntry `bson:"entries" json:"entries"`
+
+	EmailedAt *time.Time `bson:"emailed_at,omitempty" json:"emailedAt,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updatedAt"`
+}
@@ -11,6 +11,7 @@ import (
 type ActiveTradeUnit struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID    primitive.ObjectID `bson:"account_id" json:"accountId"`
 	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
 	Symbol       string             `bson:"symbol" json:"symbol"`
 	Side         string             `bson:"side" json:"side"` // LONG or SHORT
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Algo strategies
+const (
+	AlgoStrategyTWAP = "TWAP" // Equal slices over the duration
+	AlgoStrategyVWAP = "VWAP" // Slices follow the historical intraday volume curve
+)
+
+// Algo order statuses
+const (
+	AlgoStatusActive    = "ACTIVE"
+	AlgoStatusPaused    = "PAUSED"
+	AlgoStatusCompleted = "COMPLETED"
+	AlgoStatusCancelled = "CANCELLED"
+	AlgoStatusExpired   = "EXPIRED" // Schedule ended before the full quantity filled
+	AlgoStatusFailed    = "FAILED"  // Too many child orders were rejected
+)
+
+// Volume curve sources
+const (
+	AlgoCurveHistorical = "HISTORICAL"
+	AlgoCurveUniform    = "UNIFORM"
+)
+
+// AlgoOrder is a parent order worked over time by child LIMIT/MARKET orders that link back through ParentOrderID
+type AlgoOrder struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID    primitive.ObjectID `bson:"account_id" json:"accountId"`
+	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol       string             `bson:"symbol" json:"symbol"`
+
+	Strategy    string `bson:"strategy" json:"strategy"` // TWAP / VWAP
+	Side        string `bson:"side" json:"side"`         // BUY / SELL
+	Intent      string `bson:"intent,omitempty" json:"intent,omitempty"`
+	ProductType string `bson:"product_type,omitempty" json:"productType,omitempty"`
+	Quantity    int    `bson:"quantity" json:"quantity"`
+	LotSize     int    `bson:"lot_size" json:"lotSize"`
+
+	ChildOrderType   string   `bson:"child_order_type" json:"childOrderType"`            // MARKET / LIMIT
+	LimitPrice       *float64 `bson:"limit_price,omitempty" json:"limitPrice,omitempty"` // Price of LIMIT children
+	DurationMinutes  int      `bson:"duration_minutes" json:"durationMinutes"`
+	SliceSeconds     int      `bson:"slice_seconds" json:"sliceSeconds"`
+	MaxParticipation float64  `bson:"max_participation,omitempty" json:"maxParticipation,omitempty"` // Max fraction of market volume per slice; 0 is uncapped
+
+	// Curve is the cumulative fraction of the quantity due by the end of each slice
+	Curve       []float64 `bson:"curve" json:"curve"`
+	CurveSource string    `bson:"curve_source" json:"curveSource"` // HISTORICAL / UNIFORM
+
+	ArrivalPrice    float64 `bson:"arrival_price" json:"arrivalPrice"` // LTP when the algo was submitted
+	FilledQuantity  int     `bson:"filled_quantity" json:"filledQuantity"`
+	WorkingQuantity int     `bson:"working_quantity" json:"workingQuantity"` // Unfilled quantity of live children
+	AvgFillPrice    float64 `bson:"avg_fill_price" json:"avgFillPrice"`
+
+	LastSlice      int `bson:"last_slice" json:"lastSlice"` // Index of the last slice released; -1 before the first
+	ChildCount     int `bson:"child_count" json:"childCount"`
+	RejectedStreak int `bson:"rejected_streak" json:"rejectedStreak"`
+
+	Status       string `bson:"status" json:"status"`
+	StatusReason string `bson:"status_reason,omitempty" json:"statusReason,omitempty"`
+
+	StartAt   time.Time     `bson:"start_at" json:"startAt"`
+	EndAt     time.Time     `bson:"end_at" json:"endAt"` // Pushed back by the time spent paused
+	PausedAt  *time.Time    `bson:"paused_at,omitempty" json:"pausedAt,omitempty"`
+	PausedFor time.Duration `bson:"paused_for" json:"-"`
+	EndedAt   *time.Time    `bson:"ended_at,omitempty" json:"endedAt,omitempty"`
+	CreatedAt time.Time     `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updatedAt"`
+}
+
+// AlgoMaxRejectedChildren is how many child orders in a row may be rejected before the algo fails
+const AlgoMaxRejectedChildren = 3
+
+// AlgoProgress compares an algo's execution with its schedule and arrival price
+type AlgoProgress struct {
+	ScheduledQuantity int     `json:"scheduledQuantity"`
+	FilledQuantity    int     `json:"filledQuantity"`
+	WorkingQuantity   int     `json:"workingQuantity"`
+	RemainingQuantity int     `json:"remainingQuantity"`
+	PercentScheduled  float64 `json:"percentScheduled"`
+	PercentFilled     float64 `json:"percentFilled"`
+	ScheduleVariance  int     `json:"scheduleVariance"` // Filled minus scheduled; negative is behind schedule
+	SlippageBps       float64 `json:"slippageBps"`      // Average fill vs arrival price; positive is worse
+	SlippageAmount    float64 `json:"slippageAmount"`
+}
+
+// IsOpen reports whether the algo can still release child orders
+func (a *AlgoOrder) IsOpen() bool {
+	return a.Status == AlgoStatusActive || a.Status == AlgoStatusPaused
+}
+
+// elapsed is the schedule time that has run at 'now', excluding time spent paused
+func (a *AlgoOrder) elapsed(now time.Time) time.Duration {
+	if a.PausedAt != nil {
+		now = *a.PausedAt
+	}
+	if a.EndedAt != nil && a.EndedAt.Before(now) {
+		now = *a.EndedAt
+	}
+	if d := now.Sub(a.StartAt) - a.PausedFor; d > 0 {
+		return d
+	}
+	return 0
+}
+
+// SliceIndex is the slice the schedule is in at 'now', capped at the last slice
+func (a *AlgoOrder) SliceIndex(now time.Time) int {
+	if len(a.Curve) == 0 || a.SliceSeconds <= 0 {
+		return 0
+	}
+	k := int(a.elapsed(now) / (time.Duration(a.SliceSeconds) * time.Second))
+	if k >= len(a.Curve) {
+		k = len(a.Curve) - 1
+	}
+	return k
+}
+
+// ScheduledFraction is how much of the quantity the schedule expects filled at 'now', interpolated within a slice
+func (a *AlgoOrder) ScheduledFraction(now time.Time) float64 {
+	if len(a.Curve) == 0 || a.SliceSeconds <= 0 {
+		return 0
+	}
+	slice := time.Duration(a.SliceSeconds) * time.Second
+	elapsed := a.elapsed(now)
+	k := int(elapsed / slice)
+	if k >= len(a.Curve) {
+		return 1
+	}
+	prev := 0.0
+	if k > 0 {
+		prev = a.Curve[k-1]
+	}
+	within := float64(elapsed-time.Duration(k)*slice) / float64(slice)
+	return prev + (a.Curve[k]-prev)*within
+}
+
+// Progress reports execution against the schedule and slippage against the arrival price
+func (a *AlgoOrder) Progress(now time.Time) AlgoProgress {
+	scheduled := int(math.Round(a.ScheduledFraction(now) * float64(a.Quantity)))
+	p := AlgoProgress{
+		ScheduledQuantity: scheduled,
+		FilledQuantity:    a.FilledQuantity,
+		WorkingQuantity:   a.WorkingQuantity,
+		RemainingQuantity: a.Quantity - a.FilledQuantity,
+		ScheduleVariance:  a.FilledQuantity - scheduled,
+	}
+	if a.Quantity > 0 {
+		p.PercentScheduled = float64(scheduled) / float64(a.Quantity) * 100
+		p.PercentFilled = float64(a.FilledQuantity) / float64(a.Quantity) * 100
+	}
+	if a.FilledQuantity > 0 && a.ArrivalPrice > 0 {
+		diff := a.AvgFillPrice - a.ArrivalPrice
+		if a.Side == "SELL" {
+			diff = -diff
+		}
+		p.SlippageBps = diff / a.ArrivalPrice * 10000
+		p.SlippageAmount = diff * float64(a.FilledQuantity)
+	}
+	return p
+}
+
+// MarshalJSON reports progress alongside the stored fields
+func (a AlgoOrder) MarshalJSON() ([]byte, error) {
+	type algoOrder AlgoOrder
+	return json.Marshal(struct {
+		algoOrder
+		Progress AlgoProgress `json:"progress"`
+	}{algoOrder(a), a.Progress(time.Now())})
+}
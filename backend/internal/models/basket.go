@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxBasketOrders is the most orders one basket may place
+const MaxBasketOrders = 20
+
+// Basket order result statuses
+const (
+	BasketResultPlaced    = "PLACED"
+	BasketResultFailed    = "FAILED"
+	BasketResultSkipped   = "SKIPPED"   // Not placed because another order in an all-or-none basket failed
+	BasketResultCancelled = "CANCELLED" // Placed, then cancelled when an all-or-none basket failed
+)
+
+// BasketItem is one order in a basket or basket template
+type BasketItem struct {
+	InstrumentID  primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol        string             `bson:"symbol" json:"symbol"`
+	Side          string             `bson:"side" json:"side"`
+	OrderType     string             `bson:"order_type" json:"orderType"` // MARKET / LIMIT
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	Price         *float64           `bson:"price,omitempty" json:"price,omitempty"`
+	Intent        string             `bson:"intent,omitempty" json:"intent,omitempty"`
+	ProductType   string             `bson:"product_type,omitempty" json:"productType,omitempty"`
+	Validity      string             `bson:"validity,omitempty" json:"validity,omitempty"`
+	ClientOrderID string             `bson:"-" json:"clientOrderId,omitempty"` // Per placement; never saved on a template
+}
+
+// BasketTemplate is a saved basket a user can place again
+type BasketTemplate struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	Name         string             `bson:"name" json:"name"`
+	Description  string             `bson:"description,omitempty" json:"description,omitempty"`
+	Items        []BasketItem       `bson:"items" json:"items"`
+	LastPlacedAt *time.Time         `bson:"last_placed_at,omitempty" json:"lastPlacedAt,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// BasketOrderResult is the outcome of one basket item
+type BasketOrderResult struct {
+	Index    int    `json:"index"`
+	Symbol   string `json:"symbol"`
+	Side     string `json:"side"`
+	Quantity int    `json:"quantity"`
+	Status   string `json:"status"` // PLACED / FAILED / SKIPPED / CANCELLED
+	Error    string `json:"error,omitempty"`
+	Order    *Order `json:"order,omitempty"`
+}
+
+// BasketPlacement is the response to placing a basket
+type BasketPlacement struct {
+	BasketID       string              `json:"basketId"` // Prefix of every placed order's client order ID
+	TemplateID     *primitive.ObjectID `json:"templateId,omitempty"`
+	AllOrNone      bool                `json:"allOrNone"`
+	RequiredFunds  float64             `json:"requiredFunds"` // Estimated cash and short margin for the whole basket
+	AvailableFunds float64             `json:"availableFunds"`
+	Placed         int                 `json:"placed"`
+	Failed         int                 `json:"failed"`
+	Results        []BasketOrderResult `json:"results"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InstrumentTypeIndex marks synthetic, non-tradable instruments that carry an index's price series
+const InstrumentTypeIndex = "INDEX"
+
+// Index weighting methods
+const (
+	IndexPriceWeighted  = "PRICE_WEIGHTED"
+	IndexEqualWeighted  = "EQUAL_WEIGHTED"
+	IndexCustomWeighted = "CUSTOM_WEIGHTED"
+)
+
+type IndexConstituent struct {
+	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol       string             `bson:"symbol" json:"symbol"`
+	Weight       float64            `bson:"weight" json:"weight"`        // CUSTOM_WEIGHTED only, normalised on save
+	BasePrice    float64            `bson:"base_price" json:"basePrice"` // Price at last rebase
+}
+
+// BenchmarkIndex is a basket of instruments maintained as a synthetic instrument.
+// Whenever constituents change the index is rebased so that its level is continuous.
+type BenchmarkIndex struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol       string             `bson:"symbol" json:"symbol"`
+	Name         string             `bson:"name" json:"name"`
+	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"` // Synthetic instrument carrying MarketData/Candles
+	Method       string             `bson:"method" json:"method"`
+	Constituents []IndexConstituent `bson:"constituents" json:"constituents"`
+
+	BaseValue float64 `bson:"base_value" json:"baseValue"` // Index level at last rebase
+	Divisor   float64 `bson:"divisor" json:"divisor"`      // PRICE_WEIGHTED only
+
+	Sector    string `bson:"sector,omitempty" json:"sector,omitempty"` // Set for auto-maintained sector indices
+	IsDefault bool   `bson:"is_default" json:"isDefault"`              // Default benchmark for performance comparison
+	IsActive  bool   `bson:"is_active" json:"isActive"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// IndexValue is the closing level of an index for one IST trading day
+type IndexValue struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	IndexID primitive.ObjectID `bson:"index_id" json:"indexId"`
+	Date    time.Time          `bson:"date" json:"date"` // IST midnight
+	Value   float64            `bson:"value" json:"value"`
+}
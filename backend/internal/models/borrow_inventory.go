@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Borrow rate tiers
+const (
+	BorrowTierEasy = "EASY"
+	BorrowTierHard = "HARD"
+)
+
+// BorrowInventory is the lendable supply of one instrument
+type BorrowInventory struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	InstrumentID      primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol            string             `bson:"symbol" json:"symbol"`
+	TotalQuantity     int                `bson:"total_quantity" json:"totalQuantity"`
+	AvailableQuantity int                `bson:"available_quantity" json:"availableQuantity"` // Total less located and lent
+	Tier              string             `bson:"tier" json:"tier"`
+	DailyRate         float64            `bson:"daily_rate" json:"dailyRate"` // Fraction of position value charged per day
+	UpdatedBy         primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updatedAt"`
+}
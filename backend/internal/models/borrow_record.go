@@ -6,12 +6,31 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Borrow record lifecycle: a locate reserves inventory for an order, the fill activates it,
+// and covering the short returns it. Unfilled locates are released back to inventory.
+const (
+	BorrowStatusLocated  = "LOCATED"
+	BorrowStatusActive   = "ACTIVE"
+	BorrowStatusRecalled = "RECALLED" // Lender wants the shares back; must be covered by RecallDeadline
+	BorrowStatusReturned = "RETURNED"
+	BorrowStatusReleased = "RELEASED" // Locate expired without a fill
+)
+
 type BorrowRecord struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
-	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
-	Quantity     int                `bson:"quantity" json:"quantity"`
-	BorrowRate   float64            `bson:"borrow_rate" json:"borrowRate"` // Daily fee
-	BorrowedAt   time.Time          `bson:"borrowed_at" json:"borrowedAt"`
-	ReturnedAt   *time.Time         `bson:"returned_at,omitempty" json:"returnedAt,omitempty"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID      primitive.ObjectID `bson:"account_id" json:"accountId"`
+	InstrumentID   primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol         string             `bson:"symbol" json:"symbol"`
+	OrderID        primitive.ObjectID `bson:"order_id,omitempty" json:"orderId,omitempty"` // Order the locate was taken for
+	Quantity       int                `bson:"quantity" json:"quantity"`                    // Outstanding quantity
+	BorrowRate     float64            `bson:"borrow_rate" json:"borrowRate"`               // Daily fee
+	Tier           string             `bson:"tier" json:"tier"`
+	Status         string             `bson:"status" json:"status"`
+	AccruedFees    float64            `bson:"accrued_fees" json:"accruedFees"`
+	LastAccruedAt  *time.Time         `bson:"last_accrued_at,omitempty" json:"lastAccruedAt,omitempty"`
+	BorrowedAt     time.Time          `bson:"borrowed_at" json:"borrowedAt"`
+	RecalledAt     *time.Time         `bson:"recalled_at,omitempty" json:"recalledAt,omitempty"`
+	RecallDeadline *time.Time         `bson:"recall_deadline,omitempty" json:"recallDeadline,omitempty"`
+	ReturnedAt     *time.Time         `bson:"returned_at,omitempty" json:"returnedAt,omitempty"`
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Product types used to select brokerage and statutory rates
+const (
+	ProductTypeCNC = "CNC" // Cash & Carry (delivery)
+	ProductTypeMIS = "MIS" // Margin Intraday Square-off
+)
+
+// BrokerageRule: Flat + Min(Value * Rate, Cap). Cap of 0 means uncapped.
+type BrokerageRule struct {
+	Flat float64 `bson:"flat" json:"flat"`
+	Rate float64 `bson:"rate" json:"rate"`
+	Cap  float64 `bson:"cap" json:"cap"`
+}
+
+// ProductCharges holds the rates that vary by product type
+type ProductCharges struct {
+	Brokerage     BrokerageRule `bson:"brokerage" json:"brokerage"`
+	STTBuyRate    float64       `bson:"stt_buy_rate" json:"sttBuyRate"`       // STT (equity) / CTT (commodity) on buy value
+	STTSellRate   float64       `bson:"stt_sell_rate" json:"sttSellRate"`     // STT / CTT on sell value
+	StampDutyRate float64       `bson:"stamp_duty_rate" json:"stampDutyRate"` // Levied on buys only
+}
+
+// ChargeSchedule is an immutable, versioned set of brokerage and statutory rates.
+// The active schedule is the highest version whose EffectiveFrom has passed.
+type ChargeSchedule struct {
+	ID            primitive.ObjectID        `bson:"_id,omitempty" json:"id"`
+	Version       int                       `bson:"version" json:"version"`
+	EffectiveFrom time.Time                 `bson:"effective_from" json:"effectiveFrom"`
+	Products      map[string]ProductCharges `bson:"products" json:"products"` // Keyed by ProductType
+
+	ExchangeTxnRate float64 `bson:"exchange_txn_rate" json:"exchangeTxnRate"` // On turnover, both sides
+	SEBIFeeRate     float64 `bson:"sebi_fee_rate" json:"sebiFeeRate"`         // On turnover, both sides
+	GSTRate         float64 `bson:"gst_rate" json:"gstRate"`                  // On brokerage + exchange + SEBI charges
+
+	Notes     string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"created_by,omitempty" json:"createdBy"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// TradeCharges is the itemised charge breakdown for a single execution
+type TradeCharges struct {
+	Brokerage          float64 `bson:"brokerage" json:"brokerage"`
+	STT                float64 `bson:"stt" json:"stt"`
+	ExchangeTxnCharges float64 `bson:"exchange_txn_charges" json:"exchangeTxnCharges"`
+	SEBIFees           float64 `bson:"sebi_fees" json:"sebiFees"`
+	GST                float64 `bson:"gst" json:"gst"`
+	StampDuty          float64 `bson:"stamp_duty" json:"stampDuty"`
+	Total              float64 `bson:"total" json:"total"`
+}
+
+// Statutory returns everything except brokerage
+func (c TradeCharges) Statutory() float64 {
+	return c.Total - c.Brokerage
+}
+
+// Add accumulates another breakdown (used for contract note totals)
+func (c *TradeCharges) Add(o TradeCharges) {
+	c.Brokerage += o.Brokerage
+	c.STT += o.STT
+	c.ExchangeTxnCharges += o.ExchangeTxnCharges
+	c.SEBIFees += o.SEBIFees
+	c.GST += o.GST
+	c.StampDuty += o.StampDuty
+	c.Total += o.Total
+}
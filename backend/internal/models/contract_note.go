@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContractNoteLine is a single execution printed on a contract note
+type ContractNoteLine struct {
+	TradeID    string             `bson:"trade_id" json:"tradeId"`
+	OrderID    primitive.ObjectID `bson:"order_id" json:"orderId"`
+	OrderRef   string             `bson:"order_ref" json:"orderRef"` // Human-readable ORD-XXXX
+	ExecutedAt time.Time          `bson:"executed_at" json:"executedAt"`
+	Symbol     string             `bson:"symbol" json:"symbol"`
+	Side       string             `bson:"side" json:"side"` // BUY / SELL
+	Quantity   int                `bson:"quantity" json:"quantity"`
+	Price      float64            `bson:"price" json:"price"`
+	Value      float64            `bson:"value" json:"value"`
+
+	Brokerage        float64      `bson:"brokerage" json:"brokerage"`
+	StatutoryCharges float64      `bson:"statutory_charges" json:"statutoryCharges"`
+	Charges          TradeCharges `bson:"charges" json:"charges"`      // Itemised breakdown
+	NetAmount        float64      `bson:"net_amount" json:"netAmount"` // Signed: negative = payable by client
+}
+
+// ContractNote is the formal end-of-day record of all executions for a user
+type ContractNote struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	NoteNumber string             `bson:"note_number" json:"noteNumber"` // CN-YYYYMMDD-XXXXXX
+	UserID     primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID  primitive.ObjectID `bson:"account_id" json:"accountId"`
+	TradeDate  time.Time          `bson:"trade_date" json:"tradeDate"` // IST midnight of the trading day
+
+	Lines []ContractNoteLine `bson:"lines" json:"lines"`
+
+	// Totals
+	GrossBuyValue    float64      `bson:"gross_buy_value" json:"grossBuyValue"`
+	GrossSellValue   float64      `bson:"gross_sell_value" json:"grossSellValue"`
+	TotalBrokerage   float64      `bson:"total_brokerage" json:"totalBrokerage"`
+	StatutoryCharges float64      `bson:"statutory_charges" json:"statutoryCharges"`
+	Charges          TradeCharges `bson:"charges" json:"charges"`              // Itemised totals
+	NetObligation    float64      `bson:"net_obligation" json:"netObligation"` // Positive = receivable, Negative = payable
+
+	EmailedAt *time.Time `bson:"emailed_at,omitempty" json:"emailedAt,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updatedAt"`
+}
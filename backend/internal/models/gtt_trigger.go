@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GTT trigger types
+const (
+	GTTTypeSingle = "SINGLE" // One leg: a stop-loss or a target
+	GTTTypeOCO    = "OCO"    // Stop-loss and target; the first to fire cancels the other
+)
+
+// GTT trigger statuses
+const (
+	GTTStatusActive    = "ACTIVE"
+	GTTStatusTriggered = "TRIGGERED" // Order placed for the fired leg
+	GTTStatusRejected  = "REJECTED"  // Fired but the order was refused
+	GTTStatusCancelled = "CANCELLED" // By the user, or because the position was closed
+	GTTStatusExpired   = "EXPIRED"
+)
+
+// GTT leg kinds
+const (
+	GTTLegStopLoss = "STOP_LOSS"
+	GTTLegTarget   = "TARGET"
+)
+
+// GTTMaxValidity is how long a trigger may stay active
+const GTTMaxValidity = 365 * 24 * time.Hour
+
+type GTTLeg struct {
+	Kind         string   `bson:"kind" json:"kind"`                                  // STOP_LOSS / TARGET
+	TriggerPrice float64  `bson:"trigger_price" json:"triggerPrice"`                 // LTP that fires the leg
+	OrderType    string   `bson:"order_type" json:"orderType"`                       // MARKET / LIMIT
+	LimitPrice   *float64 `bson:"limit_price,omitempty" json:"limitPrice,omitempty"` // For LIMIT legs
+}
+
+// GTTTrigger is a good-till-triggered stop-loss and/or target attached to a holding rather than an order
+type GTTTrigger struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID    primitive.ObjectID `bson:"account_id" json:"accountId"`
+	HoldingID    primitive.ObjectID `bson:"holding_id" json:"holdingId"`
+	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol       string             `bson:"symbol" json:"symbol"`
+	PositionType PositionType       `bson:"position_type" json:"positionType"` // Side of the position it protects
+
+	Type         string   `bson:"type" json:"type"` // SINGLE / OCO
+	Legs         []GTTLeg `bson:"legs" json:"legs"`
+	Quantity     int      `bson:"quantity" json:"quantity"`
+	FullPosition bool     `bson:"full_position" json:"fullPosition"` // Quantity follows the holding as it grows and shrinks
+
+	Status       string              `bson:"status" json:"status"`
+	FiredLeg     string              `bson:"fired_leg,omitempty" json:"firedLeg,omitempty"`
+	FiredPrice   float64             `bson:"fired_price,omitempty" json:"firedPrice,omitempty"`
+	OrderID      *primitive.ObjectID `bson:"order_id,omitempty" json:"orderId,omitempty"`
+	StatusReason string              `bson:"status_reason,omitempty" json:"statusReason,omitempty"`
+
+	ExpiresAt   time.Time  `bson:"expires_at" json:"expiresAt"`
+	TriggeredAt *time.Time `bson:"triggered_at,omitempty" json:"triggeredAt,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updatedAt"`
+}
+
+// Fires returns the leg hit at the given price, if any; stops are checked before targets
+func (t *GTTTrigger) Fires(price float64) *GTTLeg {
+	for _, kind := range []string{GTTLegStopLoss, GTTLegTarget} {
+		for i := range t.Legs {
+			leg := &t.Legs[i]
+			if leg.Kind != kind {
+				continue
+			}
+			// A stop fires when price moves against the position, a target when it moves in its favour
+			falling := price <= leg.TriggerPrice
+			rising := price >= leg.TriggerPrice
+			longSide := t.PositionType != PositionShort
+			if (kind == GTTLegStopLoss && (longSide && falling || !longSide && rising)) ||
+				(kind == GTTLegTarget && (longSide && rising || !longSide && falling)) {
+				return leg
+			}
+		}
+	}
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-
 type PositionType string
 
 const (
@@ -30,9 +29,10 @@ type Holding struct {
 	Symbol       string             `bson:"symbol" json:"symbol"`
 
 	// Position Details
-	Quantity      int          `bson:"quantity" json:"quantity"`           // Always positive
-	PositionType  PositionType `bson:"position_type" json:"positionType"` // LONG / SHORT
-	AvgEntryPrice float64      `bson:"avg_entry_price" json:"avgEntryPrice"` // Renamed from AvgCost
+	Quantity      int          `bson:"quantity" json:"quantity"`                            // Always positive
+	PositionType  PositionType `bson:"position_type" json:"positionType"`                   // LONG / SHORT
+	ProductType   string       `bson:"product_type,omitempty" json:"productType,omitempty"` // CNC (default) / MIS
+	AvgEntryPrice float64      `bson:"avg_entry_price" json:"avgEntryPrice"`                // Renamed from AvgCost
 	TotalCost     float64      `bson:"total_cost" json:"totalCost"`
 	TotalFees     float64      `bson:"total_fees" json:"totalFees"`
 
@@ -42,11 +42,15 @@ type Holding struct {
 	TotalPL      float64 `bson:"total_pl" json:"totalPL"`
 
 	// Margin Tracking (Shorts)
-	BlockedMargin float64      `bson:"blocked_margin" json:"blockedMargin"`
-	InitialMargin float64      `bson:"initial_margin" json:"initialMargin"`
-	MarginStatus  MarginStatus `bson:"margin_status" json:"marginStatus"`
+	BlockedMargin     float64      `bson:"blocked_margin" json:"blockedMargin"` // Initial requirement at the last mark
+	InitialMargin     float64      `bson:"initial_margin" json:"initialMargin"` // Margin blocked at entry
+	MaintenanceMargin float64      `bson:"maintenance_margin" json:"maintenanceMargin"`
+	MarkPrice         float64      `bson:"mark_price,omitempty" json:"markPrice,omitempty"`
+	MarkedAt          *time.Time   `bson:"marked_at,omitempty" json:"markedAt,omitempty"`
+	MarginStatus      MarginStatus `bson:"margin_status" json:"marginStatus"`
 
 	// Compliance
+	// Deprecated: disclosure acceptance is recorded per user on SuitabilityProfile and enforced at order entry.
 	ShortSellDisclosureAccepted bool      `bson:"short_sell_disclosure_accepted,omitempty" json:"shortSellDisclosureAccepted,omitempty"`
 	DisclosureTimestamp         time.Time `bson:"disclosure_timestamp,omitempty" json:"disclosureTimestamp,omitempty"`
 
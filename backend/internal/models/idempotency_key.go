@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRetention is how long a client order ID or Idempotency-Key replays its original result
+const IdempotencyRetention = 24 * time.Hour
+
+// Idempotency key states
+const (
+	IdempotencyInProgress = "IN_PROGRESS"
+	IdempotencyCompleted  = "COMPLETED"
+)
+
+// IdempotencyKey stores the response to a mutating request sent with an Idempotency-Key header
+type IdempotencyKey struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountScope string             `bson:"account_scope" json:"accountScope"` // Requested trading account, or empty for the default
+	Key          string             `bson:"key" json:"key"`
+	Method       string             `bson:"method" json:"method"`
+	Path         string             `bson:"path" json:"path"`
+	RequestHash  string             `bson:"request_hash" json:"requestHash"` // Account, method, path and body; a reused key must match
+	Status       string             `bson:"status" json:"status"`            // IN_PROGRESS / COMPLETED
+	ResponseCode int                `bson:"response_code,omitempty" json:"responseCode,omitempty"`
+	ResponseBody []byte             `bson:"response_body,omitempty" json:"-"`
+	ContentType  string             `bson:"content_type,omitempty" json:"contentType,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"` // Expires IdempotencyRetention later
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Margin monitor alert levels
+const (
+	MarginAlertOK       = "OK"
+	MarginAlertWarning  = "WARNING"
+	MarginAlertCritical = "CRITICAL"
+)
+
+// Escalation stages reached during the current breach; reset when the account recovers
+const (
+	EscalationNone        = 0
+	EscalationWarned      = 1 // Warning alert sent
+	EscalationCritical    = 2 // Critical alert sent
+	EscalationLiquidation = 3 // Auto-liquidation attempted
+)
+
+const MarginRatioHistoryLimit = 20
+
+type MarginRatioPoint struct {
+	Ratio float64   `bson:"ratio" json:"ratio"`
+	At    time.Time `bson:"at" json:"at"`
+}
+
+// MarginHealth is the margin monitor's persisted state for one account, so hysteresis survives restarts
+type MarginHealth struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AccountID           primitive.ObjectID `bson:"account_id" json:"accountId"`
+	UserID              primitive.ObjectID `bson:"user_id" json:"userId"`
+	Ratio               float64            `bson:"ratio" json:"ratio"` // Equity / initial requirement
+	Equity              float64            `bson:"equity" json:"equity"`
+	Required            float64            `bson:"required" json:"required"` // 0 when the account has no shorts
+	AlertLevel          string             `bson:"alert_level" json:"alertLevel"`
+	ConsecutiveBreaches int                `bson:"consecutive_breaches" json:"consecutiveBreaches"` // Readings below 1.0, warning or critical
+	ConsecutiveCritical int                `bson:"consecutive_critical" json:"consecutiveCritical"` // Readings below 0.5; liquidation needs two
+	EscalationStage     int                `bson:"escalation_stage" json:"escalationStage"`
+	LastAlertAt         *time.Time         `bson:"last_alert_at,omitempty" json:"lastAlertAt,omitempty"`
+	RatioHistory        []MarginRatioPoint `bson:"ratio_history" json:"ratioHistory"` // Oldest first, capped
+	CheckedAt           time.Time          `bson:"checked_at" json:"checkedAt"`
+}
+
+// RecordRatio appends a reading and trims the history to the limit
+func (h *MarginHealth) RecordRatio(ratio float64, at time.Time) {
+	h.Ratio = ratio
+	h.CheckedAt = at
+	h.RatioHistory = append(h.RatioHistory, MarginRatioPoint{Ratio: ratio, At: at})
+	if len(h.RatioHistory) > MarginRatioHistoryLimit {
+		h.RatioHistory = h.RatioHistory[len(h.RatioHistory)-MarginRatioHistoryLimit:]
+	}
+}
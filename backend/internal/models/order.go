@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,6 +16,14 @@ const (
 	IntentCloseShort OrderIntent = "CLOSE_SHORT"
 )
 
+// Order origins
+const (
+	OrderOriginUser        = "USER"
+	OrderOriginLiquidation = "LIQUIDATION" // System square-off after a sustained critical margin breach
+	OrderOriginBuyIn       = "BUY_IN"      // Forced cover of a recalled borrow
+	OrderOriginSquareOff   = "SQUARE_OFF"  // Scheduled close of intraday (MIS) positions before market close
+)
+
 type Order struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	OrderID      string             `bson:"order_id" json:"orderId"`
@@ -24,15 +33,20 @@ type Order struct {
 	Symbol       string             `bson:"symbol" json:"symbol"`
 
 	Side      string   `bson:"side" json:"side"`            // BUY / SELL
-	OrderType string   `bson:"order_type" json:"orderType"` // MARKET / LIMIT / STOP / STOP_LIMIT / TRAILING_STOP
+	OrderType string   `bson:"order_type" json:"orderType"` // MARKET / LIMIT / STOP / STOP_LIMIT / TRAILING_STOP / CONDITIONAL / MULTI_LEG / LEG
 	Quantity  int      `bson:"quantity" json:"quantity"`
 	Price     *float64 `bson:"price,omitempty" json:"price,omitempty"`
 
+	// Iceberg: only this much of the remainder is shown at a time (LIMIT orders only)
+	DisclosedQuantity int `bson:"disclosed_quantity,omitempty" json:"disclosedQuantity,omitempty"`
+
 	// New Intent Field
 	Intent          string `bson:"intent" json:"intent"`                               // OPEN_LONG / OPEN_SHORT / CLOSE_LONG / CLOSE_SHORT
 	CoverPositionID string `bson:"cover_position_id,omitempty" json:"coverPositionId"` // For CLOSE_SHORT
+	Netting         bool   `bson:"netting,omitempty" json:"netting"`                   // Close any opposite position, then open the remainder
 
-	Validity string `bson:"validity,omitempty" json:"validity"` // DAY / IOC / GTC
+	Validity    string `bson:"validity,omitempty" json:"validity"`        // DAY / IOC / GTC
+	ProductType string `bson:"product_type,omitempty" json:"productType"` // CNC (default) / MIS
 
 	// Stop Order Fields
 	StopPrice  *float64 `bson:"stop_price,omitempty" json:"stopPrice,omitempty"`   // Trigger price for STOP and STOP_LIMIT
@@ -45,21 +59,106 @@ type Order struct {
 	HighestPrice     *float64 `bson:"highest_price,omitempty" json:"highestPrice,omitempty"`          // Highest price reached (for SELL trailing stops)
 	LowestPrice      *float64 `bson:"lowest_price,omitempty" json:"lowestPrice,omitempty"`            // Lowest price reached (for BUY trailing stops)
 
+	// Conditional orders wait as PENDING until this holds, then place a MARKET order (LIMIT at LimitPrice if set)
+	Condition *OrderCondition `bson:"condition,omitempty" json:"condition,omitempty"`
+
+	// Multi-leg orders fill every leg in one transaction or none; Price is the net limit per unit
+	Legs []OrderLeg `bson:"legs,omitempty" json:"legs,omitempty"`
+
 	// Trigger Tracking
 	TriggeredAt   *time.Time          `bson:"triggered_at,omitempty" json:"triggeredAt,omitempty"`      // When stop order was triggered
 	TriggerPrice  *float64            `bson:"trigger_price,omitempty" json:"triggerPrice,omitempty"`    // Price at which order was triggered
-	ParentOrderID *primitive.ObjectID `bson:"parent_order_id,omitempty" json:"parentOrderId,omitempty"` // Links triggered order to original stop order
+	ParentOrderID *primitive.ObjectID `bson:"parent_order_id,omitempty" json:"parentOrderId,omitempty"` // Links a triggered order to its stop order, or a child to its algo order
 
 	// Fill Details
 	FilledQuantity int        `bson:"filled_quantity" json:"filledQuantity"`
 	AvgFillPrice   float64    `bson:"avg_fill_price" json:"avgFillPrice"`
 	FilledAt       *time.Time `bson:"filled_at,omitempty" json:"filledAt,omitempty"`
 
-	Status        string `bson:"status" json:"status"` // NEW, PENDING, TRIGGERED, FILLED, CANCELLED, REJECTED
-	Source        string `bson:"source" json:"source"` // UI / API
-	ClientOrderID string `bson:"client_order_id" json:"clientOrderId"`
+	Status        string `bson:"status" json:"status"`                                    // NEW, PENDING, TRIGGERED, FILLED, CANCELLED, REJECTED
+	StatusReason  string `bson:"status_reason,omitempty" json:"statusReason,omitempty"`   // Reason code for the last status change
+	StatusMessage string `bson:"status_message,omitempty" json:"statusMessage,omitempty"` // Human-readable detail, e.g. why a trigger was rejected
+	Source        string `bson:"source" json:"source"`                                    // UI / API / SYSTEM
+	Origin        string `bson:"origin,omitempty" json:"origin,omitempty"`                // USER (default) / LIQUIDATION / BUY_IN / SQUARE_OFF
+	ClientOrderID string `bson:"client_order_id" json:"clientOrderId"`                    // Unique per user; a retry with the same ID returns the original order
+	Replayed      bool   `bson:"-" json:"replayed,omitempty"`                             // Set when PlaceOrder returned an existing order for a repeated client order ID
 
 	CreatedAt   time.Time `bson:"created_at" json:"createdAt"`
 	UpdatedAt   time.Time `bson:"updated_at" json:"updatedAt"`
 	ValidatedAt time.Time `bson:"validated_at" json:"validatedAt"`
+	PriorityAt  time.Time `bson:"priority_at" json:"priorityAt"` // Time priority; refreshed when an iceberg shows its next slice
+}
+
+// OrderLeg is one instrument of a MULTI_LEG order; it trades through its own LEG child order
+type OrderLeg struct {
+	InstrumentID primitive.ObjectID  `bson:"instrument_id" json:"instrumentId"`
+	Symbol       string              `bson:"symbol" json:"symbol"`
+	Side         string              `bson:"side" json:"side"`
+	Intent       string              `bson:"intent" json:"intent"`
+	Ratio        int                 `bson:"ratio" json:"ratio"`       // Units of this leg per unit of the parent
+	Quantity     int                 `bson:"quantity" json:"quantity"` // Ratio x parent quantity
+	OrderID      *primitive.ObjectID `bson:"order_id,omitempty" json:"orderId,omitempty"`
+	FillPrice    float64             `bson:"fill_price,omitempty" json:"fillPrice,omitempty"`
+}
+
+// Multi-leg limits
+const (
+	MinOrderLegs = 2
+	MaxOrderLegs = 4
+
+	// SpreadMarginOffset is the share of short-leg margin waived when the short legs are fully hedged by long legs
+	SpreadMarginOffset = 0.5
+)
+
+// NetPrice is the per-unit cost of a multi-leg order at the given prices: bought legs add, sold legs subtract
+func (o *Order) NetPrice(prices map[primitive.ObjectID]float64) (float64, bool) {
+	net := 0.0
+	for _, leg := range o.Legs {
+		price, ok := prices[leg.InstrumentID]
+		if !ok {
+			return 0, false
+		}
+		if leg.Side == "BUY" {
+			net += float64(leg.Ratio) * price
+		} else {
+			net -= float64(leg.Ratio) * price
+		}
+	}
+	return net, true
+}
+
+// MinDisclosedFraction is the smallest disclosed quantity allowed, as a fraction of the order quantity
+const MinDisclosedFraction = 0.1
+
+// IsIceberg reports whether the order discloses only part of its quantity
+func (o *Order) IsIceberg() bool {
+	return o.DisclosedQuantity > 0 && o.DisclosedQuantity < o.Quantity
+}
+
+// RemainingQuantity is the unfilled part of the order, including any hidden iceberg remainder
+func (o *Order) RemainingQuantity() int {
+	return o.Quantity - o.FilledQuantity
+}
+
+// DisplayedQuantity is the part of the remainder that is working in the market: the current slice
+// for an iceberg, all of it otherwise, and nothing once the order is no longer working
+func (o *Order) DisplayedQuantity() int {
+	if o.Status != OrderStatusNew {
+		return 0
+	}
+	remaining := o.RemainingQuantity()
+	if o.IsIceberg() && o.DisclosedQuantity < remaining {
+		return o.DisclosedQuantity
+	}
+	return remaining
+}
+
+// MarshalJSON reports remaining and displayed quantities alongside the stored fields
+func (o Order) MarshalJSON() ([]byte, error) {
+	type order Order
+	return json.Marshal(struct {
+		order
+		RemainingQuantity int `json:"remainingQuantity"`
+		DisplayedQuantity int `json:"displayedQuantity"`
+	}{order(o), o.RemainingQuantity(), o.DisplayedQuantity()})
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Condition clause types
+const (
+	ConditionTime           = "TIME"             // Fires once a time is reached
+	ConditionPrice          = "PRICE"            // Reference instrument's LTP against a level
+	ConditionChangeFromOpen = "CHANGE_FROM_OPEN" // Reference instrument's % change from the day's open
+	ConditionIndicator      = "INDICATOR"        // Candle indicator on the reference instrument
+)
+
+// Condition comparators and logic
+const (
+	ComparatorAbove = "ABOVE" // At or above
+	ComparatorBelow = "BELOW" // At or below
+
+	ConditionLogicAnd = "AND"
+	ConditionLogicOr  = "OR"
+)
+
+// Condition indicators
+const (
+	IndicatorSMA = "SMA" // LTP against the simple moving average
+	IndicatorEMA = "EMA" // LTP against the exponential moving average
+	IndicatorRSI = "RSI" // RSI against Value
+)
+
+// MaxConditionClauses is the most clauses one conditional order may combine
+const MaxConditionClauses = 5
+
+// ConditionClause is one test in a conditional order's trigger
+type ConditionClause struct {
+	Type         string              `bson:"type" json:"type"`                                      // TIME / PRICE / CHANGE_FROM_OPEN / INDICATOR
+	InstrumentID *primitive.ObjectID `bson:"instrument_id,omitempty" json:"instrumentId,omitempty"` // Reference instrument; the order's own when omitted
+	Symbol       string              `bson:"symbol,omitempty" json:"symbol,omitempty"`
+	Comparator   string              `bson:"comparator,omitempty" json:"comparator,omitempty"` // ABOVE / BELOW
+	Value        float64             `bson:"value,omitempty" json:"value,omitempty"`           // Price, % change from open, or RSI level
+	At           *time.Time          `bson:"at,omitempty" json:"at,omitempty"`                 // TIME clauses
+	Indicator    string              `bson:"indicator,omitempty" json:"indicator,omitempty"`   // SMA / EMA / RSI
+	Period       int                 `bson:"period,omitempty" json:"period,omitempty"`
+	Interval     string              `bson:"interval,omitempty" json:"interval,omitempty"` // Candle interval for indicators
+}
+
+// OrderCondition combines clauses with AND or OR; a CONDITIONAL order fires when it holds
+type OrderCondition struct {
+	Logic   string            `bson:"logic" json:"logic"`
+	Clauses []ConditionClause `bson:"clauses" json:"clauses"`
+}
+
+// Compare tests x against level using the clause's comparator
+func (c *ConditionClause) Compare(x, level float64) bool {
+	if c.Comparator == ComparatorBelow {
+		return x <= level
+	}
+	return x >= level
+}
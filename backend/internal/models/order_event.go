@@ -0,0 +1,199 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Order statuses
+const (
+	OrderStatusNew       = "NEW"       // Working in the market, including partially filled orders
+	OrderStatusPending   = "PENDING"   // Stop and conditional orders waiting for their trigger
+	OrderStatusTriggered = "TRIGGERED" // A stop or conditional order that released its child order
+	OrderStatusFilled    = "FILLED"
+	OrderStatusCancelled = "CANCELLED"
+	OrderStatusRejected  = "REJECTED"
+	OrderStatusExpired   = "EXPIRED" // A DAY order still working when its session closed
+)
+
+// orderTransitions lists the statuses each status may move to; an order may also be updated in place
+// while it is NEW or PENDING. A TRIGGERED order can only be rejected when its released order fails;
+// FILLED, CANCELLED, REJECTED and EXPIRED are final. PENDING to TRIGGERED goes through OrderRepository.ClaimPending.
+var orderTransitions = map[string][]string{
+	OrderStatusNew:       {OrderStatusNew, OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected, OrderStatusExpired},
+	OrderStatusPending:   {OrderStatusPending, OrderStatusTriggered, OrderStatusCancelled, OrderStatusRejected, OrderStatusExpired},
+	OrderStatusTriggered: {OrderStatusRejected},
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusesInto returns the statuses an order may be in to move to status
+func OrderStatusesInto(status string) []string {
+	from := make([]string, 0, len(orderTransitions))
+	for current, next := range orderTransitions {
+		for _, s := range next {
+			if s == status {
+				from = append(from, current)
+				break
+			}
+		}
+	}
+	return from
+}
+
+// Order event types
+const (
+	OrderEventAccepted        = "ACCEPTED"
+	OrderEventModified        = "MODIFIED"
+	OrderEventTriggered       = "TRIGGERED"
+	OrderEventPartiallyFilled = "PARTIALLY_FILLED"
+	OrderEventFilled          = "FILLED"
+	OrderEventCancelled       = "CANCELLED"
+	OrderEventExpired         = "EXPIRED"
+	OrderEventRejected        = "REJECTED"
+)
+
+// Order status reason codes
+const (
+	OrderReasonUserRequest     = "USER_REQUEST"       // Cancelled or amended by the user
+	OrderReasonIOCUnfilled     = "IOC_UNFILLED"       // IOC order could not fill immediately
+	OrderReasonForcedClose     = "FORCED_CLOSE"       // Cancelled ahead of a liquidation, buy-in or square-off
+	OrderReasonExecutionFailed = "EXECUTION_FAILED"   // System order could not be executed
+	OrderReasonLegFailed       = "LEG_FAILED"         // A leg of a multi-leg order could not be booked
+	OrderReasonStopTriggered   = "STOP_PRICE_REACHED" // Market reached the stop price
+	OrderReasonConditionMet    = "CONDITION_MET"      // Conditional order's condition was met
+	OrderReasonTriggerFailed   = "TRIGGER_FAILED"     // The order released on trigger was rejected
+	OrderReasonSessionClosed   = "SESSION_CLOSED"     // DAY order was still working at market close
+)
+
+// OrderEvent is one entry in an order's append-only timeline
+type OrderEvent struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID      primitive.ObjectID `bson:"order_id" json:"orderId"`
+	OrderRef     string             `bson:"order_ref" json:"orderRef"` // The order's display ID, e.g. ORD-...
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID    primitive.ObjectID `bson:"account_id" json:"accountId"`
+	Type         string             `bson:"type" json:"type"`
+	FromStatus   string             `bson:"from_status,omitempty" json:"fromStatus,omitempty"`
+	ToStatus     string             `bson:"to_status" json:"toStatus"`
+	Reason       string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Message      string             `bson:"message,omitempty" json:"message,omitempty"`
+	FillQuantity int                `bson:"fill_quantity,omitempty" json:"fillQuantity,omitempty"`
+	FillPrice    float64            `bson:"fill_price,omitempty" json:"fillPrice,omitempty"`
+	Changes      []OrderChange      `bson:"changes,omitempty" json:"changes,omitempty"` // Amended fields for MODIFIED events
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// OrderChange is one amended field of an order
+type OrderChange struct {
+	Field string      `bson:"field" json:"field"`
+	Old   interface{} `bson:"old,omitempty" json:"old,omitempty"`
+	New   interface{} `bson:"new,omitempty" json:"new,omitempty"`
+}
+
+// OrderDetail is an order with its full timeline
+type OrderDetail struct {
+	Order    *Order        `json:"order"`
+	Timeline []*OrderEvent `json:"timeline"`
+}
+
+// NewOrderEvent starts an event for the order in its current status
+func NewOrderEvent(order *Order, eventType string) *OrderEvent {
+	return &OrderEvent{
+		OrderID:   order.ID,
+		OrderRef:  order.OrderID,
+		UserID:    order.UserID,
+		AccountID: order.AccountID,
+		Type:      eventType,
+		ToStatus:  order.Status,
+	}
+}
+
+// OrderEventBetween describes what changed from before to after, or nil if nothing worth recording did
+func OrderEventBetween(before, after *Order) *OrderEvent {
+	var event *OrderEvent
+	switch {
+	case before.Status != after.Status:
+		// Status changes are recorded under the event type of the same name
+		event = NewOrderEvent(after, after.Status)
+		event.FromStatus = before.Status
+		event.Reason = after.StatusReason
+		event.Message = after.StatusMessage
+	case after.FilledQuantity > before.FilledQuantity:
+		event = NewOrderEvent(after, OrderEventPartiallyFilled)
+	default:
+		changes := orderChanges(before, after)
+		if len(changes) == 0 {
+			return nil
+		}
+		event = NewOrderEvent(after, OrderEventModified)
+		event.Changes = changes
+		return event
+	}
+
+	if filled := after.FilledQuantity - before.FilledQuantity; filled > 0 {
+		value := after.AvgFillPrice*float64(after.FilledQuantity) - before.AvgFillPrice*float64(before.FilledQuantity)
+		event.FillQuantity = filled
+		event.FillPrice = math.Round(value/float64(filled)*100) / 100
+	}
+	return event
+}
+
+// orderChanges lists the amendable fields that differ between two versions of an order
+func orderChanges(before, after *Order) []OrderChange {
+	var changes []OrderChange
+	add := func(field string, old, updated interface{}) {
+		changes = append(changes, OrderChange{Field: field, Old: old, New: updated})
+	}
+	if before.Quantity != after.Quantity {
+		add("quantity", before.Quantity, after.Quantity)
+	}
+	if before.DisclosedQuantity != after.DisclosedQuantity {
+		add("disclosedQuantity", before.DisclosedQuantity, after.DisclosedQuantity)
+	}
+	priceFields := []struct {
+		field         string
+		before, after *float64
+	}{
+		{"price", before.Price, after.Price},
+		{"stopPrice", before.StopPrice, after.StopPrice},
+		{"limitPrice", before.LimitPrice, after.LimitPrice},
+		{"trailAmount", before.TrailAmount, after.TrailAmount},
+	}
+	for _, f := range priceFields {
+		if !samePrice(f.before, f.after) {
+			add(f.field, priceValue(f.before), priceValue(f.after))
+		}
+	}
+	if before.TrailType != after.TrailType {
+		add("trailType", before.TrailType, after.TrailType)
+	}
+	if before.Validity != after.Validity {
+		add("validity", before.Validity, after.Validity)
+	}
+	return changes
+}
+
+func samePrice(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func priceValue(p *float64) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{OrderStatusNew, OrderStatusNew, true},
+		{OrderStatusNew, OrderStatusFilled, true},
+		{OrderStatusNew, OrderStatusCancelled, true},
+		{OrderStatusNew, OrderStatusRejected, true},
+		{OrderStatusNew, OrderStatusExpired, true},
+		{OrderStatusNew, OrderStatusPending, false},
+		{OrderStatusNew, OrderStatusTriggered, false},
+		{OrderStatusPending, OrderStatusPending, true},
+		{OrderStatusPending, OrderStatusTriggered, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusRejected, true},
+		{OrderStatusPending, OrderStatusExpired, true},
+		{OrderStatusPending, OrderStatusFilled, false},
+		{OrderStatusTriggered, OrderStatusRejected, true},
+		{OrderStatusTriggered, OrderStatusTriggered, false},
+		{OrderStatusTriggered, OrderStatusExpired, false},
+		{OrderStatusTriggered, OrderStatusCancelled, false},
+		{OrderStatusFilled, OrderStatusCancelled, false},
+		{OrderStatusCancelled, OrderStatusNew, false},
+		{OrderStatusRejected, OrderStatusRejected, false},
+		{OrderStatusExpired, OrderStatusCancelled, false},
+		{OrderStatusExpired, OrderStatusNew, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransitionOrder(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionOrder(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestOrderStatusesInto(t *testing.T) {
+	tests := []struct {
+		status string
+		want   []string
+	}{
+		{OrderStatusNew, []string{OrderStatusNew}},
+		{OrderStatusTriggered, []string{OrderStatusPending}},
+		{OrderStatusExpired, []string{OrderStatusNew, OrderStatusPending}},
+		{OrderStatusFilled, []string{OrderStatusNew}},
+		{OrderStatusCancelled, []string{OrderStatusNew, OrderStatusPending}},
+		{OrderStatusRejected, []string{OrderStatusNew, OrderStatusPending, OrderStatusTriggered}},
+	}
+
+	for _, tt := range tests {
+		got := OrderStatusesInto(tt.status)
+		sort.Strings(got)
+		sort.Strings(tt.want)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("OrderStatusesInto(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestOrderEventBetween(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	base := Order{OrderID: "ORD-1", Status: OrderStatusNew, Quantity: 100, Price: price(250)}
+
+	tests := []struct {
+		name  string
+		after func(o *Order)
+		want  *OrderEvent
+	}{
+		{
+			name:  "no change",
+			after: func(o *Order) {},
+			want:  nil,
+		},
+		{
+			name: "partial fill records the fill price of this slice",
+			after: func(o *Order) {
+				o.FilledQuantity = 40
+				o.AvgFillPrice = 249.5
+			},
+			want: &OrderEvent{OrderRef: "ORD-1", Type: OrderEventPartiallyFilled, ToStatus: OrderStatusNew, FillQuantity: 40, FillPrice: 249.5},
+		},
+		{
+			name: "cancel carries reason and message",
+			after: func(o *Order) {
+				o.Status = OrderStatusCancelled
+				o.StatusReason = OrderReasonUserRequest
+				o.StatusMessage = "Cancelled by user"
+			},
+			want: &OrderEvent{OrderRef: "ORD-1", Type: OrderEventCancelled, FromStatus: OrderStatusNew, ToStatus: OrderStatusCancelled,
+				Reason: OrderReasonUserRequest, Message: "Cancelled by user"},
+		},
+		{
+			name: "expiry at session close",
+			after: func(o *Order) {
+				o.Status = OrderStatusExpired
+				o.StatusReason = OrderReasonSessionClosed
+			},
+			want: &OrderEvent{OrderRef: "ORD-1", Type: OrderEventExpired, FromStatus: OrderStatusNew, ToStatus: OrderStatusExpired,
+				Reason: OrderReasonSessionClosed},
+		},
+		{
+			name:  "amendment lists changed fields",
+			after: func(o *Order) { o.Quantity, o.Price = 120, price(251) },
+			want: &OrderEvent{OrderRef: "ORD-1", Type: OrderEventModified, ToStatus: OrderStatusNew, Changes: []OrderChange{
+				{Field: "quantity", Old: 100, New: 120},
+				{Field: "price", Old: 250.0, New: 251.0},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := base
+			after := base
+			after.Price = price(*base.Price)
+			tt.after(&after)
+			if got := OrderEventBetween(&before, &after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OrderEventBetween() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("fill price covers only the new quantity", func(t *testing.T) {
+		before := base
+		before.FilledQuantity, before.AvgFillPrice = 40, 249.5
+		after := before
+		after.Status = OrderStatusFilled
+		after.FilledQuantity, after.AvgFillPrice = 100, 250.1 // 60 more at 250.50
+		got := OrderEventBetween(&before, &after)
+		if got == nil || got.Type != OrderEventFilled || got.FillQuantity != 60 || got.FillPrice != 250.5 {
+			t.Errorf("OrderEventBetween() = %+v, want FILLED event for 60 at 250.5", got)
+		}
+	})
+}
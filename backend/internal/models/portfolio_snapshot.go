@@ -6,12 +6,37 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	SnapshotKindEOD      = "EOD"      // Official end-of-day snapshot, one per account per trading day
+	SnapshotKindIntraday = "INTRADAY" // Monitoring / manual snapshots, pruned after a retention window
+
+	SnapshotSourceLive     = "LIVE"
+	SnapshotSourceBackfill = "BACKFILL" // Reconstructed from trades and candles after downtime
+)
+
+// SnapshotHolding is a single position valued at snapshot time
+type SnapshotHolding struct {
+	InstrumentID  primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol        string             `bson:"symbol" json:"symbol"`
+	PositionType  PositionType       `bson:"position_type" json:"positionType"`
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	AvgEntryPrice float64            `bson:"avg_entry_price" json:"avgEntryPrice"`
+	Price         float64            `bson:"price" json:"price"`              // Closing / last price used
+	MarketValue   float64            `bson:"market_value" json:"marketValue"` // Signed: shorts are negative
+	UnrealizedPL  float64            `bson:"unrealized_pl" json:"unrealizedPL"`
+}
+
 type PortfolioSnapshot struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID        primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID     primitive.ObjectID `bson:"account_id,omitempty" json:"accountId"`
+	Kind          string             `bson:"kind,omitempty" json:"kind"`                          // EOD / INTRADAY (legacy rows have none)
+	Source        string             `bson:"source,omitempty" json:"source"`                      // LIVE / BACKFILL
+	TradingDate   *time.Time         `bson:"trading_date,omitempty" json:"tradingDate,omitempty"` // IST midnight, EOD only
 	Date          time.Time          `bson:"date" json:"date"`
 	TotalEquity   float64            `bson:"total_equity" json:"totalEquity"`
 	CashBalance   float64            `bson:"cash_balance" json:"cashBalance"`
 	HoldingsValue float64            `bson:"holdings_value" json:"holdingsValue"`
+	Holdings      []SnapshotHolding  `bson:"holdings,omitempty" json:"holdings,omitempty"`
 	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
 }
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rebalance target types
+const (
+	RebalanceByInstrument = "INSTRUMENT"
+	RebalanceBySector     = "SECTOR"
+)
+
+// DefaultRebalanceTolerance is the drift, in percentage points of portfolio weight, left untraded
+const DefaultRebalanceTolerance = 2.0
+
+// RebalanceTarget is one weight in a model allocation
+type RebalanceTarget struct {
+	InstrumentID *primitive.ObjectID  `bson:"instrument_id,omitempty" json:"instrumentId,omitempty"` // INSTRUMENT targets
+	Symbol       string               `bson:"symbol,omitempty" json:"symbol,omitempty"`
+	Sector       string               `bson:"sector,omitempty" json:"sector,omitempty"`           // SECTOR targets
+	Instruments  []primitive.ObjectID `bson:"instruments,omitempty" json:"instruments,omitempty"` // SECTOR targets: what to buy when nothing in the sector is held
+	Weight       float64              `bson:"weight" json:"weight"`                               // Percent of the rebalanced value
+}
+
+// RebalanceModel is a saved target allocation; weights that add up to less than 100 leave the rest in cash
+type RebalanceModel struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID     primitive.ObjectID `bson:"account_id" json:"accountId"`
+	Name          string             `bson:"name" json:"name"`
+	TargetType    string             `bson:"target_type" json:"targetType"` // INSTRUMENT / SECTOR
+	Targets       []RebalanceTarget  `bson:"targets" json:"targets"`
+	TolerancePct  float64            `bson:"tolerance_pct" json:"tolerancePct"`    // Drift band left untraded
+	DriftAlertPct float64            `bson:"drift_alert_pct" json:"driftAlertPct"` // Notify when any target drifts this far; 0 disables
+	OrderType     string             `bson:"order_type" json:"orderType"`          // MARKET / LIMIT at the tick-rounded LTP
+
+	LastDrift        float64    `bson:"last_drift" json:"lastDrift"` // Largest drift at the last check
+	LastCheckedAt    *time.Time `bson:"last_checked_at,omitempty" json:"lastCheckedAt,omitempty"`
+	LastAlertAt      *time.Time `bson:"last_alert_at,omitempty" json:"lastAlertAt,omitempty"`
+	LastRebalancedAt *time.Time `bson:"last_rebalanced_at,omitempty" json:"lastRebalancedAt,omitempty"`
+	CreatedAt        time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time  `bson:"updated_at" json:"updatedAt"`
+}
+
+// RebalanceRow compares one target with the current portfolio
+type RebalanceRow struct {
+	Key           string  `json:"key"` // Symbol or sector
+	CurrentValue  float64 `json:"currentValue"`
+	TargetValue   float64 `json:"targetValue"`
+	CurrentWeight float64 `json:"currentWeight"`
+	TargetWeight  float64 `json:"targetWeight"`
+	Drift         float64 `json:"drift"` // Current minus target weight, in percentage points
+	InBand        bool    `json:"inBand"`
+}
+
+// RebalanceOrder is one order a rebalance would place
+type RebalanceOrder struct {
+	InstrumentID    primitive.ObjectID `json:"instrumentId"`
+	Symbol          string             `json:"symbol"`
+	Sector          string             `json:"sector,omitempty"`
+	Side            string             `json:"side"`
+	Quantity        int                `json:"quantity"`
+	Price           float64            `json:"price"`
+	Value           float64            `json:"value"`
+	CurrentQuantity int                `json:"currentQuantity"`
+	TargetQuantity  int                `json:"targetQuantity"`
+	Charges         TradeCharges       `json:"charges"`
+}
+
+// RebalancePlan is the preview of a rebalance
+type RebalancePlan struct {
+	ModelID          *primitive.ObjectID `json:"modelId,omitempty"`
+	TargetType       string              `json:"targetType"`
+	PortfolioValue   float64             `json:"portfolioValue"` // Cash plus the holdings the targets cover
+	Cash             float64             `json:"cash"`
+	CashAfter        float64             `json:"cashAfter"`
+	TolerancePct     float64             `json:"tolerancePct"`
+	MaxDrift         float64             `json:"maxDrift"`
+	Rows             []RebalanceRow      `json:"rows"`
+	Orders           []RebalanceOrder    `json:"orders"`
+	EstimatedCharges float64             `json:"estimatedCharges"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InstrumentRiskParams sets margin rates for one instrument, exchange style:
+// initial margin = VaR margin + extreme loss margin, both as a fraction of position value.
+type InstrumentRiskParams struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	InstrumentID    primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol          string             `bson:"symbol" json:"symbol"`
+	VaRRate         float64            `bson:"var_rate" json:"varRate"`                  // e.g. 0.15
+	ELMRate         float64            `bson:"elm_rate" json:"elmRate"`                  // e.g. 0.05
+	MaintenanceRate float64            `bson:"maintenance_rate" json:"maintenanceRate"`  // Minimum equity to hold the position, e.g. 0.15
+	MaxLeverage     float64            `bson:"max_leverage" json:"maxLeverage"`          // Max short position value as a multiple of balance
+	MISMarginFactor float64            `bson:"mis_margin_factor" json:"misMarginFactor"` // Intraday (MIS) requirement as a fraction of delivery, e.g. 0.5
+	UpdatedBy       primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// InitialRate is the fraction of position value blocked to open and carry a short
+func (p InstrumentRiskParams) InitialRate() float64 {
+	return p.VaRRate + p.ELMRate
+}
+
+// InitialRateFor scales the initial rate down for intraday positions
+func (p InstrumentRiskParams) InitialRateFor(productType string) float64 {
+	if productType == ProductTypeMIS {
+		return p.InitialRate() * p.MISMarginFactor
+	}
+	return p.InitialRate()
+}
+
+// MaintenanceRateFor scales the maintenance rate down for intraday positions
+func (p InstrumentRiskParams) MaintenanceRateFor(productType string) float64 {
+	if productType == ProductTypeMIS {
+		return p.MaintenanceRate * p.MISMarginFactor
+	}
+	return p.MaintenanceRate
+}
+
+// MaxLeverageFor raises the leverage cap for intraday positions in line with the lower margin
+func (p InstrumentRiskParams) MaxLeverageFor(productType string) float64 {
+	if productType == ProductTypeMIS && p.MISMarginFactor > 0 {
+		return p.MaxLeverage / p.MISMarginFactor
+	}
+	return p.MaxLeverage
+}
+
+const (
+	MarginCallOpen       = "OPEN"
+	MarginCallMet        = "MET"        // Free margin back above zero (funds added, prices moved or positions reduced)
+	MarginCallLiquidated = "LIQUIDATED" // Closed by auto-liquidation
+)
+
+// AccountMarginCall is raised when an account's free margin (equity - initial requirement) goes negative
+type AccountMarginCall struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID  primitive.ObjectID `bson:"account_id" json:"accountId"`
+	Status     string             `bson:"status" json:"status"`
+	Equity     float64            `bson:"equity" json:"equity"`
+	Required   float64            `bson:"required" json:"required"` // Initial margin requirement when raised
+	Shortfall  float64            `bson:"shortfall" json:"shortfall"`
+	RaisedAt   time.Time          `bson:"raised_at" json:"raisedAt"`
+	ResolvedAt *time.Time         `bson:"resolved_at,omitempty" json:"resolvedAt,omitempty"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Pre-trade risk rule codes, returned with every rejection
+const (
+	RiskRuleMaxOrderQuantity   = "MAX_ORDER_QUANTITY"    // Shares per order
+	RiskRuleMaxOrderValue      = "MAX_ORDER_VALUE"       // ₹ per order
+	RiskRuleMaxPositionValue   = "MAX_POSITION_VALUE"    // ₹ per instrument after the order
+	RiskRuleMaxGrossExposure   = "MAX_GROSS_EXPOSURE"    // Longs + shorts at LTP after the order, as a multiple of cash balance
+	RiskRuleFatFinger          = "FAT_FINGER"            // Max fractional deviation of a priced order from LTP
+	RiskRuleMaxOpenOrders      = "MAX_OPEN_ORDERS"       // Working (NEW/PENDING) orders
+	RiskRuleMaxOrdersPerSecond = "MAX_ORDERS_PER_SECOND" // Order submissions in any one-second window
+	RiskRuleDailyLossLimit     = "DAILY_LOSS_LIMIT"      // ₹ lost since the previous close; breaching halts new exposure for the day
+)
+
+// Rule scopes, most specific wins: USER > INSTRUMENT > GLOBAL
+const (
+	RiskScopeGlobal     = "GLOBAL"
+	RiskScopeInstrument = "INSTRUMENT"
+	RiskScopeUser       = "USER"
+)
+
+// RiskRule is one limit of the pre-trade risk engine
+type RiskRule struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code        string             `bson:"code" json:"code"`
+	Scope       string             `bson:"scope" json:"scope"`
+	ScopeID     primitive.ObjectID `bson:"scope_id" json:"scopeId,omitempty"` // User or instrument for scoped rules; nil for GLOBAL
+	Limit       float64            `bson:"limit" json:"limit"`
+	Enabled     bool               `bson:"enabled" json:"enabled"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	UpdatedBy   primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// RiskRejection records an order refused by the risk engine
+type RiskRejection struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol       string             `bson:"symbol" json:"symbol"`
+	Side         string             `bson:"side" json:"side"`
+	Intent       string             `bson:"intent" json:"intent"`
+	OrderType    string             `bson:"order_type" json:"orderType"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	Price        float64            `bson:"price" json:"price"`
+	RuleCode     string             `bson:"rule_code" json:"ruleCode"`
+	RuleScope    string             `bson:"rule_scope" json:"ruleScope"`
+	Limit        float64            `bson:"limit" json:"limit"`
+	Observed     float64            `bson:"observed" json:"observed"`
+	Message      string             `bson:"message" json:"message"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// RiskHalt stops a user opening new exposure for the rest of a trading day
+type RiskHalt struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"userId"`
+	TradingDate time.Time          `bson:"trading_date" json:"tradingDate"` // IST midnight
+	RuleCode    string             `bson:"rule_code" json:"ruleCode"`
+	Loss        float64            `bson:"loss" json:"loss"`
+	Limit       float64            `bson:"limit" json:"limit"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+}
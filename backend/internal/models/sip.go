@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SIP frequencies
+const (
+	SIPFrequencyDaily   = "DAILY"
+	SIPFrequencyWeekly  = "WEEKLY"
+	SIPFrequencyMonthly = "MONTHLY"
+)
+
+// SIP statuses
+const (
+	SIPStatusActive    = "ACTIVE"
+	SIPStatusPaused    = "PAUSED"
+	SIPStatusCompleted = "COMPLETED"
+	SIPStatusCancelled = "CANCELLED"
+)
+
+// SIP execution outcomes
+const (
+	SIPExecutionPlaced  = "PLACED"
+	SIPExecutionSkipped = "SKIPPED" // Not enough funds, or the amount buys less than one lot
+	SIPExecutionFailed  = "FAILED"  // The order was rejected
+)
+
+// SIPPlan buys a fixed rupee amount of an instrument on a schedule
+type SIPPlan struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"userId"`
+	AccountID    primitive.ObjectID `bson:"account_id" json:"accountId"`
+	InstrumentID primitive.ObjectID `bson:"instrument_id" json:"instrumentId"`
+	Symbol       string             `bson:"symbol" json:"symbol"`
+	Exchange     string             `bson:"exchange" json:"exchange"`
+
+	Amount     float64 `bson:"amount" json:"amount"`                               // Rupees per instalment; sized to whole lots at execution
+	Frequency  string  `bson:"frequency" json:"frequency"`                         // DAILY / WEEKLY / MONTHLY
+	DayOfMonth int     `bson:"day_of_month,omitempty" json:"dayOfMonth,omitempty"` // MONTHLY: 1-28
+	DayOfWeek  int     `bson:"day_of_week,omitempty" json:"dayOfWeek,omitempty"`   // WEEKLY: 1 (Monday) - 7 (Sunday)
+
+	StepUpPct   float64 `bson:"step_up_pct,omitempty" json:"stepUpPct,omitempty"`     // Automatic amount increase...
+	StepUpEvery int     `bson:"step_up_every,omitempty" json:"stepUpEvery,omitempty"` // ...every this many instalments
+
+	Instalments     int     `bson:"instalments,omitempty" json:"instalments,omitempty"` // Orders to place before completing; 0 runs until cancelled
+	InstalmentCount int     `bson:"instalment_count" json:"instalmentCount"`            // Scheduled runs so far, including skipped ones
+	PlacedCount     int     `bson:"placed_count" json:"placedCount"`
+	InvestedAmount  float64 `bson:"invested_amount" json:"investedAmount"` // Estimated at the LTP each order was sized at
+
+	Status       string     `bson:"status" json:"status"`
+	StatusReason string     `bson:"status_reason,omitempty" json:"statusReason,omitempty"`
+	ScheduledFor time.Time  `bson:"scheduled_for" json:"scheduledFor"` // Next instalment date (IST midnight) before holiday adjustment
+	NextRunAt    time.Time  `bson:"next_run_at" json:"nextRunAt"`      // First trading day on or after ScheduledFor
+	LastRunAt    *time.Time `bson:"last_run_at,omitempty" json:"lastRunAt,omitempty"`
+	PausedAt     *time.Time `bson:"paused_at,omitempty" json:"pausedAt,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time  `bson:"updated_at" json:"updatedAt"`
+}
+
+// SIPExecution records one scheduled instalment of a plan
+type SIPExecution struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	PlanID       primitive.ObjectID  `bson:"plan_id" json:"planId"`
+	UserID       primitive.ObjectID  `bson:"user_id" json:"userId"`
+	AccountID    primitive.ObjectID  `bson:"account_id" json:"accountId"`
+	Instalment   int                 `bson:"instalment" json:"instalment"`
+	ScheduledFor time.Time           `bson:"scheduled_for" json:"scheduledFor"`
+	Amount       float64             `bson:"amount" json:"amount"`
+	Price        float64             `bson:"price" json:"price"` // LTP used for sizing
+	Quantity     int                 `bson:"quantity" json:"quantity"`
+	OrderID      *primitive.ObjectID `bson:"order_id,omitempty" json:"orderId,omitempty"`
+	Status       string              `bson:"status" json:"status"` // PLACED / SKIPPED / FAILED
+	Reason       string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	ExecutedAt   time.Time           `bson:"executed_at" json:"executedAt"`
+}
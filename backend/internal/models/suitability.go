@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RiskDisclosureVersion is the current short-sell and margin risk disclosure.
+// Bump it whenever the disclosure text changes; users must re-accept before shorting again.
+const RiskDisclosureVersion = "2026.1"
+
+// Trading experience levels
+const (
+	ExperienceNone         = "NONE"
+	ExperienceBeginner     = "BEGINNER"
+	ExperienceIntermediate = "INTERMEDIATE"
+	ExperienceExpert       = "EXPERT"
+)
+
+// Risk tolerance levels
+const (
+	RiskToleranceLow    = "LOW"
+	RiskToleranceMedium = "MEDIUM"
+	RiskToleranceHigh   = "HIGH"
+)
+
+// SuitabilityAnswers are the user's questionnaire responses
+type SuitabilityAnswers struct {
+	Experience              string `bson:"experience" json:"experience"`
+	RiskTolerance           string `bson:"risk_tolerance" json:"riskTolerance"`
+	AnnualIncomeBand        string `bson:"annual_income_band" json:"annualIncomeBand"` // e.g. "<5L", "5-10L", "10-25L", ">25L"
+	UnderstandsShortSelling bool   `bson:"understands_short_selling" json:"understandsShortSelling"`
+	UnderstandsLeverage     bool   `bson:"understands_leverage" json:"understandsLeverage"`
+	CanAffordLosses         bool   `bson:"can_afford_losses" json:"canAffordLosses"` // Can absorb losses beyond the amount invested
+}
+
+// TradingEligibility is derived from the questionnaire
+type TradingEligibility struct {
+	ShortSelling   bool    `bson:"short_selling" json:"shortSelling"`
+	MaxLeverage    float64 `bson:"max_leverage" json:"maxLeverage"`
+	AdvancedOrders bool    `bson:"advanced_orders" json:"advancedOrders"` // STOP_LIMIT, TRAILING_STOP
+}
+
+// DisclosureAcceptance records one acceptance of the risk disclosure
+type DisclosureAcceptance struct {
+	Version    string    `bson:"version" json:"version"`
+	AcceptedAt time.Time `bson:"accepted_at" json:"acceptedAt"`
+	IPAddress  string    `bson:"ip_address" json:"ipAddress"`
+}
+
+// SuitabilityProfile holds a user's latest questionnaire, eligibility and disclosure acceptance
+type SuitabilityProfile struct {
+	ID                primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID     `bson:"user_id" json:"userId"`
+	Answers           SuitabilityAnswers     `bson:"answers" json:"answers"`
+	Eligibility       TradingEligibility     `bson:"eligibility" json:"eligibility"`
+	Disclosure        DisclosureAcceptance   `bson:"disclosure" json:"disclosure"`                // Latest acceptance
+	DisclosureHistory []DisclosureAcceptance `bson:"disclosure_history" json:"disclosureHistory"` // Every acceptance, oldest first
+	CreatedAt         time.Time              `bson:"created_at" json:"createdAt"`
+	UpdatedAt         time.Time              `bson:"updated_at" json:"updatedAt"`
+}
+
+// HasCurrentDisclosure reports whether the latest disclosure version has been accepted
+func (p *SuitabilityProfile) HasCurrentDisclosure() bool {
+	return p != nil && p.Disclosure.Version == RiskDisclosureVersion
+}
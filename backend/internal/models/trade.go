@@ -22,8 +22,14 @@ type Trade struct {
 	Value    float64 `bson:"value" json:"value"`        // Qty * Price
 	NetValue float64 `bson:"net_value" json:"netValue"` // Value +/- Fees
 
+	// Itemised charges from the charge schedule in force at execution
+	ProductType           string       `bson:"product_type,omitempty" json:"productType,omitempty"` // CNC / MIS
+	Charges               TradeCharges `bson:"charges" json:"charges"`
+	ChargeScheduleVersion int          `bson:"charge_schedule_version" json:"chargeScheduleVersion"`
+
+	// Summary totals kept for existing consumers: Commission = brokerage, Fees = statutory levies
 	Commission float64 `bson:"commission" json:"commission"`
-	Fees       float64 `bson:"fees" json:"fees"` // Flat fees, taxes, etc.
+	Fees       float64 `bson:"fees" json:"fees"`
 
 	ExecutedAt time.Time `bson:"executed_at" json:"executedAt"`
 	CreatedAt  time.Time `bson:"created_at" json:"createdAt"`
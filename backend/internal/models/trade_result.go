@@ -47,3 +47,26 @@ type TradeResult struct {
 	CalculationVersion int                  `bson:"calculation_version" json:"calculationVersion"`
 	CreatedAt          time.Time            `bson:"created_at" json:"createdAt"`
 }
+
+// SpreadPnL is the combined mark-to-market P&L of a filled multi-leg order
+type SpreadPnL struct {
+	OrderID    primitive.ObjectID `json:"orderId"`
+	Symbol     string             `json:"symbol"`
+	Quantity   int                `json:"quantity"`   // Spread units
+	EntryNet   float64            `json:"entryNet"`   // Net price per unit at fill
+	CurrentNet float64            `json:"currentNet"` // Net price per unit at current LTPs
+	PnL        float64            `json:"pnl"`
+	Legs       []SpreadLegPnL     `json:"legs"`
+	FilledAt   *time.Time         `json:"filledAt,omitempty"`
+}
+
+// SpreadLegPnL is one leg's share of a SpreadPnL
+type SpreadLegPnL struct {
+	InstrumentID primitive.ObjectID `json:"instrumentId"`
+	Symbol       string             `json:"symbol"`
+	Side         string             `json:"side"`
+	Quantity     int                `json:"quantity"`
+	FillPrice    float64            `json:"fillPrice"`
+	LastPrice    float64            `json:"lastPrice"`
+	PnL          float64            `json:"pnl"`
+}
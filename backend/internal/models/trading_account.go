@@ -6,9 +6,17 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// DefaultAccountName is given to each user's first account, including accounts created before sub-accounts existed
+const DefaultAccountName = "Default"
+
+// MaxTradingAccountsPerUser caps strategy sub-accounts per user
+const MaxTradingAccountsPerUser = 10
+
 type TradingAccount struct {
 	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID            primitive.ObjectID `bson:"user_id" json:"userId"`
+	Name              string             `bson:"name" json:"name"`            // e.g. "Default", "Swing", "Intraday"
+	IsDefault         bool               `bson:"is_default" json:"isDefault"` // Used when a request names no account
 	Balance           float64            `bson:"balance" json:"balance"`
 	BlockedMargin     float64            `bson:"blocked_margin" json:"blockedMargin"` // For Short Positions
 	RealizedPL        float64            `bson:"realized_pl" json:"realizedPL"`
@@ -10,7 +10,7 @@ type Transaction struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	AccountID primitive.ObjectID `bson:"account_id" json:"accountId"`
 	UserID    primitive.ObjectID `bson:"user_id" json:"userId"`
-	Type      string             `bson:"type" json:"type"` // DEPOSIT, WITHDRAWAL, TRADE, FEE
+	Type      string             `bson:"type" json:"type"` // DEPOSIT, WITHDRAWAL, TRADE, FEE, TRANSFER_IN, TRANSFER_OUT
 	Amount    float64            `bson:"amount" json:"amount"`
 	Currency  string             `bson:"currency" json:"currency"`
 	Status    string             `bson:"status" json:"status"`       // COMPLETED, PENDING, FAILED
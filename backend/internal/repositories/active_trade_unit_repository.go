@@ -22,13 +22,15 @@ func NewActiveTradeUnitRepository(db *mongo.Database) *ActiveTradeUnitRepository
 	}
 }
 
-func (r *ActiveTradeUnitRepository) FindOpenUnit(userID, instrumentID string) (*models.ActiveTradeUnit, error) {
+func (r *ActiveTradeUnitRepository) FindOpenUnit(userID, accountID, instrumentID string) (*models.ActiveTradeUnit, error) {
 	uID, _ := primitive.ObjectIDFromHex(userID)
+	aID, _ := primitive.ObjectIDFromHex(accountID)
 	iID, _ := primitive.ObjectIDFromHex(instrumentID)
 
 	var unit models.ActiveTradeUnit
 	err := r.collection.FindOne(context.Background(), bson.M{
 		"user_id":       uID,
+		"account_id":    aID,
 		"instrument_id": iID,
 	}).Decode(&unit)
 
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AlgoOrderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAlgoOrderRepository(db *mongo.Database) *AlgoOrderRepository {
+	collection := db.Collection("algo_orders")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	})
+	return &AlgoOrderRepository{collection: collection}
+}
+
+func (r *AlgoOrderRepository) Create(ctx context.Context, algo *models.AlgoOrder) error {
+	algo.ID = primitive.NewObjectID()
+	algo.CreatedAt = time.Now()
+	algo.UpdatedAt = algo.CreatedAt
+	_, err := r.collection.InsertOne(ctx, algo)
+	return err
+}
+
+func (r *AlgoOrderRepository) Update(ctx context.Context, algo *models.AlgoOrder) error {
+	algo.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": algo.ID}, algo)
+	return err
+}
+
+func (r *AlgoOrderRepository) FindByID(ctx context.Context, id string) (*models.AlgoOrder, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var algo models.AlgoOrder
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&algo)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &algo, nil
+}
+
+// FindByUserID returns a user's algo orders, newest first, optionally filtered by status
+func (r *AlgoOrderRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, status string) ([]models.AlgoOrder, error) {
+	filter := scoped(ctx, bson.M{"user_id": userID})
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+// FindActive returns every algo that is still releasing child orders
+func (r *AlgoOrderRepository) FindActive(ctx context.Context) ([]models.AlgoOrder, error) {
+	return r.find(ctx, bson.M{"status": models.AlgoStatusActive}, options.Find().SetSort(bson.M{"created_at": 1}))
+}
+
+func (r *AlgoOrderRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.AlgoOrder, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	algos := make([]models.AlgoOrder, 0)
+	if err = cursor.All(ctx, &algos); err != nil {
+		return nil, err
+	}
+	return algos, nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BasketRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBasketRepository(db *mongo.Database) *BasketRepository {
+	collection := db.Collection("basket_templates")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &BasketRepository{collection: collection}
+}
+
+func (r *BasketRepository) Create(ctx context.Context, basket *models.BasketTemplate) error {
+	basket.ID = primitive.NewObjectID()
+	basket.CreatedAt = time.Now()
+	basket.UpdatedAt = basket.CreatedAt
+	_, err := r.collection.InsertOne(ctx, basket)
+	return err
+}
+
+func (r *BasketRepository) Update(ctx context.Context, basket *models.BasketTemplate) error {
+	basket.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": basket.ID}, basket)
+	return err
+}
+
+func (r *BasketRepository) FindByID(ctx context.Context, id string) (*models.BasketTemplate, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var basket models.BasketTemplate
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&basket)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &basket, nil
+}
+
+// FindByUserID returns a user's basket templates by name
+func (r *BasketRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.BasketTemplate, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	baskets := make([]models.BasketTemplate, 0)
+	if err = cursor.All(ctx, &baskets); err != nil {
+		return nil, err
+	}
+	return baskets, nil
+}
+
+func (r *BasketRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
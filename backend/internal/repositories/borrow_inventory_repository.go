@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BorrowInventoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBorrowInventoryRepository(db *mongo.Database) *BorrowInventoryRepository {
+	collection := db.Collection("borrow_inventory")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "instrument_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &BorrowInventoryRepository{collection: collection}
+}
+
+// Upsert writes the supply settings and shifts available quantity by availableDelta
+func (r *BorrowInventoryRepository) Upsert(ctx context.Context, inv *models.BorrowInventory, availableDelta int) error {
+	inv.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"symbol":         inv.Symbol,
+			"total_quantity": inv.TotalQuantity,
+			"tier":           inv.Tier,
+			"daily_rate":     inv.DailyRate,
+			"updated_by":     inv.UpdatedBy,
+			"updated_at":     inv.UpdatedAt,
+		},
+		"$inc": bson.M{"available_quantity": availableDelta},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"instrument_id": inv.InstrumentID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *BorrowInventoryRepository) FindByInstrumentID(ctx context.Context, instrumentID primitive.ObjectID) (*models.BorrowInventory, error) {
+	var inv models.BorrowInventory
+	err := r.collection.FindOne(ctx, bson.M{"instrument_id": instrumentID}).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *BorrowInventoryRepository) FindAll(ctx context.Context) ([]models.BorrowInventory, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"symbol": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	inventory := make([]models.BorrowInventory, 0)
+	if err = cursor.All(ctx, &inventory); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// Reserve atomically takes quantity from available supply; returns nil if there is not enough
+func (r *BorrowInventoryRepository) Reserve(ctx context.Context, instrumentID primitive.ObjectID, quantity int) (*models.BorrowInventory, error) {
+	filter := bson.M{"instrument_id": instrumentID, "available_quantity": bson.M{"$gte": quantity}}
+	update := bson.M{"$inc": bson.M{"available_quantity": -quantity}}
+
+	var inv models.BorrowInventory
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Release puts quantity back into available supply
+func (r *BorrowInventoryRepository) Release(ctx context.Context, instrumentID primitive.ObjectID, quantity int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"instrument_id": instrumentID},
+		bson.M{"$inc": bson.M{"available_quantity": quantity}})
+	return err
+}
+
+// Retire removes recalled shares from total supply once they are returned to the lender
+func (r *BorrowInventoryRepository) Retire(ctx context.Context, instrumentID primitive.ObjectID, quantity int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"instrument_id": instrumentID},
+		bson.M{"$inc": bson.M{"total_quantity": -quantity}})
+	return err
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BorrowRecordRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBorrowRecordRepository(db *mongo.Database) *BorrowRecordRepository {
+	collection := db.Collection("borrow_records")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "instrument_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "order_id", Value: 1}}},
+	})
+	return &BorrowRecordRepository{collection: collection}
+}
+
+func (r *BorrowRecordRepository) Create(ctx context.Context, record *models.BorrowRecord) error {
+	record.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, record)
+	return err
+}
+
+func (r *BorrowRecordRepository) Update(ctx context.Context, record *models.BorrowRecord) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": record.ID}, record)
+	return err
+}
+
+// GetDatabase returns the database instance for transactions
+func (r *BorrowRecordRepository) GetDatabase() *mongo.Database {
+	return r.collection.Database()
+}
+
+func (r *BorrowRecordRepository) FindByID(ctx context.Context, id string) (*models.BorrowRecord, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.findOne(ctx, bson.M{"_id": objID})
+}
+
+// FindLocateByOrderID returns the unfilled locate taken for an order, if any
+func (r *BorrowRecordRepository) FindLocateByOrderID(ctx context.Context, orderID primitive.ObjectID) (*models.BorrowRecord, error) {
+	return r.findOne(ctx, bson.M{"order_id": orderID, "status": models.BorrowStatusLocated})
+}
+
+// FindOutstanding returns a user's lent (active or recalled) borrows on an instrument, oldest first
+func (r *BorrowRecordRepository) FindOutstanding(ctx context.Context, userID, instrumentID primitive.ObjectID) ([]models.BorrowRecord, error) {
+	return r.find(ctx, scoped(ctx, bson.M{
+		"user_id":       userID,
+		"instrument_id": instrumentID,
+		"status":        bson.M{"$in": []string{models.BorrowStatusActive, models.BorrowStatusRecalled}},
+	}), options.Find().SetSort(bson.M{"borrowed_at": 1}))
+}
+
+// FindAccruing returns every borrow that is currently lent out
+func (r *BorrowRecordRepository) FindAccruing(ctx context.Context) ([]models.BorrowRecord, error) {
+	return r.find(ctx, bson.M{"status": bson.M{"$in": []string{models.BorrowStatusActive, models.BorrowStatusRecalled}}}, nil)
+}
+
+func (r *BorrowRecordRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, limit int64) ([]models.BorrowRecord, error) {
+	return r.find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"borrowed_at": -1}).SetLimit(limit))
+}
+
+func (r *BorrowRecordRepository) FindByStatus(ctx context.Context, status string, limit int64) ([]models.BorrowRecord, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.M{"borrowed_at": -1}).SetLimit(limit))
+}
+
+func (r *BorrowRecordRepository) findOne(ctx context.Context, filter bson.M) (*models.BorrowRecord, error) {
+	var record models.BorrowRecord
+	err := r.collection.FindOne(ctx, filter).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *BorrowRecordRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.BorrowRecord, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]models.BorrowRecord, 0)
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
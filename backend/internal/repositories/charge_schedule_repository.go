@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ChargeScheduleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewChargeScheduleRepository(db *mongo.Database) *ChargeScheduleRepository {
+	collection := db.Collection("charge_schedules")
+
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return &ChargeScheduleRepository{collection: collection}
+}
+
+// Create inserts a new version. Schedules are never updated in place.
+func (r *ChargeScheduleRepository) Create(ctx context.Context, schedule *models.ChargeSchedule) error {
+	schedule.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	schedule.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindEffective returns the highest version effective at the given time, or nil if none
+func (r *ChargeScheduleRepository) FindEffective(ctx context.Context, at time.Time) (*models.ChargeSchedule, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	var schedule models.ChargeSchedule
+	err := r.collection.FindOne(ctx, bson.M{"effective_from": bson.M{"$lte": at}}, opts).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// FindLatestVersion returns the highest version number stored (0 if none)
+func (r *ChargeScheduleRepository) FindLatestVersion(ctx context.Context) (int, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+
+	var schedule models.ChargeSchedule
+	err := r.collection.FindOne(ctx, bson.M{}, opts).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return schedule.Version, nil
+}
+
+// FindAll returns every version, newest first
+func (r *ChargeScheduleRepository) FindAll(ctx context.Context) ([]*models.ChargeSchedule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	schedules := make([]*models.ChargeSchedule, 0)
+	if err = cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GTTRepository struct {
+	collection *mongo.Collection
+}
+
+func NewGTTRepository(db *mongo.Database) *GTTRepository {
+	collection := db.Collection("gtt_triggers")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "instrument_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+	})
+	return &GTTRepository{collection: collection}
+}
+
+func (r *GTTRepository) Create(ctx context.Context, trigger *models.GTTTrigger) error {
+	trigger.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, trigger)
+	return err
+}
+
+func (r *GTTRepository) Update(ctx context.Context, trigger *models.GTTTrigger) error {
+	trigger.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": trigger.ID}, trigger)
+	return err
+}
+
+func (r *GTTRepository) FindByID(ctx context.Context, id string) (*models.GTTTrigger, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var trigger models.GTTTrigger
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&trigger)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &trigger, nil
+}
+
+// FindActiveForPosition returns the active trigger on a user's position in an instrument, if any
+func (r *GTTRepository) FindActiveForPosition(ctx context.Context, userID, instrumentID primitive.ObjectID) (*models.GTTTrigger, error) {
+	var trigger models.GTTTrigger
+	err := r.collection.FindOne(ctx, scoped(ctx, bson.M{
+		"user_id":       userID,
+		"instrument_id": instrumentID,
+		"status":        models.GTTStatusActive,
+	})).Decode(&trigger)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &trigger, nil
+}
+
+func (r *GTTRepository) FindActive(ctx context.Context) ([]models.GTTTrigger, error) {
+	return r.find(ctx, bson.M{"status": models.GTTStatusActive}, nil)
+}
+
+func (r *GTTRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, status string) ([]models.GTTTrigger, error) {
+	filter := scoped(ctx, bson.M{"user_id": userID})
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+// ClaimActive atomically moves an active trigger to a terminal status so it fires at most once
+func (r *GTTRepository) ClaimActive(ctx context.Context, id primitive.ObjectID, status string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.GTTStatusActive},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SyncPosition keeps active triggers in line with the position they protect: full-position triggers follow
+// its quantity, partial ones are capped at it, and all are cancelled once it is closed or flipped
+func (r *GTTRepository) SyncPosition(ctx context.Context, userID, instrumentID primitive.ObjectID, quantity int, positionType models.PositionType) error {
+	now := time.Now()
+	filter := func(extra bson.M) bson.M {
+		f := scoped(ctx, bson.M{"user_id": userID, "instrument_id": instrumentID, "status": models.GTTStatusActive})
+		for k, v := range extra {
+			f[k] = v
+		}
+		return f
+	}
+
+	closed := bson.M{}
+	if quantity > 0 {
+		closed["position_type"] = bson.M{"$ne": positionType}
+	}
+	if _, err := r.collection.UpdateMany(ctx, filter(closed), bson.M{"$set": bson.M{
+		"status":        models.GTTStatusCancelled,
+		"status_reason": "position closed",
+		"updated_at":    now,
+	}}); err != nil {
+		return err
+	}
+	if quantity <= 0 {
+		return nil
+	}
+
+	resize := bson.M{"$set": bson.M{"quantity": quantity, "updated_at": now}}
+	if _, err := r.collection.UpdateMany(ctx, filter(bson.M{"full_position": true}), resize); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateMany(ctx, filter(bson.M{"full_position": false, "quantity": bson.M{"$gt": quantity}}), resize)
+	return err
+}
+
+// ExpireBefore marks active triggers past their validity as expired
+func (r *GTTRepository) ExpireBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"status": models.GTTStatusActive, "expires_at": bson.M{"$lte": cutoff}},
+		bson.M{"$set": bson.M{"status": models.GTTStatusExpired, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *GTTRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.GTTTrigger, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	triggers := make([]models.GTTTrigger, 0)
+	if err := cursor.All(ctx, &triggers); err != nil {
+		return nil, err
+	}
+	return triggers, nil
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongo.Database) *IdempotencyRepository {
+	collection := db.Collection("idempotency_keys")
+	// Keys are unique per user and trading account; replaces the earlier per-user index
+	collection.Indexes().DropOne(context.Background(), "user_id_1_key_1")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "account_scope", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(models.IdempotencyRetention.Seconds()))},
+	})
+	return &IdempotencyRepository{collection: collection}
+}
+
+// Reserve records a key as in progress; false means the key was already taken
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyKey) (bool, error) {
+	record.ID = primitive.NewObjectID()
+	record.Status = models.IdempotencyInProgress
+	record.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *IdempotencyRepository) Find(ctx context.Context, userID primitive.ObjectID, accountScope, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "account_scope": accountScope, "key": key}).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete stores the response to replay for the key
+func (r *IdempotencyRepository) Complete(ctx context.Context, id primitive.ObjectID, code int, contentType string, body []byte) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"status":        models.IdempotencyCompleted,
+		"response_code": code,
+		"content_type":  contentType,
+		"response_body": body,
+	}})
+	return err
+}
+
+// Release frees a key whose request failed so it can be retried
+func (r *IdempotencyRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IndexRepository struct {
+	indices *mongo.Collection
+	values  *mongo.Collection
+}
+
+func NewIndexRepository(db *mongo.Database) *IndexRepository {
+	indices := db.Collection("benchmark_indices")
+	values := db.Collection("index_values")
+
+	indices.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	values.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "index_id", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return &IndexRepository{
+		indices: indices,
+		values:  values,
+	}
+}
+
+func (r *IndexRepository) Create(ctx context.Context, index *models.BenchmarkIndex) error {
+	now := time.Now()
+	index.CreatedAt = now
+	index.UpdatedAt = now
+
+	result, err := r.indices.InsertOne(ctx, index)
+	if err != nil {
+		return err
+	}
+	index.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *IndexRepository) Update(ctx context.Context, index *models.BenchmarkIndex) error {
+	index.UpdatedAt = time.Now()
+	_, err := r.indices.ReplaceOne(ctx, bson.M{"_id": index.ID}, index)
+	return err
+}
+
+func (r *IndexRepository) FindByID(ctx context.Context, id string) (*models.BenchmarkIndex, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.findOne(ctx, bson.M{"_id": objID})
+}
+
+func (r *IndexRepository) FindBySymbol(ctx context.Context, symbol string) (*models.BenchmarkIndex, error) {
+	return r.findOne(ctx, bson.M{"symbol": symbol})
+}
+
+func (r *IndexRepository) FindDefault(ctx context.Context) (*models.BenchmarkIndex, error) {
+	return r.findOne(ctx, bson.M{"is_default": true, "is_active": true})
+}
+
+func (r *IndexRepository) findOne(ctx context.Context, filter bson.M) (*models.BenchmarkIndex, error) {
+	var index models.BenchmarkIndex
+	err := r.indices.FindOne(ctx, filter).Decode(&index)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// FindAll returns indices sorted by symbol; activeOnly filters out disabled ones
+func (r *IndexRepository) FindAll(ctx context.Context, activeOnly bool) ([]*models.BenchmarkIndex, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+
+	cursor, err := r.indices.Find(ctx, filter, options.Find().SetSort(bson.M{"symbol": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	indices := make([]*models.BenchmarkIndex, 0)
+	if err = cursor.All(ctx, &indices); err != nil {
+		return nil, err
+	}
+	return indices, nil
+}
+
+// UpsertDailyValue records the latest level for an IST trading day; the last write of the day is the close
+func (r *IndexRepository) UpsertDailyValue(ctx context.Context, indexID primitive.ObjectID, date time.Time, value float64) error {
+	filter := bson.M{"index_id": indexID, "date": date}
+	update := bson.M{"$set": bson.M{"value": value}}
+	_, err := r.values.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// GetDailyValues returns closes in [from, to] ascending
+func (r *IndexRepository) GetDailyValues(ctx context.Context, indexID primitive.ObjectID, from, to time.Time) ([]models.IndexValue, error) {
+	filter := bson.M{
+		"index_id": indexID,
+		"date":     bson.M{"$gte": from, "$lte": to},
+	}
+
+	cursor, err := r.values.Find(ctx, filter, options.Find().SetSort(bson.M{"date": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	values := make([]models.IndexValue, 0)
+	if err = cursor.All(ctx, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
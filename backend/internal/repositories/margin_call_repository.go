@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MarginCallRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMarginCallRepository(db *mongo.Database) *MarginCallRepository {
+	collection := db.Collection("margin_calls")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "status", Value: 1}},
+	})
+	return &MarginCallRepository{collection: collection}
+}
+
+func (r *MarginCallRepository) Create(ctx context.Context, call *models.AccountMarginCall) error {
+	result, err := r.collection.InsertOne(ctx, call)
+	if err != nil {
+		return err
+	}
+	call.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindOpenByAccount returns the account's open margin call, or nil
+func (r *MarginCallRepository) FindOpenByAccount(ctx context.Context, accountID primitive.ObjectID) (*models.AccountMarginCall, error) {
+	var call models.AccountMarginCall
+	err := r.collection.FindOne(ctx, bson.M{"account_id": accountID, "status": models.MarginCallOpen}).Decode(&call)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+func (r *MarginCallRepository) Resolve(ctx context.Context, id primitive.ObjectID, status string) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": status, "resolved_at": now}})
+	return err
+}
+
+// FindByUserID returns the user's margin calls, newest first
+func (r *MarginCallRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, limit int64) ([]models.AccountMarginCall, error) {
+	opts := options.Find().SetSort(bson.M{"raised_at": -1}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	calls := make([]models.AccountMarginCall, 0)
+	if err = cursor.All(ctx, &calls); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MarginHealthRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMarginHealthRepository(db *mongo.Database) *MarginHealthRepository {
+	collection := db.Collection("margin_health")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "account_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &MarginHealthRepository{collection: collection}
+}
+
+// FindByAccountID returns the account's monitor state, or nil if it has never been checked
+func (r *MarginHealthRepository) FindByAccountID(ctx context.Context, accountID primitive.ObjectID) (*models.MarginHealth, error) {
+	var health models.MarginHealth
+	err := r.collection.FindOne(ctx, bson.M{"account_id": accountID}).Decode(&health)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+func (r *MarginHealthRepository) Upsert(ctx context.Context, health *models.MarginHealth) error {
+	health.ID = primitive.NilObjectID
+	doc, err := bson.Marshal(health)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(doc, &fields); err != nil {
+		return err
+	}
+	_, err = r.collection.UpdateOne(ctx, bson.M{"account_id": health.AccountID}, bson.M{"$set": fields}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *MarginHealthRepository) FindAll(ctx context.Context) ([]models.MarginHealth, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	health := make([]models.MarginHealth, 0)
+	if err = cursor.All(ctx, &health); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"aequitas/internal/models"
@@ -15,35 +16,70 @@ import (
 type OrderRepository struct {
 	db         *mongo.Database
 	collection *mongo.Collection
+	events     *mongo.Collection // order_events: append-only, never updated or deleted
 }
 
 func NewOrderRepository(db *mongo.Database) *OrderRepository {
 	collection := db.Collection("orders")
 
-	// Create unique index on client_order_id + user_id for idempotency
-	indexModel := mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "user_id", Value: 1},
-			{Key: "client_order_id", Value: 1},
-		},
-		Options: options.Index().SetUnique(true),
-	}
-	collection.Indexes().CreateOne(context.Background(), indexModel)
+	// The client order ID index is created by MigrateClientOrderIndex once account_id is backfilled
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{Keys: bson.D{{Key: "parent_order_id", Value: 1}}})
+
+	events := db.Collection("order_events")
+	events.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
 
 	return &OrderRepository{
 		db:         db,
 		collection: collection,
+		events:     events,
+	}
+}
+
+// MigrateClientOrderIndex makes client order IDs unique per trading account rather than per user,
+// dropping the earlier per-user indexes. Orders without a client order ID are left out so they never collide.
+func (r *OrderRepository) MigrateClientOrderIndex(ctx context.Context) error {
+	specs, err := r.collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
 	}
+	for _, spec := range specs {
+		if spec.Name == "user_id_1_client_order_id_1" || spec.Name == "user_client_order_id_unique" {
+			if _, err := r.collection.Indexes().DropOne(ctx, spec.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "account_id", Value: 1},
+			{Key: "client_order_id", Value: 1},
+		},
+		Options: options.Index().
+			SetName("account_client_order_id_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"client_order_id": bson.M{"$gt": ""}}),
+	})
+	return err
 }
+
+// Create stores a new order and records its ACCEPTED event
 func (r *OrderRepository) Create(ctx context.Context, order *models.Order) (*models.Order, error) {
 	order.ID = primitive.NewObjectID()
 	order.CreatedAt = time.Now()
-	order.UpdatedAt = time.Now()
+	order.UpdatedAt = orderTimestamp()
+	order.PriorityAt = order.CreatedAt
 
 	_, err := r.collection.InsertOne(ctx, order)
 	if err != nil {
 		return nil, err
 	}
+	if err := r.appendEvent(ctx, models.NewOrderEvent(order, models.OrderEventAccepted)); err != nil {
+		return nil, err
+	}
 	return order, nil
 }
 
@@ -54,7 +90,7 @@ func (r *OrderRepository) FindByUserID(ctx context.Context, userID string, filte
 	}
 
 	// Build query
-	query := bson.M{"user_id": objID}
+	query := scoped(ctx, bson.M{"user_id": objID})
 
 	// Add filters
 	if instrumentID, ok := filters["instrumentId"].(string); ok && instrumentID != "" {
@@ -120,22 +156,126 @@ func (r *OrderRepository) FindByID(ctx context.Context, id string) (*models.Orde
 	}
 	return &order, err
 }
+
+// FindByClientOrderID returns the order placed with the given client order ID on the context's trading account
+func (r *OrderRepository) FindByClientOrderID(ctx context.Context, userID primitive.ObjectID, clientOrderID string) (*models.Order, error) {
+	var order models.Order
+	err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"user_id": userID, "client_order_id": clientOrderID})).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// orderTimestamp is the current time at the millisecond precision Mongo stores, so an UpdatedAt
+// held in memory still matches the stored value for Update's concurrency check
+func orderTimestamp() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
+
+// Update saves the order if its stored status may move to order.Status and nobody has written it
+// since it was loaded (UpdatedAt unchanged), and records the resulting event (status change, fill
+// or amendment). Illegal transitions and stale writes are rejected rather than overwriting.
 func (r *OrderRepository) Update(ctx context.Context, order *models.Order) (*models.Order, error) {
-	order.UpdatedAt = time.Now()
-	_, err := r.collection.ReplaceOne(
+	loadedAt := order.UpdatedAt
+	order.UpdatedAt = orderTimestamp()
+	var before models.Order
+	err := r.collection.FindOneAndReplace(
 		ctx,
-		bson.M{"_id": order.ID},
+		bson.M{
+			"_id":        order.ID,
+			"status":     bson.M{"$in": models.OrderStatusesInto(order.Status)},
+			"updated_at": loadedAt,
+		},
 		order,
-	)
+	).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		order.UpdatedAt = loadedAt
+		return nil, r.transitionError(ctx, order)
+	}
 	if err != nil {
+		order.UpdatedAt = loadedAt
 		return nil, err
 	}
+
+	if event := models.OrderEventBetween(&before, order); event != nil {
+		if err := r.appendEvent(ctx, event); err != nil {
+			return nil, err
+		}
+	}
 	return order, nil
 }
 
+// transitionError explains why Update matched no order
+func (r *OrderRepository) transitionError(ctx context.Context, order *models.Order) error {
+	var current models.Order
+	if err := r.collection.FindOne(ctx, bson.M{"_id": order.ID}).Decode(&current); err != nil {
+		return fmt.Errorf("order %s not found", order.OrderID)
+	}
+	if !models.CanTransitionOrder(current.Status, order.Status) {
+		return fmt.Errorf("illegal order transition for %s: %s to %s", order.OrderID, current.Status, order.Status)
+	}
+	return fmt.Errorf("order %s was changed by another request; reload it and try again", order.OrderID)
+}
+
+// UpdateTrailingStop saves the trailing state (current stop and best price seen) of a PENDING
+// trailing stop without touching its other fields. False if the order is no longer pending or its
+// trail was amended since it was loaded, in which case the computed state is stale.
+func (r *OrderRepository) UpdateTrailingStop(ctx context.Context, order *models.Order) (bool, error) {
+	now := orderTimestamp()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{
+			"_id":          order.ID,
+			"status":       models.OrderStatusPending,
+			"trail_amount": order.TrailAmount,
+			"trail_type":   order.TrailType,
+		},
+		bson.M{"$set": bson.M{
+			"current_stop_price": order.CurrentStopPrice,
+			"highest_price":      order.HighestPrice,
+			"lowest_price":       order.LowestPrice,
+			"updated_at":         now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+	order.UpdatedAt = now
+	return true, nil
+}
+
+func (r *OrderRepository) appendEvent(ctx context.Context, event *models.OrderEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = time.Now()
+	_, err := r.events.InsertOne(ctx, event)
+	return err
+}
+
+// FindEvents returns an order's timeline, oldest first
+func (r *OrderRepository) FindEvents(ctx context.Context, orderID primitive.ObjectID) ([]*models.OrderEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.events.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]*models.OrderEvent, 0)
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
 // FindPendingStopOrders returns all orders with PENDING status for monitoring
 func (r *OrderRepository) FindPendingStopOrders(ctx context.Context) ([]*models.Order, error) {
-	query := bson.M{"status": "PENDING"}
+	query := bson.M{"status": "PENDING", "order_type": bson.M{"$ne": "CONDITIONAL"}}
 
 	cursor, err := r.collection.Find(ctx, query)
 	if err != nil {
@@ -151,12 +291,144 @@ func (r *OrderRepository) FindPendingStopOrders(ctx context.Context) ([]*models.
 	return orders, nil
 }
 
-// FindNewLimitOrders returns all orders with status NEW and type LIMIT
+// FindPendingConditionalOrders returns CONDITIONAL orders still waiting for their condition
+func (r *OrderRepository) FindPendingConditionalOrders(ctx context.Context) ([]*models.Order, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"status": "PENDING", "order_type": "CONDITIONAL"})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ClaimPending atomically moves a PENDING order to TRIGGERED with the given reason code and trigger
+// price (nil if unknown), refreshes order with the claimed state and records the event; false if it
+// is no longer pending, so only one caller ever releases the order
+func (r *OrderRepository) ClaimPending(ctx context.Context, order *models.Order, reason string, triggerPrice *float64) (bool, error) {
+	now := orderTimestamp()
+	set := bson.M{"status": models.OrderStatusTriggered, "status_reason": reason, "triggered_at": now, "updated_at": now}
+	if triggerPrice != nil {
+		set["trigger_price"] = *triggerPrice
+	}
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": order.ID, "status": models.OrderStatusPending},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(order)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	event := models.NewOrderEvent(order, models.OrderEventTriggered)
+	event.FromStatus = models.OrderStatusPending
+	event.Reason = reason
+	return true, r.appendEvent(ctx, event)
+}
+
+// FindOpenDayOrders returns working (NEW or PENDING) DAY orders placed before the given time.
+// Legs are left out; they expire with their MULTI_LEG parent.
+func (r *OrderRepository) FindOpenDayOrders(ctx context.Context, placedBefore time.Time) ([]*models.Order, error) {
+	query := bson.M{
+		"status":     bson.M{"$in": []string{models.OrderStatusNew, models.OrderStatusPending}},
+		"validity":   "DAY",
+		"order_type": bson.M{"$ne": "LEG"},
+		"created_at": bson.M{"$lt": placedBefore},
+	}
+
+	cursor, err := r.collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// FindNewLimitOrders returns all orders with status NEW and type LIMIT in time priority
 func (r *OrderRepository) FindNewLimitOrders(ctx context.Context) ([]*models.Order, error) {
 	query := bson.M{
 		"status":     "NEW",
 		"order_type": "LIMIT",
 	}
+	opts := options.Find().SetSort(bson.D{{Key: "priority_at", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// FindNewMultiLegOrders returns working MULTI_LEG parents waiting for their net price
+func (r *OrderRepository) FindNewMultiLegOrders(ctx context.Context) ([]*models.Order, error) {
+	query := bson.M{
+		"status":     "NEW",
+		"order_type": "MULTI_LEG",
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// FindFilledMultiLegOrders returns the user's filled MULTI_LEG parents, newest first
+func (r *OrderRepository) FindFilledMultiLegOrders(ctx context.Context, userID primitive.ObjectID) ([]*models.Order, error) {
+	query := scoped(ctx, bson.M{
+		"user_id":    userID,
+		"order_type": "MULTI_LEG",
+		"status":     "FILLED",
+	})
+	opts := options.Find().SetSort(bson.D{{Key: "filled_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := make([]*models.Order, 0)
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// FindActiveByUserAndInstrument returns the user's working (NEW or PENDING) orders on an instrument
+func (r *OrderRepository) FindActiveByUserAndInstrument(ctx context.Context, userID, instrumentID primitive.ObjectID) ([]*models.Order, error) {
+	query := scoped(ctx, bson.M{
+		"user_id":       userID,
+		"instrument_id": instrumentID,
+		"status":        bson.M{"$in": []string{"NEW", "PENDING"}},
+	})
 
 	cursor, err := r.collection.Find(ctx, query)
 	if err != nil {
@@ -172,8 +444,8 @@ func (r *OrderRepository) FindNewLimitOrders(ctx context.Context) ([]*models.Ord
 	return orders, nil
 }
 
-// GetPendingQuantity calculates total quantity of active (NEW) orders for specific intent
-func (r *OrderRepository) GetPendingQuantity(userID string, instrumentID string, intent string) (int, error) {
+// GetPendingQuantity calculates the total unfilled quantity of active (NEW) orders for specific intent
+func (r *OrderRepository) GetPendingQuantity(ctx context.Context, userID string, instrumentID string, intent string) (int, error) {
 	userUID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return 0, err
@@ -185,32 +457,32 @@ func (r *OrderRepository) GetPendingQuantity(userID string, instrumentID string,
 
 	pipeline := []bson.M{
 		{
-			"$match": bson.M{
+			"$match": scoped(ctx, bson.M{
 				"user_id":       userUID,
 				"instrument_id": instrID,
 				"status":        "NEW", // Actively on the book
 				"intent":        intent,
-			},
+			}),
 		},
 		{
 			"$group": bson.M{
 				"_id":   nil,
-				"total": bson.M{"$sum": "$quantity"},
+				"total": bson.M{"$sum": bson.M{"$subtract": bson.A{"$quantity", bson.M{"$ifNull": bson.A{"$filled_quantity", 0}}}}},
 			},
 		},
 	}
 
-	cursor, err := r.collection.Aggregate(context.Background(), pipeline)
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return 0, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var result []struct {
 		Total int `bson:"total"`
 	}
 
-	if cursor.Next(context.Background()) {
+	if cursor.Next(ctx) {
 		if err := cursor.Decode(&result); err == nil && len(result) > 0 {
 			return result[0].Total, nil
 		}
@@ -229,3 +501,27 @@ func (r *OrderRepository) GetPendingQuantity(userID string, instrumentID string,
 func (r *OrderRepository) GetDatabase() *mongo.Database {
 	return r.db
 }
+
+// CountOpenByUser counts the user's working (NEW or PENDING) orders
+func (r *OrderRepository) CountOpenByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, scoped(ctx, bson.M{
+		"user_id": userID,
+		"status":  bson.M{"$in": []string{"NEW", "PENDING"}},
+	}))
+}
+
+// FindByParentOrderID returns the child orders released by a parent (stop, algo or multi-leg) order, oldest first
+func (r *OrderRepository) FindByParentOrderID(ctx context.Context, parentID primitive.ObjectID) ([]*models.Order, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"parent_order_id": parentID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := make([]*models.Order, 0)
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -17,6 +17,18 @@ type PortfolioRepository struct {
 }
 
 func NewPortfolioRepository(db *mongo.Database) *PortfolioRepository {
+	// Exactly one official EOD snapshot per trading account per trading day
+	history := db.Collection("portfolio_history")
+	history.Indexes().DropOne(context.Background(), "user_id_1_trading_date_1")
+	history.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "trading_date", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"kind": models.SnapshotKindEOD, "account_id": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}}},
+	})
+
 	return &PortfolioRepository{
 		collection: db.Collection("holdings"),
 	}
@@ -29,10 +41,10 @@ func (r *PortfolioRepository) GetHoldings(ctx context.Context, userID string) ([
 		return nil, err
 	}
 
-	filter := bson.M{
+	filter := scoped(ctx, bson.M{
 		"user_id":  objID,
 		"quantity": bson.M{"$gt": 0}, // Only return active positions
-	}
+	})
 
 	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
@@ -58,10 +70,10 @@ func (r *PortfolioRepository) GetHolding(ctx context.Context, userID, instrument
 		return nil, err
 	}
 
-	filter := bson.M{
+	filter := scoped(ctx, bson.M{
 		"user_id":       uID,
 		"instrument_id": iID,
-	}
+	})
 
 	var holding models.Holding
 	err = r.collection.FindOne(ctx, filter).Decode(&holding)
@@ -80,22 +92,27 @@ func (r *PortfolioRepository) UpsertHolding(ctx context.Context, holding *models
 		"user_id":       holding.UserID,
 		"instrument_id": holding.InstrumentID,
 	}
+	if !holding.AccountID.IsZero() {
+		filter["account_id"] = holding.AccountID
+	}
 
 	update := bson.M{
 		"$set": bson.M{
-			"quantity":        holding.Quantity,
-			"avg_entry_price": holding.AvgEntryPrice,
-			"total_cost":      holding.TotalCost,
-			"realized_pl":     holding.RealizedPL,
-			"unrealized_pl":   holding.UnrealizedPL,
-			"total_pl":        holding.TotalPL,
-			"position_type":   holding.PositionType,
-			"blocked_margin":  holding.BlockedMargin,
-			"initial_margin":  holding.InitialMargin,
-			"margin_status":   holding.MarginStatus,
-			"last_updated":    time.Now(),
-			"symbol":          holding.Symbol,
-			"account_id":      holding.AccountID,
+			"quantity":           holding.Quantity,
+			"avg_entry_price":    holding.AvgEntryPrice,
+			"total_cost":         holding.TotalCost,
+			"realized_pl":        holding.RealizedPL,
+			"unrealized_pl":      holding.UnrealizedPL,
+			"total_pl":           holding.TotalPL,
+			"position_type":      holding.PositionType,
+			"product_type":       holding.ProductType,
+			"blocked_margin":     holding.BlockedMargin,
+			"initial_margin":     holding.InitialMargin,
+			"maintenance_margin": holding.MaintenanceMargin,
+			"margin_status":      holding.MarginStatus,
+			"last_updated":       time.Now(),
+			"symbol":             holding.Symbol,
+			"account_id":         holding.AccountID,
 		},
 		"$setOnInsert": bson.M{
 			"created_at": time.Now(),
@@ -107,6 +124,39 @@ func (r *PortfolioRepository) UpsertHolding(ctx context.Context, holding *models
 	return err
 }
 
+// FindOpenByProductType returns open positions across all users held under a product type
+func (r *PortfolioRepository) FindOpenByProductType(ctx context.Context, productType string) ([]models.Holding, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"product_type": productType,
+		"quantity":     bson.M{"$gt": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	holdings := make([]models.Holding, 0)
+	if err = cursor.All(ctx, &holdings); err != nil {
+		return nil, err
+	}
+	return holdings, nil
+}
+
+// MarkHolding stores the latest mark-to-market margin figures for a position
+func (r *PortfolioRepository) MarkHolding(ctx context.Context, holdingID primitive.ObjectID, markPrice, blocked, maintenance float64, status models.MarginStatus) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": holdingID}, bson.M{
+		"$set": bson.M{
+			"mark_price":         markPrice,
+			"marked_at":          now,
+			"blocked_margin":     blocked,
+			"maintenance_margin": maintenance,
+			"margin_status":      status,
+		},
+	})
+	return err
+}
+
 // DeleteHolding removes a holding record (used when fully exited)
 func (r *PortfolioRepository) DeleteHolding(ctx context.Context, holdingID string) error {
 	objID, err := primitive.ObjectIDFromHex(holdingID)
@@ -141,7 +191,7 @@ func (r *PortfolioRepository) GetSnapshots(ctx context.Context, userID string, l
 		// Let's keep it simple: return all for now and filter in service/frontend
 	}
 
-	filter := bson.M{"user_id": objID}
+	filter := scoped(ctx, bson.M{"user_id": objID})
 
 	cursor, err := historyCollection.Find(ctx, filter, opts)
 	if err != nil {
@@ -155,3 +205,94 @@ func (r *PortfolioRepository) GetSnapshots(ctx context.Context, userID string, l
 	}
 	return snapshots, nil
 }
+
+// GetSnapshotsBetween retrieves snapshots in [from, to] sorted by date ascending
+func (r *PortfolioRepository) GetSnapshotsBetween(ctx context.Context, userID string, from, to time.Time) ([]models.PortfolioSnapshot, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	historyCollection := r.collection.Database().Collection("portfolio_history")
+
+	filter := scoped(ctx, bson.M{
+		"user_id": objID,
+		"date":    bson.M{"$gte": from, "$lte": to},
+	})
+	opts := options.Find().SetSort(bson.M{"date": 1})
+
+	cursor, err := historyCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	snapshots := make([]models.PortfolioSnapshot, 0)
+	if err = cursor.All(ctx, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// UpsertEODSnapshot writes the official snapshot for the snapshot's trading day, replacing any earlier attempt
+func (r *PortfolioRepository) UpsertEODSnapshot(ctx context.Context, snapshot *models.PortfolioSnapshot) error {
+	historyCollection := r.collection.Database().Collection("portfolio_history")
+
+	filter := bson.M{
+		"user_id":      snapshot.UserID,
+		"kind":         models.SnapshotKindEOD,
+		"trading_date": snapshot.TradingDate,
+	}
+	if !snapshot.AccountID.IsZero() {
+		filter["account_id"] = snapshot.AccountID
+	}
+	_, err := historyCollection.ReplaceOne(ctx, filter, snapshot, options.Replace().SetUpsert(true))
+	return err
+}
+
+// FindLatestEODDate returns the most recent trading day with an EOD snapshot, or nil if none
+func (r *PortfolioRepository) FindLatestEODDate(ctx context.Context, userID primitive.ObjectID) (*time.Time, error) {
+	historyCollection := r.collection.Database().Collection("portfolio_history")
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "trading_date", Value: -1}})
+	var snapshot models.PortfolioSnapshot
+	err := historyCollection.FindOne(ctx, scoped(ctx, bson.M{"user_id": userID, "kind": models.SnapshotKindEOD}), opts).Decode(&snapshot)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.TradingDate, nil
+}
+
+// DeleteIntradaySnapshotsBefore prunes non-EOD snapshots (including legacy untagged rows) older than the cutoff
+func (r *PortfolioRepository) DeleteIntradaySnapshotsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	historyCollection := r.collection.Database().Collection("portfolio_history")
+
+	result, err := historyCollection.DeleteMany(ctx, bson.M{
+		"kind": bson.M{"$ne": models.SnapshotKindEOD},
+		"date": bson.M{"$lt": cutoff},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// FindLatestEODBefore returns the most recent EOD snapshot for a trading day before the given date, or nil if none
+func (r *PortfolioRepository) FindLatestEODBefore(ctx context.Context, userID primitive.ObjectID, before time.Time) (*models.PortfolioSnapshot, error) {
+	historyCollection := r.collection.Database().Collection("portfolio_history")
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "trading_date", Value: -1}})
+	filter := scoped(ctx, bson.M{"user_id": userID, "kind": models.SnapshotKindEOD, "trading_date": bson.M{"$lt": before}})
+	var snapshot models.PortfolioSnapshot
+	err := historyCollection.FindOne(ctx, filter, opts).Decode(&snapshot)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RebalanceModelRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRebalanceModelRepository(db *mongo.Database) *RebalanceModelRepository {
+	collection := db.Collection("rebalance_models")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "account_id", Value: 1}, {Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "drift_alert_pct", Value: 1}}},
+	})
+	return &RebalanceModelRepository{collection: collection}
+}
+
+func (r *RebalanceModelRepository) Create(ctx context.Context, model *models.RebalanceModel) error {
+	model.ID = primitive.NewObjectID()
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	_, err := r.collection.InsertOne(ctx, model)
+	return err
+}
+
+func (r *RebalanceModelRepository) Update(ctx context.Context, model *models.RebalanceModel) error {
+	model.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": model.ID}, model)
+	return err
+}
+
+func (r *RebalanceModelRepository) FindByID(ctx context.Context, id string) (*models.RebalanceModel, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var model models.RebalanceModel
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&model)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// FindByUserID returns the user's models for the account in scope, by name
+func (r *RebalanceModelRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.RebalanceModel, error) {
+	return r.find(ctx, scoped(ctx, bson.M{"user_id": userID}), options.Find().SetSort(bson.M{"name": 1}))
+}
+
+// FindWithDriftAlerts returns every model that asks for drift notifications
+func (r *RebalanceModelRepository) FindWithDriftAlerts(ctx context.Context) ([]models.RebalanceModel, error) {
+	return r.find(ctx, bson.M{"drift_alert_pct": bson.M{"$gt": 0}}, options.Find())
+}
+
+func (r *RebalanceModelRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *RebalanceModelRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.RebalanceModel, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rebalanceModels := make([]models.RebalanceModel, 0)
+	if err = cursor.All(ctx, &rebalanceModels); err != nil {
+		return nil, err
+	}
+	return rebalanceModels, nil
+}
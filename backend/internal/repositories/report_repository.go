@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReportRepository struct {
+	notes      *mongo.Collection
+	statements *mongo.Collection
+}
+
+func NewReportRepository(db *mongo.Database) *ReportRepository {
+	notes := db.Collection("contract_notes")
+	statements := db.Collection("account_statements")
+
+	// One contract note per trading account per trading day, one statement per account per month
+	notes.Indexes().DropOne(context.Background(), "user_id_1_trade_date_-1")
+	notes.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "trade_date", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "trade_date", Value: -1}}},
+	})
+	statements.Indexes().DropOne(context.Background(), "user_id_1_period_-1")
+	statements.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "period", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "period", Value: -1}}},
+	})
+
+	return &ReportRepository{
+		notes:      notes,
+		statements: statements,
+	}
+}
+
+// UpsertContractNote replaces the note for the account/day so that regeneration is idempotent
+func (r *ReportRepository) UpsertContractNote(ctx context.Context, note *models.ContractNote) (*models.ContractNote, error) {
+	now := time.Now()
+	note.UpdatedAt = now
+
+	filter := bson.M{"account_id": note.AccountID, "trade_date": note.TradeDate}
+	update := bson.M{
+		"$set": bson.M{
+			"note_number":       note.NoteNumber,
+			"user_id":           note.UserID,
+			"lines":             note.Lines,
+			"gross_buy_value":   note.GrossBuyValue,
+			"gross_sell_value":  note.GrossSellValue,
+			"total_brokerage":   note.TotalBrokerage,
+			"statutory_charges": note.StatutoryCharges,
+			"charges":           note.Charges,
+			"net_obligation":    note.NetObligation,
+			"updated_at":        now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.ContractNote
+	if err := r.notes.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (r *ReportRepository) FindContractNoteByID(ctx context.Context, id string) (*models.ContractNote, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var note models.ContractNote
+	err = r.notes.FindOne(ctx, bson.M{"_id": objID}).Decode(&note)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &note, err
+}
+
+// FindContractNotesByUserID returns the most recent notes first
+func (r *ReportRepository) FindContractNotesByUserID(ctx context.Context, userID string, limit int) ([]*models.ContractNote, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "trade_date", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"lines": 0}) // Lines are fetched on download
+
+	cursor, err := r.notes.Find(ctx, scoped(ctx, bson.M{"user_id": objID}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notes := make([]*models.ContractNote, 0)
+	if err = cursor.All(ctx, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *ReportRepository) MarkContractNoteEmailed(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.notes.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"emailed_at": time.Now()}})
+	return err
+}
+
+// UpsertStatement replaces the statement for the account/period
+func (r *ReportRepository) UpsertStatement(ctx context.Context, stmt *models.AccountStatement) (*models.AccountStatement, error) {
+	now := time.Now()
+	stmt.UpdatedAt = now
+
+	filter := bson.M{"account_id": stmt.AccountID, "period": stmt.Period}
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":           stmt.UserID,
+			"period_start":      stmt.PeriodStart,
+			"period_end":        stmt.PeriodEnd,
+			"opening_balance":   stmt.OpeningBalance,
+			"deposits":          stmt.Deposits,
+			"withdrawals":       stmt.Withdrawals,
+			"trade_settlements": stmt.TradeSettlements,
+			"adjustments":       stmt.Adjustments,
+			"fees":              stmt.Fees,
+			"closing_balance":   stmt.ClosingBalance,
+			"entries":           stmt.Entries,
+			"updated_at":        now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.AccountStatement
+	if err := r.statements.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (r *ReportRepository) FindStatementByID(ctx context.Context, id string) (*models.AccountStatement, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmt models.AccountStatement
+	err = r.statements.FindOne(ctx, bson.M{"_id": objID}).Decode(&stmt)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &stmt, err
+}
+
+func (r *ReportRepository) FindStatementsByUserID(ctx context.Context, userID string) ([]*models.AccountStatement, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "period", Value: -1}}).
+		SetProjection(bson.M{"entries": 0})
+
+	cursor, err := r.statements.Find(ctx, scoped(ctx, bson.M{"user_id": objID}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	statements := make([]*models.AccountStatement, 0)
+	if err = cursor.All(ctx, &statements); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+func (r *ReportRepository) MarkStatementEmailed(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.statements.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"emailed_at": time.Now()}})
+	return err
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RiskParamsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRiskParamsRepository(db *mongo.Database) *RiskParamsRepository {
+	collection := db.Collection("instrument_risk_params")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "instrument_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &RiskParamsRepository{collection: collection}
+}
+
+// Upsert creates or replaces the override for an instrument
+func (r *RiskParamsRepository) Upsert(ctx context.Context, params *models.InstrumentRiskParams) error {
+	params.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"symbol":            params.Symbol,
+		"var_rate":          params.VaRRate,
+		"elm_rate":          params.ELMRate,
+		"maintenance_rate":  params.MaintenanceRate,
+		"max_leverage":      params.MaxLeverage,
+		"mis_margin_factor": params.MISMarginFactor,
+		"updated_by":        params.UpdatedBy,
+		"updated_at":        params.UpdatedAt,
+	}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"instrument_id": params.InstrumentID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *RiskParamsRepository) FindByInstrumentID(ctx context.Context, instrumentID primitive.ObjectID) (*models.InstrumentRiskParams, error) {
+	var params models.InstrumentRiskParams
+	err := r.collection.FindOne(ctx, bson.M{"instrument_id": instrumentID}).Decode(&params)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func (r *RiskParamsRepository) FindAll(ctx context.Context) ([]models.InstrumentRiskParams, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"symbol": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	params := make([]models.InstrumentRiskParams, 0)
+	if err = cursor.All(ctx, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
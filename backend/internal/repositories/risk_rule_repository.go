@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RiskRuleRepository stores risk engine rules, rejections and daily halts
+type RiskRuleRepository struct {
+	rules      *mongo.Collection
+	rejections *mongo.Collection
+	halts      *mongo.Collection
+}
+
+func NewRiskRuleRepository(db *mongo.Database) *RiskRuleRepository {
+	rules := db.Collection("risk_rules")
+	rules.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}, {Key: "scope", Value: 1}, {Key: "scope_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	rejections := db.Collection("risk_rejections")
+	rejections.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	halts := db.Collection("risk_halts")
+	halts.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "trading_date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return &RiskRuleRepository{rules: rules, rejections: rejections, halts: halts}
+}
+
+// UpsertRule creates or replaces the rule for its code and scope
+func (r *RiskRuleRepository) UpsertRule(ctx context.Context, rule *models.RiskRule) error {
+	rule.UpdatedAt = time.Now()
+	filter := bson.M{"code": rule.Code, "scope": rule.Scope, "scope_id": rule.ScopeID}
+	update := bson.M{"$set": bson.M{
+		"limit":       rule.Limit,
+		"enabled":     rule.Enabled,
+		"description": rule.Description,
+		"updated_by":  rule.UpdatedBy,
+		"updated_at":  rule.UpdatedAt,
+	}}
+	_, err := r.rules.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// InsertRuleIfMissing seeds a default without overwriting an edited rule
+func (r *RiskRuleRepository) InsertRuleIfMissing(ctx context.Context, rule *models.RiskRule) error {
+	rule.UpdatedAt = time.Now()
+	filter := bson.M{"code": rule.Code, "scope": rule.Scope, "scope_id": rule.ScopeID}
+	_, err := r.rules.UpdateOne(ctx, filter, bson.M{"$setOnInsert": rule}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *RiskRuleRepository) FindRule(ctx context.Context, code, scope string, scopeID primitive.ObjectID) (*models.RiskRule, error) {
+	var rule models.RiskRule
+	err := r.rules.FindOne(ctx, bson.M{"code": code, "scope": scope, "scope_id": scopeID}).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindApplicableRules returns global rules plus any overrides for the user or instrument
+func (r *RiskRuleRepository) FindApplicableRules(ctx context.Context, userID, instrumentID primitive.ObjectID) ([]models.RiskRule, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"scope": models.RiskScopeGlobal},
+		{"scope": models.RiskScopeUser, "scope_id": userID},
+		{"scope": models.RiskScopeInstrument, "scope_id": instrumentID},
+	}}
+	return r.findRules(ctx, filter)
+}
+
+func (r *RiskRuleRepository) FindAllRules(ctx context.Context) ([]models.RiskRule, error) {
+	return r.findRules(ctx, bson.M{})
+}
+
+func (r *RiskRuleRepository) FindRuleByID(ctx context.Context, id primitive.ObjectID) (*models.RiskRule, error) {
+	var rule models.RiskRule
+	err := r.rules.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *RiskRuleRepository) DeleteRule(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.rules.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *RiskRuleRepository) findRules(ctx context.Context, filter bson.M) ([]models.RiskRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "code", Value: 1}, {Key: "scope", Value: 1}})
+	cursor, err := r.rules.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := make([]models.RiskRule, 0)
+	if err = cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *RiskRuleRepository) CreateRejection(ctx context.Context, rejection *models.RiskRejection) error {
+	rejection.ID = primitive.NewObjectID()
+	rejection.CreatedAt = time.Now()
+	_, err := r.rejections.InsertOne(ctx, rejection)
+	return err
+}
+
+func (r *RiskRuleRepository) FindRejectionsSince(ctx context.Context, since time.Time, limit int64) ([]models.RiskRejection, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit)
+	cursor, err := r.rejections.Find(ctx, bson.M{"created_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rejections := make([]models.RiskRejection, 0)
+	if err = cursor.All(ctx, &rejections); err != nil {
+		return nil, err
+	}
+	return rejections, nil
+}
+
+// CountRejectionsByRule groups rejections since a point in time by rule code
+func (r *RiskRuleRepository) CountRejectionsByRule(ctx context.Context, since time.Time) (map[string]int64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": bson.M{"$gte": since}}},
+		{"$group": bson.M{"_id": "$rule_code", "count": bson.M{"$sum": 1}}},
+	}
+	cursor, err := r.rejections.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Code  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Code] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateHalt records a daily halt; a second halt for the same day is ignored
+func (r *RiskRuleRepository) CreateHalt(ctx context.Context, halt *models.RiskHalt) error {
+	halt.CreatedAt = time.Now()
+	filter := bson.M{"user_id": halt.UserID, "trading_date": halt.TradingDate}
+	_, err := r.halts.UpdateOne(ctx, filter, bson.M{"$setOnInsert": halt}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *RiskRuleRepository) FindHalt(ctx context.Context, userID primitive.ObjectID, tradingDate time.Time) (*models.RiskHalt, error) {
+	var halt models.RiskHalt
+	err := r.halts.FindOne(ctx, bson.M{"user_id": userID, "trading_date": tradingDate}).Decode(&halt)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &halt, nil
+}
+
+func (r *RiskRuleRepository) FindHaltsForDate(ctx context.Context, tradingDate time.Time) ([]models.RiskHalt, error) {
+	cursor, err := r.halts.Find(ctx, bson.M{"trading_date": tradingDate})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	halts := make([]models.RiskHalt, 0)
+	if err = cursor.All(ctx, &halts); err != nil {
+		return nil, err
+	}
+	return halts, nil
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SIPRepository struct {
+	plans      *mongo.Collection
+	executions *mongo.Collection
+}
+
+func NewSIPRepository(db *mongo.Database) *SIPRepository {
+	plans := db.Collection("sip_plans")
+	plans.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_run_at", Value: 1}}},
+	})
+	executions := db.Collection("sip_executions")
+	executions.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "plan_id", Value: 1}, {Key: "executed_at", Value: -1}},
+	})
+	return &SIPRepository{plans: plans, executions: executions}
+}
+
+func (r *SIPRepository) Create(ctx context.Context, plan *models.SIPPlan) error {
+	plan.ID = primitive.NewObjectID()
+	plan.CreatedAt = time.Now()
+	plan.UpdatedAt = plan.CreatedAt
+	_, err := r.plans.InsertOne(ctx, plan)
+	return err
+}
+
+func (r *SIPRepository) Update(ctx context.Context, plan *models.SIPPlan) error {
+	plan.UpdatedAt = time.Now()
+	_, err := r.plans.ReplaceOne(ctx, bson.M{"_id": plan.ID}, plan)
+	return err
+}
+
+func (r *SIPRepository) FindByID(ctx context.Context, id string) (*models.SIPPlan, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var plan models.SIPPlan
+	err = r.plans.FindOne(ctx, bson.M{"_id": objID}).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// FindByUserID returns a user's plans, newest first, optionally filtered by status
+func (r *SIPRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, status string) ([]models.SIPPlan, error) {
+	filter := scoped(ctx, bson.M{"user_id": userID})
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+// FindDue returns active plans whose next run has arrived
+func (r *SIPRepository) FindDue(ctx context.Context, now time.Time) ([]models.SIPPlan, error) {
+	filter := bson.M{"status": models.SIPStatusActive, "next_run_at": bson.M{"$lte": now}}
+	return r.find(ctx, filter, options.Find().SetSort(bson.M{"next_run_at": 1}))
+}
+
+// ClaimRun moves a due plan's next run forward unless another pass already has
+func (r *SIPRepository) ClaimRun(ctx context.Context, id primitive.ObjectID, due, scheduledFor, nextRunAt time.Time) (bool, error) {
+	res, err := r.plans.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.SIPStatusActive, "next_run_at": due},
+		bson.M{"$set": bson.M{"scheduled_for": scheduledFor, "next_run_at": nextRunAt, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *SIPRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.SIPPlan, error) {
+	cursor, err := r.plans.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	plans := make([]models.SIPPlan, 0)
+	if err = cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (r *SIPRepository) CreateExecution(ctx context.Context, execution *models.SIPExecution) error {
+	execution.ID = primitive.NewObjectID()
+	_, err := r.executions.InsertOne(ctx, execution)
+	return err
+}
+
+// FindExecutions returns a plan's execution history, newest first
+func (r *SIPRepository) FindExecutions(ctx context.Context, planID primitive.ObjectID, limit int) ([]models.SIPExecution, error) {
+	opts := options.Find().SetSort(bson.M{"executed_at": -1}).SetLimit(int64(limit))
+	cursor, err := r.executions.Find(ctx, bson.M{"plan_id": planID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	executions := make([]models.SIPExecution, 0)
+	if err = cursor.All(ctx, &executions); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
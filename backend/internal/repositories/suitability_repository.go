@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"aequitas/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SuitabilityRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSuitabilityRepository(db *mongo.Database) *SuitabilityRepository {
+	collection := db.Collection("suitability_profiles")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &SuitabilityRepository{collection: collection}
+}
+
+func (r *SuitabilityRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.SuitabilityProfile, error) {
+	var profile models.SuitabilityProfile
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&profile)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Save replaces the user's answers and eligibility and appends the acceptance to the history
+func (r *SuitabilityRepository) Save(ctx context.Context, profile *models.SuitabilityProfile) error {
+	profile.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"answers":     profile.Answers,
+			"eligibility": profile.Eligibility,
+			"disclosure":  profile.Disclosure,
+			"updated_at":  profile.UpdatedAt,
+		},
+		"$push":        bson.M{"disclosure_history": profile.Disclosure},
+		"$setOnInsert": bson.M{"created_at": profile.UpdatedAt},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"user_id": profile.UserID}, update, options.Update().SetUpsert(true))
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TradeRepository struct {
@@ -38,7 +39,7 @@ func (r *TradeRepository) FindByUserID(ctx context.Context, userID string) ([]*m
 		return nil, err
 	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{"user_id": objID})
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"user_id": objID}))
 	if err != nil {
 		return nil, err
 	}
@@ -76,3 +77,47 @@ func (r *TradeRepository) CountRecent(ctx context.Context, duration time.Duratio
 	count, err := r.collection.CountDocuments(ctx, bson.M{"created_at": bson.M{"$gte": since}})
 	return count, err
 }
+
+// FindByUserIDBetween returns a user's trades executed in [from, to), oldest first
+func (r *TradeRepository) FindByUserIDBetween(ctx context.Context, userID string, from, to time.Time) ([]*models.Trade, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := scoped(ctx, bson.M{
+		"user_id":     objID,
+		"executed_at": bson.M{"$gte": from, "$lt": to},
+	})
+	opts := options.Find().SetSort(bson.D{{Key: "executed_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	trades := []*models.Trade{}
+	if err = cursor.All(ctx, &trades); err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// FindTradedUserIDs returns the distinct users who traded in [from, to)
+func (r *TradeRepository) FindTradedUserIDs(ctx context.Context, from, to time.Time) ([]primitive.ObjectID, error) {
+	values, err := r.collection.Distinct(ctx, "user_id", bson.M{
+		"executed_at": bson.M{"$gte": from, "$lt": to},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs, nil
+}
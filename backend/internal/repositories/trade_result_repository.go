@@ -33,7 +33,7 @@ func (r *TradeResultRepository) Create(tr *models.TradeResult) (*models.TradeRes
 	return tr, nil
 }
 
-func (r *TradeResultRepository) FindByUserID(userID string) ([]*models.TradeResult, error) {
+func (r *TradeResultRepository) FindByUserID(ctx context.Context, userID string) ([]*models.TradeResult, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, err
@@ -42,14 +42,14 @@ func (r *TradeResultRepository) FindByUserID(userID string) ([]*models.TradeResu
 	// Sort by ExitTime descending (newest first)
 	opts := options.Find().SetSort(bson.D{{Key: "exit_time", Value: -1}})
 
-	cursor, err := r.collection.Find(context.Background(), bson.M{"user_id": objID}, opts)
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"user_id": objID}), opts)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	results := []*models.TradeResult{}
-	if err = cursor.All(context.Background(), &results); err != nil {
+	if err = cursor.All(ctx, &results); err != nil {
 		return nil, err
 	}
 
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -10,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"aequitas/internal/models"
+	"aequitas/internal/utils"
 )
 
 type TradingAccountRepository struct {
@@ -19,9 +21,10 @@ type TradingAccountRepository struct {
 func NewTradingAccountRepository(db *mongo.Database) *TradingAccountRepository {
 	collection := db.Collection("trading_accounts")
 
-	// Create unique index on user_id
+	// Users may hold several named accounts; the old one-account-per-user index is replaced
+	collection.Indexes().DropOne(context.Background(), "user_id_1")
 	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
 		Options: options.Index().SetUnique(true),
 	}
 	collection.Indexes().CreateOne(context.Background(), indexModel)
@@ -43,18 +46,21 @@ func (r *TradingAccountRepository) Create(ctx context.Context, account *models.T
 	return account, nil
 }
 
-// FindByUserID finds a trading account by user ID
+// FindByUserID finds the user's account the context is scoped to, falling back to their default account
 func (r *TradingAccountRepository) FindByUserID(ctx context.Context, userID string) (*models.TradingAccount, error) {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, err
 	}
 
+	filter := bson.M{"user_id": objectID}
+	opts := options.FindOne().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}})
+	if accountID, ok := accountScope(ctx); ok {
+		filter["_id"] = accountID
+	}
+
 	var account models.TradingAccount
-	err = r.collection.FindOne(
-		ctx,
-		bson.M{"user_id": objectID},
-	).Decode(&account)
+	err = r.collection.FindOne(ctx, filter, opts).Decode(&account)
 
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
@@ -63,6 +69,123 @@ func (r *TradingAccountRepository) FindByUserID(ctx context.Context, userID stri
 	return &account, err
 }
 
+// FindByID finds a trading account by its ID
+func (r *TradingAccountRepository) FindByID(ctx context.Context, id string) (*models.TradingAccount, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var account models.TradingAccount
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&account)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &account, err
+}
+
+// FindAllByUserID lists a user's accounts, default first
+func (r *TradingAccountRepository) FindAllByUserID(ctx context.Context, userID string) ([]models.TradingAccount, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []models.TradingAccount
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// CountByUserID counts a user's accounts
+func (r *TradingAccountRepository) CountByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// GetDatabase returns the database for transactions spanning accounts
+func (r *TradingAccountRepository) GetDatabase() *mongo.Database {
+	return r.collection.Database()
+}
+
+// MigrateToDefaultAccounts names pre-existing accounts as each user's default and
+// backfills account_id on account-owned records that predate it
+func (r *TradingAccountRepository) MigrateToDefaultAccounts(ctx context.Context) error {
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"$or": []bson.M{{"name": bson.M{"$exists": false}}, {"name": ""}}},
+		bson.M{"$set": bson.M{"name": models.DefaultAccountName, "is_default": true, "updated_at": time.Now()}},
+	); err != nil {
+		return err
+	}
+
+	missing := []bson.M{
+		{"account_id": bson.M{"$exists": false}},
+		{"account_id": primitive.NilObjectID},
+		{"$expr": bson.M{"$eq": bson.A{"$account_id", "$user_id"}}}, // Trade results used to record the user ID here
+	}
+	db := r.collection.Database()
+	defaults := make(map[primitive.ObjectID]primitive.ObjectID)
+	for _, name := range []string{"holdings", "orders", "trades", "portfolio_history", "trade_results", "active_trade_units", "borrow_records", "gtt_triggers"} {
+		coll := db.Collection(name)
+		userIDs, err := coll.Distinct(ctx, "user_id", bson.M{"$or": missing})
+		if err != nil {
+			return err
+		}
+
+		var migrated int64
+		for _, v := range userIDs {
+			userID, ok := v.(primitive.ObjectID)
+			if !ok {
+				continue
+			}
+			accountID, ok := defaults[userID]
+			if !ok {
+				account, err := r.FindByUserID(ctx, userID.Hex())
+				if err != nil || account == nil {
+					continue // Users without an account get one lazily; their records are attached then
+				}
+				accountID = account.ID
+				defaults[userID] = accountID
+			}
+
+			filter := bson.M{"user_id": userID, "$or": missing}
+			res, err := coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"account_id": accountID}})
+			if err != nil {
+				return err
+			}
+			migrated += res.ModifiedCount
+		}
+		if migrated > 0 {
+			log.Printf("Attached %d %s records to default trading accounts", migrated, name)
+		}
+	}
+	return nil
+}
+
+// accountScope returns the trading account the context is scoped to, if any
+func accountScope(ctx context.Context) (primitive.ObjectID, bool) {
+	accountID, err := primitive.ObjectIDFromHex(utils.AccountIDFromContext(ctx))
+	if err != nil || accountID.IsZero() {
+		return primitive.NilObjectID, false
+	}
+	return accountID, true
+}
+
+// scoped narrows a user-keyed filter to the context's trading account
+func scoped(ctx context.Context, filter bson.M) bson.M {
+	if accountID, ok := accountScope(ctx); ok {
+		filter["account_id"] = accountID
+	}
+	return filter
+}
+
 // UpdateBalance updates the balance of a trading account
 func (r *TradingAccountRepository) UpdateBalance(ctx context.Context, accountID primitive.ObjectID, newBalance float64) error {
 	_, err := r.collection.UpdateOne(
@@ -73,6 +196,31 @@ func (r *TradingAccountRepository) UpdateBalance(ctx context.Context, accountID
 	return err
 }
 
+// AddToBalance atomically adds delta (negative for debits) to the account balance and returns the new balance
+func (r *TradingAccountRepository) AddToBalance(ctx context.Context, accountID primitive.ObjectID, delta float64) (float64, error) {
+	var account models.TradingAccount
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": accountID},
+		bson.M{"$inc": bson.M{"balance": delta}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&account)
+	if err != nil {
+		return 0, err
+	}
+	return account.Balance, nil
+}
+
+// SetBlockedMargin overwrites the account's blocked margin with the re-marked total of its positions
+func (r *TradingAccountRepository) SetBlockedMargin(ctx context.Context, accountID primitive.ObjectID, amount float64) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": accountID},
+		bson.M{"$set": bson.M{"blocked_margin": amount, "updated_at": time.Now()}},
+	)
+	return err
+}
+
 // Update updates a trading account (generic)
 func (r *TradingAccountRepository) Update(ctx context.Context, account *models.TradingAccount) (*models.TradingAccount, error) {
 	account.UpdatedAt = time.Now()
@@ -73,3 +73,54 @@ func (r *TransactionRepository) UpdateStatus(ctx context.Context, id string, sta
 	)
 	return err
 }
+
+// FindCompletedBetween returns COMPLETED ledger entries in [from, to), oldest first
+func (r *TransactionRepository) FindCompletedBetween(ctx context.Context, accountID primitive.ObjectID, from, to time.Time) ([]*models.Transaction, error) {
+	filter := bson.M{
+		"account_id": accountID,
+		"status":     "COMPLETED",
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := make([]*models.Transaction, 0)
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// SumCompletedBefore returns the net of all COMPLETED ledger entries before a point in time
+func (r *TransactionRepository) SumCompletedBefore(ctx context.Context, accountID primitive.ObjectID, before time.Time) (float64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"account_id": accountID,
+			"status":     "COMPLETED",
+			"created_at": bson.M{"$lt": before},
+		}},
+		{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Total, nil
+}
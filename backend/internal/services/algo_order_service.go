@@ -0,0 +1,635 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	algoDefaultSliceSeconds = 60
+	algoMinSliceSeconds     = 60
+	algoMaxDurationMinutes  = 375 // One full session
+	algoVolumeLookbackDays  = 10
+)
+
+// Candle intervals used to build a VWAP volume curve, finest first
+var algoCurveIntervals = []struct {
+	interval CandleInterval
+	minutes  int
+}{{Interval15m, 15}, {Interval5m, 5}, {Interval1h, 60}}
+
+// AlgoOrderService works TWAP/VWAP parent orders by releasing child orders through OrderService
+type AlgoOrderService struct {
+	algoRepo            *repositories.AlgoOrderRepository
+	orderRepo           *repositories.OrderRepository
+	instrumentRepo      *repositories.InstrumentRepository
+	tradingAccountRepo  *repositories.TradingAccountRepository
+	marketDataRepo      *repositories.MarketDataRepository
+	candleRepo          *repositories.CandleRepository
+	marketRepo          *repositories.MarketRepository
+	marketService       *MarketService
+	orderService        *OrderService
+	notificationService *NotificationService
+	auditService        *AuditService
+
+	mu       sync.Mutex // Serialises slicing with pause/resume/cancel
+	stopChan chan struct{}
+}
+
+func NewAlgoOrderService(
+	algoRepo *repositories.AlgoOrderRepository,
+	orderRepo *repositories.OrderRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	tradingAccountRepo *repositories.TradingAccountRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	candleRepo *repositories.CandleRepository,
+	marketRepo *repositories.MarketRepository,
+	marketService *MarketService,
+	orderService *OrderService,
+	notificationService *NotificationService,
+	auditService *AuditService,
+) *AlgoOrderService {
+	return &AlgoOrderService{
+		algoRepo:            algoRepo,
+		orderRepo:           orderRepo,
+		instrumentRepo:      instrumentRepo,
+		tradingAccountRepo:  tradingAccountRepo,
+		marketDataRepo:      marketDataRepo,
+		candleRepo:          candleRepo,
+		marketRepo:          marketRepo,
+		marketService:       marketService,
+		orderService:        orderService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// AlgoOrderRequest submits a TWAP or VWAP parent order
+type AlgoOrderRequest struct {
+	InstrumentID     string   `json:"instrumentId"`
+	Strategy         string   `json:"strategy"`
+	Side             string   `json:"side"`
+	Intent           string   `json:"intent,omitempty"`
+	ProductType      string   `json:"productType,omitempty"`
+	Quantity         int      `json:"quantity"`
+	ChildOrderType   string   `json:"childOrderType,omitempty"` // MARKET (default) / LIMIT
+	LimitPrice       *float64 `json:"limitPrice,omitempty"`
+	DurationMinutes  int      `json:"durationMinutes"`
+	SliceSeconds     int      `json:"sliceSeconds,omitempty"`
+	MaxParticipation float64  `json:"maxParticipation,omitempty"`
+}
+
+// Start begins releasing child orders for active algos
+func (s *AlgoOrderService) Start() {
+	ticker := time.NewTicker(5 * time.Second)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.MonitorAlgoOrders(context.Background())
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("Algo order service started (polling 5s)")
+}
+
+// Stop gracefully shuts down the algo order service
+func (s *AlgoOrderService) Stop() {
+	close(s.stopChan)
+	log.Println("Algo order service stopped")
+}
+
+// CreateAlgoOrder validates and schedules a parent order and releases its first slice
+func (s *AlgoOrderService) CreateAlgoOrder(ctx context.Context, userID string, req AlgoOrderRequest) (*models.AlgoOrder, error) {
+	req.Strategy = strings.ToUpper(req.Strategy)
+	if req.Strategy != models.AlgoStrategyTWAP && req.Strategy != models.AlgoStrategyVWAP {
+		return nil, errors.New("invalid strategy. Must be TWAP or VWAP")
+	}
+	if req.Side != "BUY" && req.Side != "SELL" {
+		return nil, errors.New("invalid order side. Must be BUY or SELL")
+	}
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	if req.ChildOrderType == "" {
+		req.ChildOrderType = "MARKET"
+	}
+	switch req.ChildOrderType {
+	case "MARKET":
+		if req.LimitPrice != nil {
+			return nil, errors.New("limit price is only allowed with LIMIT child orders")
+		}
+	case "LIMIT":
+		if req.LimitPrice == nil || *req.LimitPrice <= 0 {
+			return nil, errors.New("limit price is required for LIMIT child orders")
+		}
+	default:
+		return nil, errors.New("invalid child order type. Must be MARKET or LIMIT")
+	}
+
+	if req.DurationMinutes < 1 || req.DurationMinutes > algoMaxDurationMinutes {
+		return nil, fmt.Errorf("duration must be between 1 and %d minutes", algoMaxDurationMinutes)
+	}
+	if req.SliceSeconds == 0 {
+		req.SliceSeconds = algoDefaultSliceSeconds
+	}
+	if req.SliceSeconds < algoMinSliceSeconds {
+		return nil, fmt.Errorf("slice interval must be at least %d seconds", algoMinSliceSeconds)
+	}
+	slices := req.DurationMinutes * 60 / req.SliceSeconds
+	if slices < 1 {
+		return nil, errors.New("duration must cover at least one slice")
+	}
+	if req.MaxParticipation < 0 || req.MaxParticipation > 1 {
+		return nil, errors.New("max participation must be between 0 and 1")
+	}
+
+	instrument, err := s.instrumentRepo.FindByID(req.InstrumentID)
+	if err != nil || instrument == nil {
+		return nil, errors.New("instrument not found or inactive")
+	}
+	if instrument.Status != "ACTIVE" || instrument.Type == models.InstrumentTypeIndex {
+		return nil, errors.New("instrument is not active for trading")
+	}
+	if req.Quantity%instrument.LotSize != 0 {
+		return nil, fmt.Errorf("quantity must be a multiple of lot size (%d)", instrument.LotSize)
+	}
+
+	// The schedule has to run inside today's session
+	isOpen, _, err := s.marketService.IsMarketOpen(snapshotExchange)
+	if err != nil || !isOpen {
+		return nil, errors.New("algo orders can only be started while the market is open")
+	}
+	now := time.Now()
+	endAt := now.Add(time.Duration(req.DurationMinutes) * time.Minute)
+	if closeAt, ok := exchangeSessionClose(s.marketRepo, snapshotExchange, utils.GetISTTime()); ok && endAt.After(closeAt) {
+		return nil, fmt.Errorf("schedule must finish by today's market close (%s)", closeAt.Format("15:04"))
+	}
+
+	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, instrument.ID.Hex())
+	if err != nil || marketData == nil {
+		return nil, errors.New("market data unavailable for this instrument")
+	}
+
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	userUID, _ := primitive.ObjectIDFromHex(userID)
+
+	slice := time.Duration(req.SliceSeconds) * time.Second
+	curve, source := uniformCurve(slices), models.AlgoCurveUniform
+	if req.Strategy == models.AlgoStrategyVWAP {
+		if historical, ok := s.volumeCurve(instrument.ID.Hex(), now, slices, slice); ok {
+			curve, source = historical, models.AlgoCurveHistorical
+		} else {
+			log.Printf("Algo: no intraday volume history for %s, VWAP falls back to a uniform curve", instrument.Symbol)
+		}
+	}
+
+	algo := &models.AlgoOrder{
+		UserID:           userUID,
+		AccountID:        account.ID,
+		InstrumentID:     instrument.ID,
+		Symbol:           instrument.Symbol,
+		Strategy:         req.Strategy,
+		Side:             req.Side,
+		Intent:           req.Intent,
+		ProductType:      req.ProductType,
+		Quantity:         req.Quantity,
+		LotSize:          instrument.LotSize,
+		ChildOrderType:   req.ChildOrderType,
+		LimitPrice:       req.LimitPrice,
+		DurationMinutes:  req.DurationMinutes,
+		SliceSeconds:     req.SliceSeconds,
+		MaxParticipation: req.MaxParticipation,
+		Curve:            curve,
+		CurveSource:      source,
+		ArrivalPrice:     marketData.LastPrice,
+		LastSlice:        -1,
+		Status:           models.AlgoStatusActive,
+		StartAt:          now,
+		EndAt:            endAt,
+	}
+	if err := s.algoRepo.Create(ctx, algo); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "ALGO_ORDER_CREATED", algo.ID.Hex(), "ALGO_ORDER",
+		fmt.Sprintf("%s %s %d %s over %d min", algo.Strategy, algo.Side, algo.Quantity, algo.Symbol, algo.DurationMinutes),
+		nil, algo)
+
+	s.mu.Lock()
+	s.work(ctx, algo, now, true)
+	s.mu.Unlock()
+	return algo, nil
+}
+
+func (s *AlgoOrderService) GetUserAlgoOrders(ctx context.Context, userID string, status string) ([]models.AlgoOrder, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.algoRepo.FindByUserID(ctx, uid, status)
+}
+
+// GetAlgoOrder returns an algo with the child orders it has released
+func (s *AlgoOrderService) GetAlgoOrder(ctx context.Context, userID string, algoID string) (*models.AlgoOrder, []*models.Order, error) {
+	algo, err := s.getOwned(ctx, userID, algoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	children, err := s.orderRepo.FindByParentOrderID(ctx, algo.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return algo, children, nil
+}
+
+// PauseAlgoOrder stops releasing slices and pulls the working children
+func (s *AlgoOrderService) PauseAlgoOrder(ctx context.Context, userID string, algoID string) (*models.AlgoOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	algo, err := s.getOwned(ctx, userID, algoID)
+	if err != nil {
+		return nil, err
+	}
+	if algo.Status != models.AlgoStatusActive {
+		return nil, fmt.Errorf("cannot pause algo order with status: %s", algo.Status)
+	}
+	ctx = utils.WithAccountID(ctx, algo.AccountID.Hex())
+
+	old := *algo
+	now := time.Now()
+	s.cancelWorking(ctx, algo)
+	s.refresh(ctx, algo)
+	algo.Status = models.AlgoStatusPaused
+	algo.PausedAt = &now
+	if err := s.algoRepo.Update(ctx, algo); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "ALGO_ORDER_PAUSED", algo.ID.Hex(), "ALGO_ORDER",
+		fmt.Sprintf("%s %s %s paused at %d/%d", algo.Strategy, algo.Side, algo.Symbol, algo.FilledQuantity, algo.Quantity), old, algo)
+	return algo, nil
+}
+
+// ResumeAlgoOrder restarts a paused algo; the schedule end moves back by the time spent paused
+func (s *AlgoOrderService) ResumeAlgoOrder(ctx context.Context, userID string, algoID string) (*models.AlgoOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	algo, err := s.getOwned(ctx, userID, algoID)
+	if err != nil {
+		return nil, err
+	}
+	if algo.Status != models.AlgoStatusPaused || algo.PausedAt == nil {
+		return nil, fmt.Errorf("cannot resume algo order with status: %s", algo.Status)
+	}
+
+	now := time.Now()
+	paused := now.Sub(*algo.PausedAt)
+	endAt := algo.EndAt.Add(paused)
+	closeAt, ok := exchangeSessionClose(s.marketRepo, snapshotExchange, utils.GetISTTime())
+	if !ok {
+		return nil, errors.New("cannot resume: the market is not trading today")
+	}
+	if endAt.After(closeAt) {
+		return nil, fmt.Errorf("cannot resume: the remaining schedule would run past market close (%s)", closeAt.Format("15:04"))
+	}
+
+	old := *algo
+	algo.Status = models.AlgoStatusActive
+	algo.StatusReason = ""
+	algo.PausedFor += paused
+	algo.PausedAt = nil
+	algo.EndAt = endAt
+	if err := s.algoRepo.Update(ctx, algo); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "ALGO_ORDER_RESUMED", algo.ID.Hex(), "ALGO_ORDER",
+		fmt.Sprintf("%s %s %s resumed, now ends %s", algo.Strategy, algo.Side, algo.Symbol, endAt.In(closeAt.Location()).Format("15:04")), old, algo)
+	return algo, nil
+}
+
+// CancelAlgoOrder stops the algo for good and cancels its working children; fills already made stand
+func (s *AlgoOrderService) CancelAlgoOrder(ctx context.Context, userID string, algoID string) (*models.AlgoOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	algo, err := s.getOwned(ctx, userID, algoID)
+	if err != nil {
+		return nil, err
+	}
+	if !algo.IsOpen() {
+		return nil, fmt.Errorf("cannot cancel algo order with status: %s", algo.Status)
+	}
+	ctx = utils.WithAccountID(ctx, algo.AccountID.Hex())
+
+	old := *algo
+	now := time.Now()
+	s.cancelWorking(ctx, algo)
+	s.refresh(ctx, algo)
+	algo.Status = models.AlgoStatusCancelled
+	algo.StatusReason = "cancelled by user"
+	algo.EndedAt = &now
+	if err := s.algoRepo.Update(ctx, algo); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "ALGO_ORDER_CANCELLED", algo.ID.Hex(), "ALGO_ORDER",
+		fmt.Sprintf("%s %s %s cancelled at %d/%d", algo.Strategy, algo.Side, algo.Symbol, algo.FilledQuantity, algo.Quantity), old, algo)
+	return algo, nil
+}
+
+// MonitorAlgoOrders tracks child fills and releases the next slice of every active algo
+func (s *AlgoOrderService) MonitorAlgoOrders(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	algos, err := s.algoRepo.FindActive(ctx)
+	if err != nil {
+		log.Printf("Algo monitor error: failed to fetch active algos: %v", err)
+		return
+	}
+	if len(algos) == 0 {
+		return
+	}
+
+	isOpen, _, err := s.marketService.IsMarketOpen(snapshotExchange)
+	marketOpen := err == nil && isOpen
+
+	now := time.Now()
+	for i := range algos {
+		s.work(ctx, &algos[i], now, marketOpen)
+	}
+}
+
+// work brings an algo's fills up to date, ends it when done or out of time, and otherwise
+// releases a child for the current slice. Callers hold s.mu.
+func (s *AlgoOrderService) work(ctx context.Context, algo *models.AlgoOrder, now time.Time, marketOpen bool) {
+	ctx = utils.WithAccountID(ctx, algo.AccountID.Hex())
+	if err := s.refresh(ctx, algo); err != nil {
+		log.Printf("Algo monitor error: failed to load children of %s: %v", algo.ID.Hex(), err)
+		return
+	}
+
+	slice := time.Duration(algo.SliceSeconds) * time.Second
+	if algo.FilledQuantity >= algo.Quantity {
+		s.finish(ctx, algo, models.AlgoStatusCompleted, "")
+		return
+	}
+	if now.After(algo.EndAt.Add(slice)) {
+		s.cancelWorking(ctx, algo)
+		s.refresh(ctx, algo)
+		s.finish(ctx, algo, models.AlgoStatusExpired,
+			fmt.Sprintf("schedule ended with %d of %d filled", algo.FilledQuantity, algo.Quantity))
+		return
+	}
+
+	k := algo.SliceIndex(now)
+	if !marketOpen || k <= algo.LastSlice {
+		s.save(ctx, algo)
+		return
+	}
+
+	// Children left unfilled by earlier slices go back into the schedule
+	if algo.WorkingQuantity > 0 {
+		s.cancelWorking(ctx, algo)
+		s.refresh(ctx, algo)
+	}
+
+	target := int(math.Round(algo.Curve[k] * float64(algo.Quantity)))
+	due := target - algo.FilledQuantity - algo.WorkingQuantity
+	if k < len(algo.Curve)-1 {
+		due -= due % algo.LotSize
+	}
+	if algo.MaxParticipation > 0 && due > 0 {
+		if allowed := s.participationCap(algo, now); due > allowed {
+			due = allowed
+		}
+	}
+
+	algo.LastSlice = k
+	if due > 0 {
+		s.placeChild(ctx, algo, k, due)
+		if algo.Status != models.AlgoStatusActive {
+			return
+		}
+		s.refresh(ctx, algo)
+	}
+	s.save(ctx, algo)
+}
+
+// placeChild sends one slice to the market; repeated rejections fail the algo
+func (s *AlgoOrderService) placeChild(ctx context.Context, algo *models.AlgoOrder, k int, quantity int) {
+	child := models.Order{
+		InstrumentID:  algo.InstrumentID,
+		Symbol:        algo.Symbol,
+		Side:          algo.Side,
+		OrderType:     algo.ChildOrderType,
+		Quantity:      quantity,
+		Intent:        algo.Intent,
+		ProductType:   algo.ProductType,
+		Source:        "ALGO",
+		ClientOrderID: fmt.Sprintf("ALGO-%s-%d", algo.ID.Hex(), k),
+		ParentOrderID: &algo.ID,
+	}
+	if algo.ChildOrderType == "LIMIT" {
+		child.Price = algo.LimitPrice
+	}
+
+	placed, err := s.orderService.PlaceOrder(ctx, algo.UserID.Hex(), child)
+	if err != nil {
+		log.Printf("❌ Algo %s slice %d rejected: %v", algo.ID.Hex(), k+1, err)
+		algo.RejectedStreak++
+		algo.StatusReason = fmt.Sprintf("slice %d rejected: %v", k+1, err)
+		if algo.RejectedStreak >= models.AlgoMaxRejectedChildren {
+			s.cancelWorking(ctx, algo)
+			s.refresh(ctx, algo)
+			s.finish(ctx, algo, models.AlgoStatusFailed, algo.StatusReason)
+		}
+		return
+	}
+
+	log.Printf("Algo %s slice %d/%d: %s %s %d %s", algo.ID.Hex(), k+1, len(algo.Curve), placed.OrderType, placed.Side, placed.Quantity, placed.Symbol)
+	algo.RejectedStreak = 0
+	algo.StatusReason = ""
+	algo.ChildCount++
+}
+
+// participationCap is the most the algo may trade this slice given the market volume of the previous one
+func (s *AlgoOrderService) participationCap(algo *models.AlgoOrder, now time.Time) int {
+	slice := time.Duration(algo.SliceSeconds) * time.Second
+	candles, err := s.candleRepo.GetCandles(algo.InstrumentID.Hex(), string(Interval1m), now.Add(-slice), now, 100)
+	if err != nil {
+		return 0
+	}
+	var volume int64
+	for _, c := range candles {
+		volume += c.Volume
+	}
+	allowed := int(float64(volume) * algo.MaxParticipation)
+	return allowed - allowed%algo.LotSize
+}
+
+// refresh recomputes filled and working quantities and the average fill from the children
+func (s *AlgoOrderService) refresh(ctx context.Context, algo *models.AlgoOrder) error {
+	children, err := s.orderRepo.FindByParentOrderID(ctx, algo.ID)
+	if err != nil {
+		return err
+	}
+	filled, working, notional := 0, 0, 0.0
+	for _, child := range children {
+		filled += child.FilledQuantity
+		notional += float64(child.FilledQuantity) * child.AvgFillPrice
+		if child.Status == "NEW" {
+			working += child.RemainingQuantity()
+		}
+	}
+	algo.FilledQuantity = filled
+	algo.WorkingQuantity = working
+	if filled > 0 {
+		algo.AvgFillPrice = notional / float64(filled)
+	}
+	return nil
+}
+
+// cancelWorking cancels the algo's children that are still on the book
+func (s *AlgoOrderService) cancelWorking(ctx context.Context, algo *models.AlgoOrder) {
+	children, err := s.orderRepo.FindByParentOrderID(ctx, algo.ID)
+	if err != nil {
+		log.Printf("Algo monitor error: failed to load children of %s: %v", algo.ID.Hex(), err)
+		return
+	}
+	for _, child := range children {
+		if child.Status != "NEW" {
+			continue
+		}
+		if _, err := s.orderService.CancelOrder(ctx, algo.UserID.Hex(), child.ID.Hex()); err != nil {
+			log.Printf("Algo %s: failed to cancel child %s: %v", algo.ID.Hex(), child.OrderID, err)
+		}
+	}
+}
+
+// finish moves an algo to a terminal status and tells the user how it went
+func (s *AlgoOrderService) finish(ctx context.Context, algo *models.AlgoOrder, status string, reason string) {
+	now := time.Now()
+	old := *algo
+	algo.Status = status
+	algo.StatusReason = reason
+	algo.EndedAt = &now
+	s.save(ctx, algo)
+
+	progress := algo.Progress(now)
+	s.auditService.Log(algo.UserID.Hex(), "System", "SYSTEM", "ALGO_ORDER_"+status, algo.ID.Hex(), "ALGO_ORDER",
+		fmt.Sprintf("%s %s %s %s: %d/%d filled", algo.Strategy, algo.Side, algo.Symbol, status, algo.FilledQuantity, algo.Quantity),
+		old, algo)
+
+	title := map[string]string{
+		models.AlgoStatusCompleted: "Algo Order Completed",
+		models.AlgoStatusExpired:   "Algo Order Expired",
+		models.AlgoStatusFailed:    "Algo Order Failed",
+	}[status]
+	message := fmt.Sprintf("Your %s %s of %d %s is %s with %d filled",
+		algo.Strategy, algo.Side, algo.Quantity, algo.Symbol, strings.ToLower(status), algo.FilledQuantity)
+	if algo.FilledQuantity > 0 {
+		message += fmt.Sprintf(" at an average of ₹%.2f (%.1f bps vs arrival)", algo.AvgFillPrice, progress.SlippageBps)
+	}
+	if reason != "" {
+		message += ": " + reason
+	}
+	go func() {
+		_ = s.notificationService.SendNotification(context.Background(), algo.UserID.Hex(),
+			models.NotificationTypeOrder, title, message+".",
+			map[string]interface{}{"algoOrderId": algo.ID.Hex(), "symbol": algo.Symbol}, nil)
+	}()
+}
+
+func (s *AlgoOrderService) save(ctx context.Context, algo *models.AlgoOrder) {
+	if err := s.algoRepo.Update(ctx, algo); err != nil {
+		log.Printf("Algo monitor error: failed to update %s: %v", algo.ID.Hex(), err)
+	}
+}
+
+func (s *AlgoOrderService) getOwned(ctx context.Context, userID string, algoID string) (*models.AlgoOrder, error) {
+	algo, err := s.algoRepo.FindByID(ctx, algoID)
+	if err != nil || algo == nil {
+		return nil, errors.New("algo order not found")
+	}
+	if algo.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return algo, nil
+}
+
+// volumeCurve builds a cumulative schedule from the instrument's average intraday volume on previous days
+func (s *AlgoOrderService) volumeCurve(instrumentID string, start time.Time, slices int, slice time.Duration) ([]float64, bool) {
+	dayStart, _ := istDayBounds(start)
+	ist := dayStart.Location()
+
+	for _, c := range algoCurveIntervals {
+		candles, err := s.candleRepo.GetCandles(instrumentID, string(c.interval), dayStart.AddDate(0, 0, -algoVolumeLookbackDays), dayStart, 1000)
+		if err != nil || len(candles) == 0 {
+			continue
+		}
+
+		// Volume by time-of-day bucket, in minutes since midnight IST
+		profile := make(map[int]float64)
+		for _, candle := range candles {
+			t := candle.Time.In(ist)
+			minute := t.Hour()*60 + t.Minute()
+			profile[minute-minute%c.minutes] += float64(candle.Volume)
+		}
+
+		weights := make([]float64, slices)
+		total := 0.0
+		for k := range weights {
+			mid := start.Add(time.Duration(k)*slice + slice/2).In(ist)
+			minute := mid.Hour()*60 + mid.Minute()
+			weights[k] = profile[minute-minute%c.minutes]
+			total += weights[k]
+		}
+		if total <= 0 {
+			continue
+		}
+
+		curve := make([]float64, slices)
+		cumulative := 0.0
+		for k, w := range weights {
+			cumulative += w
+			curve[k] = cumulative / total
+		}
+		curve[slices-1] = 1
+		return curve, true
+	}
+	return nil, false
+}
+
+// uniformCurve releases an equal share of the quantity each slice
+func uniformCurve(slices int) []float64 {
+	curve := make([]float64, slices)
+	for k := range curve {
+		curve[k] = float64(k+1) / float64(slices)
+	}
+	return curve
+}
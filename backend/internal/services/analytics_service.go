@@ -9,23 +9,31 @@ import (
 
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AnalyticsService struct {
 	tradeResultRepo *repositories.TradeResultRepository
 	activeUnitRepo  *repositories.ActiveTradeUnitRepository
 	candleRepo      *repositories.CandleRepository
+	orderRepo       *repositories.OrderRepository
+	marketDataRepo  *repositories.MarketDataRepository
 }
 
 func NewAnalyticsService(
 	tradeResultRepo *repositories.TradeResultRepository,
 	activeUnitRepo *repositories.ActiveTradeUnitRepository,
 	candleRepo *repositories.CandleRepository,
+	orderRepo *repositories.OrderRepository,
+	marketDataRepo *repositories.MarketDataRepository,
 ) *AnalyticsService {
 	return &AnalyticsService{
 		tradeResultRepo: tradeResultRepo,
 		activeUnitRepo:  activeUnitRepo,
 		candleRepo:      candleRepo,
+		orderRepo:       orderRepo,
+		marketDataRepo:  marketDataRepo,
 	}
 }
 
@@ -34,8 +42,8 @@ func (s *AnalyticsService) ProcessTrade(ctx context.Context, trade *models.Trade
 	userID := trade.UserID.Hex()
 	instrumentID := trade.InstrumentID.Hex()
 
-	// 1. Get or Create Active Unit
-	unit, err := s.activeUnitRepo.FindOpenUnit(userID, instrumentID)
+	// 1. Get or Create Active Unit (round trips are tracked per trading account)
+	unit, err := s.activeUnitRepo.FindOpenUnit(userID, trade.AccountID.Hex(), instrumentID)
 	if err != nil {
 		return fmt.Errorf("failed to find open unit: %w", err)
 	}
@@ -43,6 +51,7 @@ func (s *AnalyticsService) ProcessTrade(ctx context.Context, trade *models.Trade
 	if unit == nil {
 		unit = &models.ActiveTradeUnit{
 			UserID:         trade.UserID,
+			AccountID:      trade.AccountID,
 			InstrumentID:   trade.InstrumentID,
 			Symbol:         trade.Symbol,
 			CreatedAt:      time.Now(),
@@ -104,7 +113,7 @@ func (s *AnalyticsService) finalizeTradeResult(ctx context.Context, unit *models
 
 	res := &models.TradeResult{
 		UserID:             unit.UserID,
-		AccountID:          unit.UserID, // AccountID mapping if different
+		AccountID:          unit.AccountID,
 		InstrumentID:       unit.InstrumentID,
 		Symbol:             unit.Symbol,
 		Side:               unit.Side,
@@ -204,6 +213,64 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%ds", s)
 }
 
-func (s *AnalyticsService) GetUserTradeDiagnostics(userID string) ([]*models.TradeResult, error) {
-	return s.tradeResultRepo.FindByUserID(userID)
+func (s *AnalyticsService) GetUserTradeDiagnostics(ctx context.Context, userID string) ([]*models.TradeResult, error) {
+	return s.tradeResultRepo.FindByUserID(ctx, userID)
+}
+
+// GetSpreadPnL marks each filled multi-leg order to market as one position; the legs' holdings are tracked separately
+func (s *AnalyticsService) GetSpreadPnL(ctx context.Context, userID string) ([]*models.SpreadPnL, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.orderRepo.FindFilledMultiLegOrders(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	lastPrices := make(map[primitive.ObjectID]float64)
+	results := make([]*models.SpreadPnL, 0, len(orders))
+	for _, order := range orders {
+		for _, leg := range order.Legs {
+			if _, ok := lastPrices[leg.InstrumentID]; ok {
+				continue
+			}
+			if marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, leg.InstrumentID.Hex()); err == nil && marketData != nil {
+				lastPrices[leg.InstrumentID] = marketData.LastPrice
+			}
+		}
+		current, ok := order.NetPrice(lastPrices)
+		if !ok {
+			continue
+		}
+
+		res := &models.SpreadPnL{
+			OrderID:    order.ID,
+			Symbol:     order.Symbol,
+			Quantity:   order.Quantity,
+			EntryNet:   order.AvgFillPrice,
+			CurrentNet: current,
+			FilledAt:   order.FilledAt,
+		}
+		for _, leg := range order.Legs {
+			last := lastPrices[leg.InstrumentID]
+			pnl := (last - leg.FillPrice) * float64(leg.Quantity)
+			if leg.Side == "SELL" {
+				pnl = -pnl
+			}
+			res.PnL += pnl
+			res.Legs = append(res.Legs, models.SpreadLegPnL{
+				InstrumentID: leg.InstrumentID,
+				Symbol:       leg.Symbol,
+				Side:         leg.Side,
+				Quantity:     leg.Quantity,
+				FillPrice:    leg.FillPrice,
+				LastPrice:    last,
+				PnL:          math.Round(pnl*100) / 100,
+			})
+		}
+		res.PnL = math.Round(res.PnL*100) / 100
+		results = append(results, res)
+	}
+	return results, nil
 }
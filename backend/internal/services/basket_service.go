@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BasketService places many orders at once, validated together, and keeps reusable basket templates
+type BasketService struct {
+	basketRepo         *repositories.BasketRepository
+	instrumentRepo     *repositories.InstrumentRepository
+	marketDataRepo     *repositories.MarketDataRepository
+	tradingAccountRepo *repositories.TradingAccountRepository
+	portfolioService   *PortfolioService
+	marginService      *MarginService
+	orderService       *OrderService
+	auditService       *AuditService
+}
+
+func NewBasketService(
+	basketRepo *repositories.BasketRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	tradingAccountRepo *repositories.TradingAccountRepository,
+	portfolioService *PortfolioService,
+	marginService *MarginService,
+	orderService *OrderService,
+	auditService *AuditService,
+) *BasketService {
+	return &BasketService{
+		basketRepo:         basketRepo,
+		instrumentRepo:     instrumentRepo,
+		marketDataRepo:     marketDataRepo,
+		tradingAccountRepo: tradingAccountRepo,
+		portfolioService:   portfolioService,
+		marginService:      marginService,
+		orderService:       orderService,
+		auditService:       auditService,
+	}
+}
+
+// BasketTemplateRequest creates or replaces a basket template
+type BasketTemplateRequest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Items       []models.BasketItem `json:"items"`
+}
+
+// PlaceBasket validates the items together, then places them. With allOrNone nothing is placed unless every
+// item passes validation, and working orders already placed are cancelled if a later one is rejected; orders
+// that have already filled cannot be undone. Without it, each valid item is placed and failures are reported.
+func (s *BasketService) PlaceBasket(ctx context.Context, userID string, items []models.BasketItem, allOrNone bool) (*models.BasketPlacement, error) {
+	if len(items) == 0 {
+		return nil, errors.New("basket has no orders")
+	}
+	if len(items) > models.MaxBasketOrders {
+		return nil, fmt.Errorf("a basket can place at most %d orders", models.MaxBasketOrders)
+	}
+
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	ctx = utils.WithAccountID(ctx, account.ID.Hex())
+
+	// 1. Load instruments, quotes and holdings for the whole basket in one query each
+	ids := make([]primitive.ObjectID, 0, len(items))
+	hexIDs := make([]string, 0, len(items))
+	seenIDs := make(map[primitive.ObjectID]bool)
+	for _, item := range items {
+		if !seenIDs[item.InstrumentID] {
+			seenIDs[item.InstrumentID] = true
+			ids = append(ids, item.InstrumentID)
+			hexIDs = append(hexIDs, item.InstrumentID.Hex())
+		}
+	}
+	instrumentList, err := s.instrumentRepo.FindAll(map[string]interface{}{"_id": map[string]interface{}{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	instruments := make(map[primitive.ObjectID]*models.Instrument, len(instrumentList))
+	for _, instrument := range instrumentList {
+		instruments[instrument.ID] = instrument
+	}
+	quoteList, err := s.marketDataRepo.FindByInstrumentIDs(ctx, hexIDs)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make(map[primitive.ObjectID]float64, len(quoteList))
+	for _, quote := range quoteList {
+		quotes[quote.InstrumentID] = quote.LastPrice
+	}
+	holdingList, err := s.portfolioService.GetHoldings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holdings: %v", err)
+	}
+	holdings := make(map[primitive.ObjectID]models.Holding, len(holdingList))
+	for _, holding := range holdingList {
+		if holding.Quantity > 0 {
+			holdings[holding.InstrumentID] = holding
+		}
+	}
+
+	// 2. Validate each item, detect duplicates and total the funds the basket needs
+	basketID := fmt.Sprintf("BASKET-%s", primitive.NewObjectID().Hex())
+	placement := &models.BasketPlacement{
+		BasketID:       basketID,
+		AllOrNone:      allOrNone,
+		AvailableFunds: account.Balance - account.BlockedMargin,
+		Results:        make([]models.BasketOrderResult, len(items)),
+	}
+	orders := make([]*models.Order, len(items))
+	seen := make(map[string]int)
+	closing := make(map[primitive.ObjectID]int)
+	invalid := 0
+	for i := range items {
+		item := &items[i]
+		placement.Results[i] = models.BasketOrderResult{Index: i, Symbol: item.Symbol, Side: item.Side, Quantity: item.Quantity}
+
+		key := item.InstrumentID.Hex()
+		if first, ok := seen[key]; ok {
+			placement.Results[i].Status = models.BasketResultFailed
+			placement.Results[i].Error = fmt.Sprintf("duplicate of order %d on the same instrument", first+1)
+			invalid++
+			continue
+		}
+		seen[key] = i
+
+		order, cost, err := s.checkItem(ctx, item, instruments[item.InstrumentID], quotes, holdings, closing)
+		if err != nil {
+			placement.Results[i].Status = models.BasketResultFailed
+			placement.Results[i].Error = err.Error()
+			invalid++
+			continue
+		}
+		if order.ClientOrderID == "" {
+			order.ClientOrderID = fmt.Sprintf("%s-%d", basketID, i+1)
+		}
+		placement.Results[i].Symbol = order.Symbol
+		placement.RequiredFunds += cost
+		orders[i] = order
+	}
+	placement.RequiredFunds = math.Round(math.Max(placement.RequiredFunds, 0)*100) / 100
+
+	if allOrNone {
+		if invalid == 0 && placement.RequiredFunds > placement.AvailableFunds {
+			return nil, fmt.Errorf("insufficient funds for the basket. Required: ₹%0.2f, Available: ₹%0.2f",
+				placement.RequiredFunds, placement.AvailableFunds)
+		}
+		if invalid > 0 {
+			for i := range placement.Results {
+				if placement.Results[i].Status == "" {
+					placement.Results[i].Status = models.BasketResultSkipped
+				}
+			}
+			placement.Failed = invalid
+			return placement, nil
+		}
+	}
+
+	// 3. Place resting orders before market orders so an all-or-none rollback can still cancel them,
+	// and sells before buys so their proceeds fund the buys
+	sequence := make([]int, 0, len(items))
+	for i, order := range orders {
+		if order != nil {
+			sequence = append(sequence, i)
+		}
+	}
+	sort.SliceStable(sequence, func(a, b int) bool {
+		x, y := orders[sequence[a]], orders[sequence[b]]
+		if x.OrderType != y.OrderType {
+			return x.OrderType == "LIMIT"
+		}
+		return x.Side == "SELL" && y.Side == "BUY"
+	})
+
+	placed := make([]int, 0, len(sequence))
+	for n, i := range sequence {
+		order, err := s.orderService.PlaceOrder(ctx, userID, *orders[i])
+		if err != nil {
+			placement.Results[i].Status = models.BasketResultFailed
+			placement.Results[i].Error = err.Error()
+			if allOrNone {
+				for _, j := range sequence[n+1:] {
+					placement.Results[j].Status = models.BasketResultSkipped
+				}
+				s.rollback(ctx, userID, placement, placed)
+				break
+			}
+			continue
+		}
+		placement.Results[i].Status = models.BasketResultPlaced
+		placement.Results[i].Order = order
+		placed = append(placed, i)
+	}
+
+	for _, result := range placement.Results {
+		switch result.Status {
+		case models.BasketResultPlaced:
+			placement.Placed++
+		case models.BasketResultFailed:
+			placement.Failed++
+		}
+	}
+
+	s.auditService.LogFromContext(ctx, "BASKET_PLACED", basketID, "ORDER",
+		fmt.Sprintf("Basket of %d orders: %d placed, %d failed (all-or-none: %t)", len(items), placement.Placed, placement.Failed, allOrNone),
+		nil, placement)
+	return placement, nil
+}
+
+// checkItem validates one basket item against the preloaded data and returns its order and estimated cost;
+// market sells of held stock return their proceeds as a negative cost
+func (s *BasketService) checkItem(ctx context.Context, item *models.BasketItem, instrument *models.Instrument, quotes map[primitive.ObjectID]float64,
+	holdings map[primitive.ObjectID]models.Holding, closing map[primitive.ObjectID]int) (*models.Order, float64, error) {
+	if item.Side != "BUY" && item.Side != "SELL" {
+		return nil, 0, errors.New("invalid side. Must be BUY or SELL")
+	}
+	if item.OrderType == "" {
+		item.OrderType = "MARKET"
+	}
+	if item.OrderType != "MARKET" && item.OrderType != "LIMIT" {
+		return nil, 0, errors.New("basket orders must be MARKET or LIMIT")
+	}
+	if item.Quantity <= 0 {
+		return nil, 0, errors.New("quantity must be positive")
+	}
+	if instrument == nil || instrument.Status != "ACTIVE" || instrument.Type == models.InstrumentTypeIndex {
+		return nil, 0, errors.New("instrument not found or inactive")
+	}
+	if item.Quantity%instrument.LotSize != 0 {
+		return nil, 0, fmt.Errorf("quantity must be a multiple of lot size (%d)", instrument.LotSize)
+	}
+
+	price := quotes[instrument.ID] * 1.01 // Same buffer PlaceOrder uses for market orders
+	if item.OrderType == "LIMIT" {
+		if item.Price == nil || *item.Price <= 0 {
+			return nil, 0, errors.New("price is required for limit orders")
+		}
+		price = *item.Price
+	} else if quotes[instrument.ID] <= 0 {
+		return nil, 0, errors.New("market data unavailable for this instrument")
+	}
+
+	intent := item.Intent
+	holding, held := holdings[instrument.ID]
+	if intent == "" {
+		intent = string(models.IntentOpenLong)
+		if item.Side == "SELL" {
+			intent = string(models.IntentCloseLong)
+		}
+	}
+
+	cost := 0.0
+	switch intent {
+	case string(models.IntentOpenLong), string(models.IntentCloseShort):
+		if item.Side != "BUY" {
+			return nil, 0, fmt.Errorf("invalid intent %s for a SELL order", intent)
+		}
+		if intent == string(models.IntentCloseShort) && (!held || holding.PositionType != models.PositionShort) {
+			return nil, 0, fmt.Errorf("no short position in %s to cover", instrument.Symbol)
+		}
+		cost = price * float64(item.Quantity)
+	case string(models.IntentCloseLong), string(models.IntentOpenShort):
+		if item.Side != "SELL" {
+			return nil, 0, fmt.Errorf("invalid intent %s for a BUY order", intent)
+		}
+		if intent == string(models.IntentCloseLong) && (!held || holding.PositionType == models.PositionShort) {
+			return nil, 0, fmt.Errorf("no long position in %s to sell", instrument.Symbol)
+		}
+		if intent == string(models.IntentCloseLong) && item.OrderType == "MARKET" {
+			cost = -quotes[instrument.ID] * float64(item.Quantity) // Market sells go first and fund the buys
+		}
+		if intent == string(models.IntentOpenShort) {
+			params, err := s.marginService.GetRiskParams(ctx, instrument.ID)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to load risk parameters: %v", err)
+			}
+			cost = price * float64(item.Quantity) * params.InitialRateFor(productTypeOf(item.ProductType))
+		}
+	default:
+		return nil, 0, fmt.Errorf("invalid intent: %s", intent)
+	}
+	if intent == string(models.IntentCloseLong) || intent == string(models.IntentCloseShort) {
+		if closing[instrument.ID]+item.Quantity > holding.Quantity {
+			return nil, 0, fmt.Errorf("insufficient position in %s. Held: %d, Requested: %d", instrument.Symbol, holding.Quantity, item.Quantity)
+		}
+		closing[instrument.ID] += item.Quantity
+	}
+
+	return &models.Order{
+		InstrumentID:  instrument.ID,
+		Symbol:        instrument.Symbol,
+		Side:          item.Side,
+		OrderType:     item.OrderType,
+		Quantity:      item.Quantity,
+		Price:         item.Price,
+		Intent:        intent,
+		ProductType:   item.ProductType,
+		Validity:      item.Validity,
+		Source:        "BASKET",
+		ClientOrderID: item.ClientOrderID,
+	}, cost, nil
+}
+
+// rollback cancels the basket's orders that are still working after an all-or-none basket failed
+func (s *BasketService) rollback(ctx context.Context, userID string, placement *models.BasketPlacement, placed []int) {
+	for _, i := range placed {
+		result := &placement.Results[i]
+		if result.Order.Status != "NEW" && result.Order.Status != "PENDING" {
+			continue // Already filled
+		}
+		cancelled, err := s.orderService.CancelOrder(ctx, userID, result.Order.ID.Hex())
+		if err != nil {
+			log.Printf("ERROR: Failed to roll back basket order %s: %v", result.Order.OrderID, err)
+			continue
+		}
+		result.Status = models.BasketResultCancelled
+		result.Order = cancelled
+	}
+}
+
+// GetTemplates lists the user's basket templates
+func (s *BasketService) GetTemplates(ctx context.Context, userID string) ([]models.BasketTemplate, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.basketRepo.FindByUserID(ctx, uid)
+}
+
+func (s *BasketService) GetTemplate(ctx context.Context, userID, basketID string) (*models.BasketTemplate, error) {
+	return s.getOwned(ctx, userID, basketID)
+}
+
+// SaveTemplate creates a template, or replaces an existing one when basketID is set
+func (s *BasketService) SaveTemplate(ctx context.Context, userID, basketID string, req BasketTemplateRequest) (*models.BasketTemplate, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("template name is required")
+	}
+	if len(req.Items) == 0 || len(req.Items) > models.MaxBasketOrders {
+		return nil, fmt.Errorf("a basket template needs 1 to %d orders", models.MaxBasketOrders)
+	}
+	for i := range req.Items {
+		item := &req.Items[i]
+		instrument, err := s.instrumentRepo.FindByID(item.InstrumentID.Hex())
+		if err != nil || instrument == nil {
+			return nil, fmt.Errorf("order %d: instrument not found", i+1)
+		}
+		if item.Side != "BUY" && item.Side != "SELL" {
+			return nil, fmt.Errorf("order %d: invalid side. Must be BUY or SELL", i+1)
+		}
+		if item.Quantity <= 0 || item.Quantity%instrument.LotSize != 0 {
+			return nil, fmt.Errorf("order %d: quantity must be a positive multiple of lot size (%d)", i+1, instrument.LotSize)
+		}
+		item.Symbol = instrument.Symbol
+		item.ClientOrderID = ""
+	}
+
+	var old *models.BasketTemplate
+	basket := &models.BasketTemplate{}
+	if basketID != "" {
+		existing, err := s.getOwned(ctx, userID, basketID)
+		if err != nil {
+			return nil, err
+		}
+		snapshot := *existing
+		old = &snapshot
+		basket = existing
+	}
+	basket.Name = req.Name
+	basket.Description = req.Description
+	basket.Items = req.Items
+
+	var err error
+	if old == nil {
+		basket.UserID, _ = primitive.ObjectIDFromHex(userID)
+		err = s.basketRepo.Create(ctx, basket)
+	} else {
+		err = s.basketRepo.Update(ctx, basket)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("a basket named %q already exists", req.Name)
+		}
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "BASKET_TEMPLATE_SAVED", basket.ID.Hex(), "BASKET",
+		fmt.Sprintf("Basket template %q with %d orders", basket.Name, len(basket.Items)), old, basket)
+	return basket, nil
+}
+
+func (s *BasketService) DeleteTemplate(ctx context.Context, userID, basketID string) error {
+	basket, err := s.getOwned(ctx, userID, basketID)
+	if err != nil {
+		return err
+	}
+	if err := s.basketRepo.Delete(ctx, basket.ID); err != nil {
+		return err
+	}
+	s.auditService.LogFromContext(ctx, "BASKET_TEMPLATE_DELETED", basket.ID.Hex(), "BASKET",
+		fmt.Sprintf("Basket template %q deleted", basket.Name), basket, nil)
+	return nil
+}
+
+// PlaceTemplate places a saved basket
+func (s *BasketService) PlaceTemplate(ctx context.Context, userID, basketID string, allOrNone bool) (*models.BasketPlacement, error) {
+	basket, err := s.getOwned(ctx, userID, basketID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]models.BasketItem, len(basket.Items))
+	copy(items, basket.Items)
+
+	placement, err := s.PlaceBasket(ctx, userID, items, allOrNone)
+	if err != nil {
+		return nil, err
+	}
+	placement.TemplateID = &basket.ID
+
+	now := time.Now()
+	basket.LastPlacedAt = &now
+	if err := s.basketRepo.Update(ctx, basket); err != nil {
+		log.Printf("Failed to update basket template %s: %v", basket.ID.Hex(), err)
+	}
+	return placement, nil
+}
+
+func (s *BasketService) getOwned(ctx context.Context, userID, basketID string) (*models.BasketTemplate, error) {
+	basket, err := s.basketRepo.FindByID(ctx, basketID)
+	if err != nil || basket == nil {
+		return nil, errors.New("basket template not found")
+	}
+	if basket.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return basket, nil
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultEasyBorrowRate = 0.0001 // 0.01% of position value per day
+	defaultHardBorrowRate = 0.001  // 0.1% per day
+	borrowRecallNotice    = 24 * time.Hour
+)
+
+// BorrowService manages locate inventory for short sales, the borrow lifecycle, and daily borrow fees
+type BorrowService struct {
+	inventoryRepo       *repositories.BorrowInventoryRepository
+	recordRepo          *repositories.BorrowRecordRepository
+	instrumentRepo      *repositories.InstrumentRepository
+	marketDataRepo      *repositories.MarketDataRepository
+	accountService      *TradingAccountService
+	notificationService *NotificationService
+	auditService        *AuditService
+	stopChan            chan struct{}
+
+	lastAccrualRun string // YYYY-MM-DD
+}
+
+func NewBorrowService(
+	inventoryRepo *repositories.BorrowInventoryRepository,
+	recordRepo *repositories.BorrowRecordRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	accountService *TradingAccountService,
+	notificationService *NotificationService,
+	auditService *AuditService,
+) *BorrowService {
+	return &BorrowService{
+		inventoryRepo:       inventoryRepo,
+		recordRepo:          recordRepo,
+		instrumentRepo:      instrumentRepo,
+		marketDataRepo:      marketDataRepo,
+		accountService:      accountService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start begins the daily borrow-fee accrual job
+func (s *BorrowService) Start() {
+	ticker := time.NewTicker(15 * time.Minute)
+	go func() {
+		s.tick()
+		for {
+			select {
+			case <-ticker.C:
+				s.tick()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("Borrow fee accrual started (checks every 15m)")
+}
+
+// Stop gracefully shuts down the accrual job
+func (s *BorrowService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *BorrowService) tick() {
+	today, _ := istDayBounds(utils.GetISTTime())
+	todayKey := today.Format("2006-01-02")
+	if s.lastAccrualRun == todayKey {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	s.AccrueFees(ctx, today)
+	s.lastAccrualRun = todayKey
+}
+
+// Locate reserves borrow inventory for an OPEN_SHORT order
+func (s *BorrowService) Locate(ctx context.Context, account *models.TradingAccount, instrument *models.Instrument, quantity int) (*models.BorrowRecord, error) {
+	inv, err := s.inventoryRepo.Reserve(ctx, instrument.ID, quantity)
+	if err != nil {
+		return nil, fmt.Errorf("locate failed: %w", err)
+	}
+	if inv == nil {
+		return nil, fmt.Errorf("locate failed: insufficient borrow inventory for %s", instrument.Symbol)
+	}
+
+	record := &models.BorrowRecord{
+		UserID:       account.UserID,
+		AccountID:    account.ID,
+		InstrumentID: instrument.ID,
+		Symbol:       instrument.Symbol,
+		Quantity:     quantity,
+		BorrowRate:   inv.DailyRate,
+		Tier:         inv.Tier,
+		Status:       models.BorrowStatusLocated,
+		BorrowedAt:   time.Now(),
+	}
+	if err := s.recordRepo.Create(ctx, record); err != nil {
+		_ = s.inventoryRepo.Release(ctx, instrument.ID, quantity)
+		return nil, err
+	}
+	return record, nil
+}
+
+// CheckAvailable reports whether quantity could be located now without reserving it, for stop orders that locate on trigger
+func (s *BorrowService) CheckAvailable(ctx context.Context, instrument *models.Instrument, quantity int) error {
+	inv, err := s.inventoryRepo.FindByInstrumentID(ctx, instrument.ID)
+	if err != nil {
+		return fmt.Errorf("locate failed: %w", err)
+	}
+	if inv == nil || inv.AvailableQuantity < quantity {
+		return fmt.Errorf("locate failed: insufficient borrow inventory for %s", instrument.Symbol)
+	}
+	return nil
+}
+
+// AttachOrder links a locate to the order it was taken for
+func (s *BorrowService) AttachOrder(ctx context.Context, record *models.BorrowRecord, orderID primitive.ObjectID) error {
+	record.OrderID = orderID
+	return s.recordRepo.Update(ctx, record)
+}
+
+// ReleaseLocate returns an unfilled locate to inventory; a no-op if the order has none
+func (s *BorrowService) ReleaseLocate(ctx context.Context, record *models.BorrowRecord) error {
+	if record == nil || record.Status != models.BorrowStatusLocated {
+		return nil
+	}
+	now := time.Now()
+	record.Status = models.BorrowStatusReleased
+	record.ReturnedAt = &now
+	if err := s.recordRepo.Update(ctx, record); err != nil {
+		return err
+	}
+	return s.inventoryRepo.Release(ctx, record.InstrumentID, record.Quantity)
+}
+
+// ReleaseOrderLocate releases the locate held by a cancelled or rejected order
+func (s *BorrowService) ReleaseOrderLocate(ctx context.Context, orderID primitive.ObjectID) error {
+	record, err := s.recordRepo.FindLocateByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return s.ReleaseLocate(ctx, record)
+}
+
+// ResizeLocate adjusts the locate held by a modified order
+func (s *BorrowService) ResizeLocate(ctx context.Context, orderID primitive.ObjectID, instrument *models.Instrument, quantity int) error {
+	record, err := s.recordRepo.FindLocateByOrderID(ctx, orderID)
+	if err != nil || record == nil {
+		return err
+	}
+
+	delta := quantity - record.Quantity
+	if delta > 0 {
+		inv, err := s.inventoryRepo.Reserve(ctx, instrument.ID, delta)
+		if err != nil {
+			return fmt.Errorf("locate failed: %w", err)
+		}
+		if inv == nil {
+			return fmt.Errorf("locate failed: insufficient borrow inventory for %s", instrument.Symbol)
+		}
+	} else if delta < 0 {
+		if err := s.inventoryRepo.Release(ctx, instrument.ID, -delta); err != nil {
+			return err
+		}
+	}
+
+	record.Quantity = quantity
+	return s.recordRepo.Update(ctx, record)
+}
+
+// ApplyFill activates the locate when a short opens and returns borrows when a short is covered
+func (s *BorrowService) ApplyFill(ctx context.Context, order *models.Order, trade *models.Trade) error {
+	switch trade.Intent {
+	case string(models.IntentOpenShort):
+		record, err := s.recordRepo.FindLocateByOrderID(ctx, order.ID)
+		if err != nil || record == nil {
+			return err // Shorts opened before locates were required carry no borrow
+		}
+		if record.Quantity > trade.Quantity {
+			// Partial fill: the filled shares are lent out, the rest stays located for the remainder
+			lent := *record
+			lent.Quantity = trade.Quantity
+			lent.Status = models.BorrowStatusActive
+			lent.BorrowedAt = trade.ExecutedAt
+			if err := s.recordRepo.Create(ctx, &lent); err != nil {
+				return err
+			}
+			record.Quantity -= trade.Quantity
+			return s.recordRepo.Update(ctx, record)
+		}
+		record.Status = models.BorrowStatusActive
+		record.BorrowedAt = trade.ExecutedAt
+		return s.recordRepo.Update(ctx, record)
+	case string(models.IntentCloseShort):
+		return s.returnBorrow(ctx, trade.UserID, trade.InstrumentID, trade.Quantity)
+	}
+	return nil
+}
+
+// returnBorrow closes out borrows for a covered quantity, recalled borrows first, then oldest first
+func (s *BorrowService) returnBorrow(ctx context.Context, userID, instrumentID primitive.ObjectID, quantity int) error {
+	records, err := s.recordRepo.FindOutstanding(ctx, userID, instrumentID)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Status == models.BorrowStatusRecalled && records[j].Status != models.BorrowStatusRecalled
+	})
+
+	now := time.Now()
+	for i := range records {
+		if quantity <= 0 {
+			break
+		}
+		record := &records[i]
+		returned := record.Quantity
+		if returned > quantity {
+			returned = quantity
+		}
+		quantity -= returned
+		record.Quantity -= returned
+
+		recalled := record.Status == models.BorrowStatusRecalled
+		if record.Quantity == 0 {
+			record.Status = models.BorrowStatusReturned
+			record.ReturnedAt = &now
+		}
+		if err := s.recordRepo.Update(ctx, record); err != nil {
+			return err
+		}
+
+		// Recalled shares go back to the lender instead of the lendable pool
+		if recalled {
+			err = s.inventoryRepo.Retire(ctx, instrumentID, returned)
+		} else {
+			err = s.inventoryRepo.Release(ctx, instrumentID, returned)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AccrueFees charges each outstanding borrow for every full day since it was last charged
+func (s *BorrowService) AccrueFees(ctx context.Context, today time.Time) {
+	records, err := s.recordRepo.FindAccruing(ctx)
+	if err != nil {
+		log.Printf("[Borrow] Failed to load outstanding borrows: %v", err)
+		return
+	}
+
+	charged := 0
+	for i := range records {
+		record := &records[i]
+		since := record.BorrowedAt
+		if record.LastAccruedAt != nil {
+			since = *record.LastAccruedAt
+		}
+		sinceDay, _ := istDayBounds(since)
+		days := int(today.Sub(sinceDay).Hours() / 24)
+		if days <= 0 {
+			continue
+		}
+
+		data, err := s.marketDataRepo.FindByInstrumentID(ctx, record.InstrumentID.Hex())
+		if err != nil || data == nil || data.LastPrice <= 0 {
+			log.Printf("[Borrow] No price for %s, deferring fee on borrow %s", record.Symbol, record.ID.Hex())
+			continue
+		}
+
+		fee := float64(record.Quantity) * data.LastPrice * record.BorrowRate * float64(days)
+		if err := s.chargeFee(ctx, record, fee, days, today); err != nil {
+			log.Printf("[Borrow] Failed to charge borrow %s: %v", record.ID.Hex(), err)
+			continue
+		}
+		charged++
+	}
+
+	if charged > 0 {
+		log.Printf("[Borrow] Accrued borrow fees on %d borrows", charged)
+	}
+}
+
+// chargeFee debits one accrual and advances the borrow's LastAccruedAt in a single transaction,
+// so a failure between the two cannot charge the same days twice
+func (s *BorrowService) chargeFee(ctx context.Context, record *models.BorrowRecord, fee float64, days int, today time.Time) error {
+	session, err := s.recordRepo.GetDatabase().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	updated := *record
+	updated.AccruedFees += fee
+	updated.LastAccruedAt = &today
+	reference := fmt.Sprintf("BORROW_FEE_%s_%s", record.ID.Hex(), today.Format("20060102"))
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := s.accountService.ChargeFee(utils.WithAccountID(sessCtx, record.AccountID.Hex()), record.UserID.Hex(), fee, reference,
+			fmt.Sprintf("Borrow fee: %d %s x %d day(s) @ %.3f%%", record.Quantity, record.Symbol, days, record.BorrowRate*100)); err != nil {
+			return nil, err
+		}
+		return nil, s.recordRepo.Update(sessCtx, &updated)
+	})
+	if err != nil {
+		return err
+	}
+	*record = updated
+	return nil
+}
+
+func (s *BorrowService) GetInventory(ctx context.Context) ([]models.BorrowInventory, error) {
+	return s.inventoryRepo.FindAll(ctx)
+}
+
+// UpdateInventory sets an instrument's lendable supply and rate tier; quantity already lent is preserved
+func (s *BorrowService) UpdateInventory(ctx context.Context, instrumentID string, req models.BorrowInventory, adminID string) (*models.BorrowInventory, error) {
+	instrument, err := s.instrumentRepo.FindByID(instrumentID)
+	if err != nil || instrument == nil {
+		return nil, errors.New("instrument not found")
+	}
+	if req.TotalQuantity < 0 {
+		return nil, errors.New("total quantity cannot be negative")
+	}
+	if req.Tier != models.BorrowTierEasy && req.Tier != models.BorrowTierHard {
+		return nil, fmt.Errorf("tier must be %s or %s", models.BorrowTierEasy, models.BorrowTierHard)
+	}
+	if req.DailyRate < 0 || req.DailyRate > 0.05 {
+		return nil, errors.New("daily rate must be between 0 and 5%")
+	}
+	if req.DailyRate == 0 {
+		req.DailyRate = defaultEasyBorrowRate
+		if req.Tier == models.BorrowTierHard {
+			req.DailyRate = defaultHardBorrowRate
+		}
+	}
+
+	old, err := s.inventoryRepo.FindByInstrumentID(ctx, instrument.ID)
+	if err != nil {
+		return nil, err
+	}
+	delta := req.TotalQuantity
+	if old != nil {
+		onLoan := old.TotalQuantity - old.AvailableQuantity
+		if req.TotalQuantity < onLoan {
+			return nil, fmt.Errorf("total quantity cannot be below the %d shares currently located or lent", onLoan)
+		}
+		delta = req.TotalQuantity - old.TotalQuantity
+	}
+
+	req.InstrumentID = instrument.ID
+	req.Symbol = instrument.Symbol
+	req.UpdatedBy, _ = primitive.ObjectIDFromHex(adminID)
+	if err := s.inventoryRepo.Upsert(ctx, &req, delta); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.inventoryRepo.FindByInstrumentID(ctx, instrument.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.LogFromContext(ctx, "BORROW_INVENTORY_UPDATED", instrument.ID.Hex(), "INSTRUMENT",
+		fmt.Sprintf("%s borrow supply set to %d (%s, %.3f%%/day)", instrument.Symbol, req.TotalQuantity, req.Tier, req.DailyRate*100),
+		old, updated)
+	return updated, nil
+}
+
+func (s *BorrowService) GetBorrow(ctx context.Context, borrowID string) (*models.BorrowRecord, error) {
+	record, err := s.recordRepo.FindByID(ctx, borrowID)
+	if err != nil || record == nil {
+		return nil, errors.New("borrow not found")
+	}
+	return record, nil
+}
+
+func (s *BorrowService) GetUserBorrows(ctx context.Context, userID string) ([]models.BorrowRecord, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recordRepo.FindByUserID(ctx, uid, 100)
+}
+
+func (s *BorrowService) ListBorrows(ctx context.Context, status string) ([]models.BorrowRecord, error) {
+	return s.recordRepo.FindByStatus(ctx, status, 500)
+}
+
+// Recall asks the borrower to cover by the notice deadline; an admin buy-in follows if they don't
+func (s *BorrowService) Recall(ctx context.Context, borrowID string, reason string) (*models.BorrowRecord, error) {
+	record, err := s.GetBorrow(ctx, borrowID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != models.BorrowStatusActive {
+		return nil, fmt.Errorf("cannot recall borrow with status: %s", record.Status)
+	}
+
+	old := *record
+	now := time.Now()
+	deadline := now.Add(borrowRecallNotice)
+	record.Status = models.BorrowStatusRecalled
+	record.RecalledAt = &now
+	record.RecallDeadline = &deadline
+	if err := s.recordRepo.Update(ctx, record); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "BORROW_RECALLED", record.ID.Hex(), "BORROW",
+		fmt.Sprintf("Recalled %d %s borrowed by %s: %s", record.Quantity, record.Symbol, record.UserID.Hex(), reason), old, record)
+
+	go func() {
+		_ = s.notificationService.SendNotification(
+			context.Background(),
+			record.UserID.Hex(),
+			models.NotificationTypeAlert,
+			"Borrow Recalled",
+			fmt.Sprintf("The lender has recalled %d %s shares you borrowed. Cover your short by %s or it will be bought in at market.",
+				record.Quantity, record.Symbol, deadline.In(utils.GetISTTime().Location()).Format("02 Jan 15:04 IST")),
+			map[string]interface{}{"borrowId": record.ID.Hex(), "symbol": record.Symbol, "quantity": record.Quantity},
+			nil,
+		)
+	}()
+
+	return record, nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"aequitas/internal/config"
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ChargeService struct {
+	repo         *repositories.ChargeScheduleRepository
+	config       *config.Config
+	auditService *AuditService
+}
+
+func NewChargeService(repo *repositories.ChargeScheduleRepository, cfg *config.Config, auditService *AuditService) *ChargeService {
+	return &ChargeService{
+		repo:         repo,
+		config:       cfg,
+		auditService: auditService,
+	}
+}
+
+// GetActiveSchedule returns the schedule in force now. Until an admin publishes one,
+// a version 0 schedule is derived from fees.json plus current statutory rates.
+func (s *ChargeService) GetActiveSchedule(ctx context.Context) (*models.ChargeSchedule, error) {
+	schedule, err := s.repo.FindEffective(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return s.defaultSchedule(), nil
+	}
+	return schedule, nil
+}
+
+func (s *ChargeService) GetScheduleHistory(ctx context.Context) ([]*models.ChargeSchedule, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// PublishSchedule stores a new immutable version. JIT approval is enforced at the route.
+func (s *ChargeService) PublishSchedule(ctx context.Context, schedule *models.ChargeSchedule, adminID string) (*models.ChargeSchedule, error) {
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if schedule.EffectiveFrom.IsZero() {
+		schedule.EffectiveFrom = now
+	} else if schedule.EffectiveFrom.Before(now.Add(-time.Minute)) {
+		return nil, errors.New("effectiveFrom cannot be in the past")
+	}
+
+	previous, _ := s.GetActiveSchedule(ctx)
+
+	latest, err := s.repo.FindLatestVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedule.ID = primitive.NilObjectID
+	schedule.Version = latest + 1
+	schedule.CreatedBy, _ = primitive.ObjectIDFromHex(adminID)
+
+	if err := s.repo.Create(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to publish charge schedule: %w", err)
+	}
+
+	s.auditService.LogFromContext(ctx, "CHARGE_SCHEDULE_PUBLISHED", schedule.ID.Hex(), "CHARGE_SCHEDULE",
+		fmt.Sprintf("Charge schedule v%d published, effective %s", schedule.Version, schedule.EffectiveFrom.Format(time.RFC3339)), previous, schedule)
+
+	return schedule, nil
+}
+
+// Calculate itemises charges for one execution using the active schedule
+func (s *ChargeService) Calculate(ctx context.Context, productType, side string, value float64) (models.TradeCharges, int, error) {
+	schedule, err := s.GetActiveSchedule(ctx)
+	if err != nil {
+		return models.TradeCharges{}, 0, err
+	}
+	return ComputeCharges(schedule, productType, side, value), schedule.Version, nil
+}
+
+// ComputeCharges applies a schedule to a trade value. Unknown product types fall back to CNC.
+func ComputeCharges(schedule *models.ChargeSchedule, productType, side string, value float64) models.TradeCharges {
+	product, ok := schedule.Products[productType]
+	if !ok {
+		product = schedule.Products[models.ProductTypeCNC]
+	}
+
+	var c models.TradeCharges
+
+	brokerage := value * product.Brokerage.Rate
+	if product.Brokerage.Cap > 0 && brokerage > product.Brokerage.Cap {
+		brokerage = product.Brokerage.Cap
+	}
+	c.Brokerage = roundPaise(product.Brokerage.Flat + brokerage)
+
+	if side == "BUY" {
+		c.STT = roundPaise(value * product.STTBuyRate)
+		c.StampDuty = roundPaise(value * product.StampDutyRate)
+	} else {
+		c.STT = roundPaise(value * product.STTSellRate)
+	}
+
+	c.ExchangeTxnCharges = roundPaise(value * schedule.ExchangeTxnRate)
+	c.SEBIFees = roundPaise(value * schedule.SEBIFeeRate)
+	c.GST = roundPaise((c.Brokerage + c.ExchangeTxnCharges + c.SEBIFees) * schedule.GSTRate)
+
+	c.Total = roundPaise(c.Brokerage + c.STT + c.ExchangeTxnCharges + c.SEBIFees + c.GST + c.StampDuty)
+	return c
+}
+
+// defaultSchedule keeps the legacy fees.json brokerage and applies standard NSE equity rates
+func (s *ChargeService) defaultSchedule() *models.ChargeSchedule {
+	brokerage := models.BrokerageRule{
+		Flat: s.config.FlatFee,
+		Rate: s.config.CommissionRate,
+		Cap:  s.config.MaxCommission,
+	}
+
+	return &models.ChargeSchedule{
+		Version: 0,
+		Products: map[string]models.ProductCharges{
+			models.ProductTypeCNC: {
+				Brokerage:     brokerage,
+				STTBuyRate:    0.001,
+				STTSellRate:   0.001,
+				StampDutyRate: 0.00015,
+			},
+			models.ProductTypeMIS: {
+				Brokerage:     brokerage,
+				STTBuyRate:    0,
+				STTSellRate:   0.00025,
+				StampDutyRate: 0.00003,
+			},
+		},
+		ExchangeTxnRate: 0.0000297, // NSE: 0.00297%
+		SEBIFeeRate:     0.000001,  // ₹10 per crore
+		GSTRate:         0.18,
+		Notes:           "Default schedule (fees.json)",
+	}
+}
+
+func validateSchedule(schedule *models.ChargeSchedule) error {
+	if _, ok := schedule.Products[models.ProductTypeCNC]; !ok {
+		return errors.New("schedule must define charges for product type CNC")
+	}
+
+	rates := []float64{schedule.ExchangeTxnRate, schedule.SEBIFeeRate, schedule.GSTRate}
+	for productType, p := range schedule.Products {
+		if productType != models.ProductTypeCNC && productType != models.ProductTypeMIS {
+			return fmt.Errorf("unknown product type: %s", productType)
+		}
+		if p.Brokerage.Flat < 0 || p.Brokerage.Cap < 0 {
+			return fmt.Errorf("brokerage for %s cannot be negative", productType)
+		}
+		rates = append(rates, p.Brokerage.Rate, p.STTBuyRate, p.STTSellRate, p.StampDutyRate)
+	}
+
+	for _, r := range rates {
+		if r < 0 || r >= 1 {
+			return errors.New("rates must be fractions between 0 and 1 (e.g. 0.001 for 0.1%)")
+		}
+	}
+	return nil
+}
+
+func roundPaise(v float64) float64 {
+	return math.Round(v*100) / 100
+}
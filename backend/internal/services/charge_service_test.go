@@ -0,0 +1,102 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"aequitas/internal/config"
+	"aequitas/internal/models"
+)
+
+func testSchedule() *models.ChargeSchedule {
+	s := &ChargeService{config: &config.Config{CommissionRate: 0.0003, MaxCommission: 20}}
+	return s.defaultSchedule()
+}
+
+func TestComputeCharges(t *testing.T) {
+	tests := []struct {
+		name        string
+		productType string
+		side        string
+		value       float64
+		want        models.TradeCharges
+	}{
+		{
+			name: "CNC buy with capped brokerage", productType: models.ProductTypeCNC, side: "BUY", value: 100000,
+			want: models.TradeCharges{Brokerage: 20, STT: 100, ExchangeTxnCharges: 2.97, SEBIFees: 0.1, GST: 4.15, StampDuty: 15, Total: 142.22},
+		},
+		{
+			name: "CNC sell pays no stamp duty", productType: models.ProductTypeCNC, side: "SELL", value: 100000,
+			want: models.TradeCharges{Brokerage: 20, STT: 100, ExchangeTxnCharges: 2.97, SEBIFees: 0.1, GST: 4.15, Total: 127.22},
+		},
+		{
+			name: "MIS buy pays no STT", productType: models.ProductTypeMIS, side: "BUY", value: 100000,
+			want: models.TradeCharges{Brokerage: 20, ExchangeTxnCharges: 2.97, SEBIFees: 0.1, GST: 4.15, StampDuty: 3, Total: 30.22},
+		},
+		{
+			name: "MIS sell", productType: models.ProductTypeMIS, side: "SELL", value: 100000,
+			want: models.TradeCharges{Brokerage: 20, STT: 25, ExchangeTxnCharges: 2.97, SEBIFees: 0.1, GST: 4.15, Total: 52.22},
+		},
+		{
+			name: "uncapped brokerage rounds each line to paise", productType: models.ProductTypeCNC, side: "BUY", value: 10000,
+			want: models.TradeCharges{Brokerage: 3, STT: 10, ExchangeTxnCharges: 0.3, SEBIFees: 0.01, GST: 0.6, StampDuty: 1.5, Total: 15.41},
+		},
+		{
+			name: "unknown product falls back to CNC", productType: "NRML", side: "BUY", value: 100000,
+			want: models.TradeCharges{Brokerage: 20, STT: 100, ExchangeTxnCharges: 2.97, SEBIFees: 0.1, GST: 4.15, StampDuty: 15, Total: 142.22},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeCharges(testSchedule(), tt.productType, tt.side, tt.value)
+			if got != tt.want {
+				t.Errorf("ComputeCharges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeChargesFlatBrokerage(t *testing.T) {
+	schedule := testSchedule()
+	cnc := schedule.Products[models.ProductTypeCNC]
+	cnc.Brokerage = models.BrokerageRule{Flat: 10, Rate: 0.001, Cap: 5}
+	schedule.Products[models.ProductTypeCNC] = cnc
+
+	got := ComputeCharges(schedule, models.ProductTypeCNC, "SELL", 100000)
+	if got.Brokerage != 15 {
+		t.Errorf("Brokerage = %v, want flat 10 plus capped 5", got.Brokerage)
+	}
+	if math.Abs(got.Statutory()-(got.Total-15)) > 1e-9 {
+		t.Errorf("Statutory() = %v, want total less brokerage", got.Statutory())
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*models.ChargeSchedule)
+		wantErr bool
+	}{
+		{"default schedule", func(*models.ChargeSchedule) {}, false},
+		{"missing CNC", func(s *models.ChargeSchedule) { delete(s.Products, models.ProductTypeCNC) }, true},
+		{"unknown product", func(s *models.ChargeSchedule) { s.Products["NRML"] = s.Products[models.ProductTypeCNC] }, true},
+		{"percentage instead of fraction", func(s *models.ChargeSchedule) { s.GSTRate = 18 }, true},
+		{"negative rate", func(s *models.ChargeSchedule) { s.SEBIFeeRate = -0.000001 }, true},
+		{"negative brokerage cap", func(s *models.ChargeSchedule) {
+			mis := s.Products[models.ProductTypeMIS]
+			mis.Brokerage.Cap = -1
+			s.Products[models.ProductTypeMIS] = mis
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := testSchedule()
+			tt.mutate(schedule)
+			if err := validateSchedule(schedule); (err != nil) != tt.wantErr {
+				t.Errorf("validateSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
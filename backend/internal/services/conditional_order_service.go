@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConditionalOrderService fires CONDITIONAL orders when their condition holds, evaluated after every price tick
+type ConditionalOrderService struct {
+	orderRepo           *repositories.OrderRepository
+	marketDataRepo      *repositories.MarketDataRepository
+	candleRepo          *repositories.CandleRepository
+	orderService        *OrderService
+	notificationService *NotificationService
+	auditService        *AuditService
+	running             sync.Mutex // One evaluation pass at a time; overlapping ticks are skipped
+}
+
+func NewConditionalOrderService(
+	orderRepo *repositories.OrderRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	candleRepo *repositories.CandleRepository,
+	orderService *OrderService,
+	notificationService *NotificationService,
+	auditService *AuditService,
+) *ConditionalOrderService {
+	return &ConditionalOrderService{
+		orderRepo:           orderRepo,
+		marketDataRepo:      marketDataRepo,
+		candleRepo:          candleRepo,
+		orderService:        orderService,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// EvaluateConditionalOrders checks every pending conditional order against the latest prices
+func (s *ConditionalOrderService) EvaluateConditionalOrders(ctx context.Context) {
+	if !s.running.TryLock() {
+		return
+	}
+	defer s.running.Unlock()
+
+	orders, err := s.orderRepo.FindPendingConditionalOrders(ctx)
+	if err != nil {
+		log.Printf("Conditional order monitor error: failed to fetch pending orders: %v", err)
+		return
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	eval := newConditionEvaluator(s.marketDataRepo, s.candleRepo)
+	now := time.Now()
+	for _, order := range orders {
+		if met, _ := eval.Met(ctx, order.Condition, now); met {
+			s.trigger(ctx, order, eval)
+		}
+	}
+}
+
+// trigger claims the order and places its MARKET or LIMIT order
+func (s *ConditionalOrderService) trigger(ctx context.Context, order *models.Order, eval *conditionEvaluator) {
+	ctx = utils.WithAccountID(ctx, order.AccountID.Hex())
+	var triggerPrice *float64
+	if quote, err := eval.quote(ctx, order.InstrumentID); err == nil {
+		triggerPrice = &quote.LastPrice
+	}
+	old := *order
+	claimed, err := s.orderRepo.ClaimPending(ctx, order, models.OrderReasonConditionMet, triggerPrice)
+	if err != nil || !claimed {
+		return // Cancelled or fired by another pass
+	}
+
+	newOrder := models.Order{
+		InstrumentID:  order.InstrumentID,
+		Symbol:        order.Symbol,
+		Side:          order.Side,
+		OrderType:     "MARKET",
+		Quantity:      order.Quantity,
+		Intent:        order.Intent,
+		Netting:       order.Netting,
+		ProductType:   order.ProductType,
+		Source:        "CONDITION_TRIGGER",
+		ClientOrderID: fmt.Sprintf("COND-%s", order.OrderID),
+		ParentOrderID: &order.ID,
+	}
+	if order.LimitPrice != nil {
+		newOrder.OrderType = "LIMIT"
+		newOrder.Price = order.LimitPrice
+	}
+
+	title := "Conditional Order Triggered"
+	message := fmt.Sprintf("The condition on your %s order for %d %s was met; a %s order was placed.",
+		order.Side, order.Quantity, order.Symbol, newOrder.OrderType)
+
+	placed, err := s.orderService.PlaceOrder(ctx, order.UserID.Hex(), newOrder)
+	if err != nil {
+		log.Printf("❌ Conditional order trigger failed: %s - %v", order.OrderID, err)
+		order.Status = "REJECTED"
+		order.StatusReason = models.OrderReasonTriggerFailed
+		order.StatusMessage = err.Error()
+		title = "Conditional Order Rejected"
+		message = fmt.Sprintf("The condition on your %s order for %d %s was met but the order was rejected: %v",
+			order.Side, order.Quantity, order.Symbol, err)
+		if _, err := s.orderRepo.Update(ctx, order); err != nil {
+			log.Printf("Conditional order monitor error: failed to update %s: %v", order.OrderID, err)
+		}
+	} else {
+		log.Printf("✅ Conditional order executed: %s → %s", order.OrderID, placed.OrderID)
+	}
+
+	s.auditService.Log(order.UserID.Hex(), "System", "SYSTEM", "CONDITIONAL_ORDER_"+order.Status, order.ID.Hex(), "ORDER",
+		fmt.Sprintf("Condition met for %s %d %s", order.Side, order.Quantity, order.Symbol), old, order)
+
+	data := map[string]interface{}{"orderId": order.ID.Hex(), "symbol": order.Symbol}
+	if placed != nil {
+		data["triggeredOrderId"] = placed.ID.Hex()
+	}
+	go func() {
+		_ = s.notificationService.SendNotification(context.Background(), order.UserID.Hex(),
+			models.NotificationTypeOrder, title, message, data, nil)
+	}()
+}
+
+// conditionEvaluator tests order conditions, caching quotes and indicators for one pass
+type conditionEvaluator struct {
+	marketDataRepo *repositories.MarketDataRepository
+	candleRepo     *repositories.CandleRepository
+	quotes         map[primitive.ObjectID]*models.MarketData
+	indicators     map[string]float64
+}
+
+func newConditionEvaluator(marketDataRepo *repositories.MarketDataRepository, candleRepo *repositories.CandleRepository) *conditionEvaluator {
+	return &conditionEvaluator{
+		marketDataRepo: marketDataRepo,
+		candleRepo:     candleRepo,
+		quotes:         make(map[primitive.ObjectID]*models.MarketData),
+		indicators:     make(map[string]float64),
+	}
+}
+
+// Met reports whether the condition holds; a clause without data counts as not met
+func (e *conditionEvaluator) Met(ctx context.Context, cond *models.OrderCondition, now time.Time) (bool, error) {
+	if cond == nil || len(cond.Clauses) == 0 {
+		return false, errors.New("condition has no clauses")
+	}
+
+	var firstErr error
+	for i := range cond.Clauses {
+		met, err := e.clauseMet(ctx, &cond.Clauses[i], now)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if cond.Logic == models.ConditionLogicOr && met {
+			return true, nil
+		}
+		if cond.Logic != models.ConditionLogicOr && !met {
+			return false, firstErr
+		}
+	}
+	return cond.Logic != models.ConditionLogicOr, firstErr
+}
+
+func (e *conditionEvaluator) clauseMet(ctx context.Context, c *models.ConditionClause, now time.Time) (bool, error) {
+	if c.Type == models.ConditionTime {
+		return c.At != nil && !now.Before(*c.At), nil
+	}
+	if c.InstrumentID == nil {
+		return false, errors.New("condition clause has no instrument")
+	}
+
+	quote, err := e.quote(ctx, *c.InstrumentID)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.Type {
+	case models.ConditionPrice:
+		return c.Compare(quote.LastPrice, c.Value), nil
+	case models.ConditionChangeFromOpen:
+		if quote.Open <= 0 {
+			return false, fmt.Errorf("no opening price for %s", c.Symbol)
+		}
+		return c.Compare((quote.LastPrice-quote.Open)/quote.Open*100, c.Value), nil
+	case models.ConditionIndicator:
+		value, err := e.indicator(c)
+		if err != nil {
+			return false, err
+		}
+		if c.Indicator == models.IndicatorRSI {
+			return c.Compare(value, c.Value), nil
+		}
+		return c.Compare(quote.LastPrice, value), nil
+	}
+	return false, fmt.Errorf("unknown condition type: %s", c.Type)
+}
+
+func (e *conditionEvaluator) quote(ctx context.Context, instrumentID primitive.ObjectID) (*models.MarketData, error) {
+	if quote, ok := e.quotes[instrumentID]; ok {
+		return quote, nil
+	}
+	quote, err := e.marketDataRepo.FindByInstrumentID(ctx, instrumentID.Hex())
+	if err != nil || quote == nil {
+		return nil, errors.New("market data unavailable for condition instrument")
+	}
+	e.quotes[instrumentID] = quote
+	return quote, nil
+}
+
+// indicator computes an SMA, EMA or RSI over the instrument's latest candles
+func (e *conditionEvaluator) indicator(c *models.ConditionClause) (float64, error) {
+	key := fmt.Sprintf("%s/%s/%d/%s", c.InstrumentID.Hex(), c.Indicator, c.Period, c.Interval)
+	if value, ok := e.indicators[key]; ok {
+		return value, nil
+	}
+
+	needed := c.Period
+	switch c.Indicator {
+	case models.IndicatorEMA:
+		needed = c.Period * 2 // Extra history lets the average settle
+	case models.IndicatorRSI:
+		needed = c.Period + 1
+	}
+	now := time.Now()
+	candles, err := e.candleRepo.GetCandles(c.InstrumentID.Hex(), c.Interval, now.AddDate(0, 0, -30), now, needed)
+	if err != nil {
+		return 0, err
+	}
+	if len(candles) < c.Period || (c.Indicator == models.IndicatorRSI && len(candles) < c.Period+1) {
+		return 0, fmt.Errorf("not enough %s candles for %s(%d) on %s", c.Interval, c.Indicator, c.Period, c.Symbol)
+	}
+
+	closes := make([]float64, len(candles))
+	for i, candle := range candles {
+		closes[i] = candle.Close
+	}
+
+	var value float64
+	switch c.Indicator {
+	case models.IndicatorSMA:
+		value, _ = meanStdDev(closes[len(closes)-c.Period:])
+	case models.IndicatorEMA:
+		k := 2 / float64(c.Period+1)
+		value = closes[0]
+		for _, price := range closes[1:] {
+			value = price*k + value*(1-k)
+		}
+	case models.IndicatorRSI:
+		gains, losses := 0.0, 0.0
+		for i := len(closes) - c.Period; i < len(closes); i++ {
+			if change := closes[i] - closes[i-1]; change > 0 {
+				gains += change
+			} else {
+				losses -= change
+			}
+		}
+		if losses == 0 {
+			value = 100
+		} else {
+			value = 100 - 100/(1+gains/losses)
+		}
+	default:
+		return 0, fmt.Errorf("unknown indicator: %s", c.Indicator)
+	}
+
+	e.indicators[key] = value
+	return value, nil
+}
@@ -48,8 +48,10 @@ type DashboardSummary struct {
 }
 
 type HeatmapSector struct {
-	Name   string       `json:"name"`
-	Stocks []SmartStock `json:"stocks"`
+	Name       string       `json:"name"`
+	IndexLevel float64      `json:"indexLevel,omitempty"` // Sector index level, when one is maintained
+	ChangePct  float64      `json:"changePct"`            // Sector index change for the session
+	Stocks     []SmartStock `json:"stocks"`
 }
 
 type PerformanceOverview struct {
@@ -619,6 +621,21 @@ func (s *DashboardService) getMarketIntelligence(
 		}, nil
 	}
 
+	// Indices are not stocks; keep them out of gainers/losers
+	if indices, err := s.instrumentRepo.FindAll(map[string]interface{}{"type": models.InstrumentTypeIndex}); err == nil && len(indices) > 0 {
+		isIndex := make(map[string]bool, len(indices))
+		for _, inst := range indices {
+			isIndex[inst.ID.Hex()] = true
+		}
+		stocks := allData[:0]
+		for _, data := range allData {
+			if !isIndex[data.InstrumentID.Hex()] {
+				stocks = append(stocks, data)
+			}
+		}
+		allData = stocks
+	}
+
 	// Handle empty data
 	if len(allData) == 0 {
 		return &MarketIntelligence{
@@ -675,10 +692,17 @@ func (s *DashboardService) getMarketHeatmap(
 		instrumentMap[inst.ID.Hex()] = inst
 	}
 
-	// Group by sector
+	// Group by sector; sector indices colour the tile rather than appearing as stocks
 	sectorMap := make(map[string][]SmartStock)
+	sectorIndex := make(map[string]*models.MarketData)
 	for _, data := range allData {
 		inst, ok := instrumentMap[data.InstrumentID.Hex()]
+		if ok && inst.Type == models.InstrumentTypeIndex {
+			if inst.Sector != "" {
+				sectorIndex[inst.Sector] = data
+			}
+			continue
+		}
 		sector := "Other"
 		name := data.Symbol
 		if ok {
@@ -710,10 +734,15 @@ func (s *DashboardService) getMarketHeatmap(
 			stocks = stocks[:limit]
 		}
 
-		sectors = append(sectors, HeatmapSector{
+		sector := HeatmapSector{
 			Name:   sectorName,
 			Stocks: stocks,
-		})
+		}
+		if index, ok := sectorIndex[sectorName]; ok {
+			sector.IndexLevel = index.LastPrice
+			sector.ChangePct = index.ChangePct
+		}
+		sectors = append(sectors, sector)
 	}
 
 	// Sort sectors by name for consistency
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GTTService manages good-till-triggered stop-loss/target triggers on holdings and fires them through OrderService
+type GTTService struct {
+	gttRepo             *repositories.GTTRepository
+	portfolioRepo       *repositories.PortfolioRepository
+	instrumentRepo      *repositories.InstrumentRepository
+	marketDataRepo      *repositories.MarketDataRepository
+	orderService        *OrderService
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+func NewGTTService(
+	gttRepo *repositories.GTTRepository,
+	portfolioRepo *repositories.PortfolioRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	orderService *OrderService,
+	notificationService *NotificationService,
+	auditService *AuditService,
+) *GTTService {
+	return &GTTService{
+		gttRepo:             gttRepo,
+		portfolioRepo:       portfolioRepo,
+		instrumentRepo:      instrumentRepo,
+		marketDataRepo:      marketDataRepo,
+		orderService:        orderService,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// GTTRequest creates or replaces a trigger; Quantity 0 protects the whole position
+type GTTRequest struct {
+	InstrumentID string          `json:"instrumentId"`
+	Type         string          `json:"type"`
+	Quantity     int             `json:"quantity"`
+	Legs         []models.GTTLeg `json:"legs"`
+	ExpiresAt    *time.Time      `json:"expiresAt,omitempty"`
+}
+
+func (s *GTTService) GetUserTriggers(ctx context.Context, userID string, status string) ([]models.GTTTrigger, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.gttRepo.FindByUserID(ctx, uid, status)
+}
+
+// CreateTrigger attaches a GTT to the user's open position in an instrument
+func (s *GTTService) CreateTrigger(ctx context.Context, userID string, req GTTRequest) (*models.GTTTrigger, error) {
+	holding, err := s.portfolioRepo.GetHolding(ctx, userID, req.InstrumentID)
+	if err != nil {
+		return nil, errors.New("invalid instrument ID")
+	}
+	if holding == nil || holding.Quantity <= 0 {
+		return nil, errors.New("no open position to attach a GTT to")
+	}
+
+	existing, err := s.gttRepo.FindActiveForPosition(ctx, holding.UserID, holding.InstrumentID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("a GTT is already active on this position; modify or cancel it instead")
+	}
+
+	now := time.Now()
+	trigger := &models.GTTTrigger{
+		UserID:       holding.UserID,
+		AccountID:    holding.AccountID,
+		HoldingID:    holding.ID,
+		InstrumentID: holding.InstrumentID,
+		Symbol:       holding.Symbol,
+		PositionType: holding.PositionType,
+		Status:       models.GTTStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.applyRequest(ctx, trigger, holding, req); err != nil {
+		return nil, err
+	}
+	if err := s.gttRepo.Create(ctx, trigger); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "GTT_CREATED", trigger.ID.Hex(), "GTT",
+		fmt.Sprintf("%s GTT on %d %s (%s)", trigger.Type, trigger.Quantity, trigger.Symbol, trigger.PositionType), nil, trigger)
+	return trigger, nil
+}
+
+// ModifyTrigger replaces the legs, quantity and expiry of an active trigger
+func (s *GTTService) ModifyTrigger(ctx context.Context, userID string, triggerID string, req GTTRequest) (*models.GTTTrigger, error) {
+	trigger, err := s.getOwnedActive(ctx, userID, triggerID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = utils.WithAccountID(ctx, trigger.AccountID.Hex())
+
+	holding, err := s.portfolioRepo.GetHolding(ctx, userID, trigger.InstrumentID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if holding == nil || holding.Quantity <= 0 || holding.PositionType != trigger.PositionType {
+		return nil, errors.New("the position this GTT protects is no longer open")
+	}
+
+	old := *trigger
+	if err := s.applyRequest(ctx, trigger, holding, req); err != nil {
+		return nil, err
+	}
+	if err := s.gttRepo.Update(ctx, trigger); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "GTT_MODIFIED", trigger.ID.Hex(), "GTT",
+		fmt.Sprintf("%s GTT on %d %s modified", trigger.Type, trigger.Quantity, trigger.Symbol), old, trigger)
+	return trigger, nil
+}
+
+func (s *GTTService) CancelTrigger(ctx context.Context, userID string, triggerID string) (*models.GTTTrigger, error) {
+	trigger, err := s.getOwnedActive(ctx, userID, triggerID)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.gttRepo.ClaimActive(ctx, trigger.ID, models.GTTStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errors.New("GTT is no longer active")
+	}
+
+	old := *trigger
+	trigger.Status = models.GTTStatusCancelled
+	trigger.StatusReason = "cancelled by user"
+	if err := s.gttRepo.Update(ctx, trigger); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "GTT_CANCELLED", trigger.ID.Hex(), "GTT",
+		fmt.Sprintf("%s GTT on %s cancelled", trigger.Type, trigger.Symbol), old, trigger)
+	return trigger, nil
+}
+
+// MonitorTriggers expires stale triggers and fires any leg whose trigger price has been reached
+func (s *GTTService) MonitorTriggers(ctx context.Context) {
+	if expired, err := s.gttRepo.ExpireBefore(ctx, time.Now()); err != nil {
+		log.Printf("GTT monitor error: failed to expire triggers: %v", err)
+	} else if expired > 0 {
+		log.Printf("GTT monitor: expired %d triggers", expired)
+	}
+
+	triggers, err := s.gttRepo.FindActive(ctx)
+	if err != nil {
+		log.Printf("GTT monitor error: failed to fetch active triggers: %v", err)
+		return
+	}
+
+	prices := make(map[primitive.ObjectID]float64)
+	for i := range triggers {
+		trigger := &triggers[i]
+		price, ok := prices[trigger.InstrumentID]
+		if !ok {
+			marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, trigger.InstrumentID.Hex())
+			if err != nil || marketData == nil {
+				continue
+			}
+			price = marketData.LastPrice
+			prices[trigger.InstrumentID] = price
+		}
+
+		if leg := trigger.Fires(price); leg != nil {
+			s.fire(ctx, trigger, *leg, price)
+		}
+	}
+}
+
+// fire claims the trigger and places the closing order for the leg that was hit
+func (s *GTTService) fire(ctx context.Context, trigger *models.GTTTrigger, leg models.GTTLeg, price float64) {
+	ctx = utils.WithAccountID(ctx, trigger.AccountID.Hex())
+	claimed, err := s.gttRepo.ClaimActive(ctx, trigger.ID, models.GTTStatusTriggered)
+	if err != nil || !claimed {
+		return // Cancelled, resized away or fired by another pass
+	}
+
+	log.Printf("🎯 GTT %s fired: %s %s at ₹%.2f (trigger ₹%.2f)", trigger.ID.Hex(), leg.Kind, trigger.Symbol, price, leg.TriggerPrice)
+
+	now := time.Now()
+	old := *trigger
+	trigger.Status = models.GTTStatusTriggered
+	trigger.FiredLeg = leg.Kind
+	trigger.FiredPrice = price
+	trigger.TriggeredAt = &now
+
+	order := models.Order{
+		InstrumentID:  trigger.InstrumentID,
+		Symbol:        trigger.Symbol,
+		OrderType:     leg.OrderType,
+		Quantity:      trigger.Quantity,
+		Source:        "GTT",
+		ClientOrderID: fmt.Sprintf("GTT-%s", trigger.ID.Hex()),
+	}
+	if leg.OrderType == "LIMIT" {
+		order.Price = leg.LimitPrice
+	}
+	if trigger.PositionType == models.PositionShort {
+		order.Side = "BUY"
+		order.Intent = string(models.IntentCloseShort)
+	} else {
+		order.Side = "SELL"
+		order.Intent = string(models.IntentCloseLong)
+	}
+
+	title := "GTT Triggered"
+	message := fmt.Sprintf("Your %s GTT on %s fired at ₹%.2f; a %s %s order for %d shares was placed.",
+		legLabel(leg.Kind), trigger.Symbol, price, leg.OrderType, order.Side, order.Quantity)
+
+	placed, err := s.orderService.PlaceOrder(ctx, trigger.UserID.Hex(), order)
+	if err != nil {
+		log.Printf("❌ GTT %s order rejected: %v", trigger.ID.Hex(), err)
+		trigger.Status = models.GTTStatusRejected
+		trigger.StatusReason = err.Error()
+		title = "GTT Order Rejected"
+		message = fmt.Sprintf("Your %s GTT on %s fired at ₹%.2f but the order was rejected: %v",
+			legLabel(leg.Kind), trigger.Symbol, price, err)
+	} else {
+		trigger.OrderID = &placed.ID
+	}
+
+	if err := s.gttRepo.Update(ctx, trigger); err != nil {
+		log.Printf("GTT monitor error: failed to update trigger %s: %v", trigger.ID.Hex(), err)
+	}
+
+	s.auditService.Log(trigger.UserID.Hex(), "System", "SYSTEM", "GTT_"+trigger.Status, trigger.ID.Hex(), "GTT",
+		fmt.Sprintf("%s leg of GTT on %s fired at ₹%.2f", leg.Kind, trigger.Symbol, price), old, trigger)
+
+	data := map[string]interface{}{"gttId": trigger.ID.Hex(), "symbol": trigger.Symbol}
+	if trigger.OrderID != nil {
+		data["orderId"] = trigger.OrderID.Hex()
+	}
+	go func() {
+		_ = s.notificationService.SendNotification(context.Background(), trigger.UserID.Hex(),
+			models.NotificationTypeOrder, title, message, data, nil)
+	}()
+}
+
+func (s *GTTService) getOwnedActive(ctx context.Context, userID string, triggerID string) (*models.GTTTrigger, error) {
+	trigger, err := s.gttRepo.FindByID(ctx, triggerID)
+	if err != nil || trigger == nil {
+		return nil, errors.New("GTT not found")
+	}
+	if trigger.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	if trigger.Status != models.GTTStatusActive {
+		return nil, fmt.Errorf("cannot change GTT with status: %s", trigger.Status)
+	}
+	return trigger, nil
+}
+
+// applyRequest validates a request against the position and current price and copies it onto the trigger
+func (s *GTTService) applyRequest(ctx context.Context, trigger *models.GTTTrigger, holding *models.Holding, req GTTRequest) error {
+	instrument, err := s.instrumentRepo.FindByID(holding.InstrumentID.Hex())
+	if err != nil || instrument == nil {
+		return errors.New("instrument not found")
+	}
+	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, holding.InstrumentID.Hex())
+	if err != nil || marketData == nil {
+		return errors.New("market data unavailable for this instrument")
+	}
+
+	// Legs
+	switch req.Type {
+	case models.GTTTypeSingle:
+		if len(req.Legs) != 1 {
+			return errors.New("a SINGLE GTT takes exactly one leg")
+		}
+	case models.GTTTypeOCO:
+		if len(req.Legs) != 2 || req.Legs[0].Kind == req.Legs[1].Kind {
+			return errors.New("an OCO GTT takes one STOP_LOSS leg and one TARGET leg")
+		}
+	default:
+		return errors.New("invalid GTT type. Must be SINGLE or OCO")
+	}
+	for _, leg := range req.Legs {
+		if leg.Kind != models.GTTLegStopLoss && leg.Kind != models.GTTLegTarget {
+			return errors.New("invalid leg kind. Must be STOP_LOSS or TARGET")
+		}
+		if leg.TriggerPrice <= 0 || !onTick(leg.TriggerPrice, instrument.TickSize) {
+			return fmt.Errorf("%s trigger price must be positive and a multiple of tick size (%v)", legLabel(leg.Kind), instrument.TickSize)
+		}
+		switch leg.OrderType {
+		case "MARKET":
+			if leg.LimitPrice != nil {
+				return errors.New("market legs must not specify a limit price")
+			}
+		case "LIMIT":
+			if leg.LimitPrice == nil || *leg.LimitPrice <= 0 || !onTick(*leg.LimitPrice, instrument.TickSize) {
+				return fmt.Errorf("%s limit price must be positive and a multiple of tick size (%v)", legLabel(leg.Kind), instrument.TickSize)
+			}
+		default:
+			return errors.New("invalid leg order type. Must be MARKET or LIMIT")
+		}
+	}
+
+	// A leg that is already hit would fire on the next pass
+	probe := models.GTTTrigger{PositionType: holding.PositionType, Legs: req.Legs}
+	if leg := probe.Fires(marketData.LastPrice); leg != nil {
+		return fmt.Errorf("%s trigger ₹%.2f would fire immediately at the current price ₹%.2f", legLabel(leg.Kind), leg.TriggerPrice, marketData.LastPrice)
+	}
+
+	// Quantity
+	if req.Quantity < 0 || req.Quantity > holding.Quantity {
+		return fmt.Errorf("quantity must be between 1 and your position of %d shares", holding.Quantity)
+	}
+	if req.Quantity > 0 && req.Quantity%instrument.LotSize != 0 {
+		return fmt.Errorf("quantity must be a multiple of lot size (%d)", instrument.LotSize)
+	}
+	trigger.FullPosition = req.Quantity == 0 || req.Quantity == holding.Quantity
+	trigger.Quantity = holding.Quantity
+	if !trigger.FullPosition {
+		trigger.Quantity = req.Quantity
+	}
+
+	// Validity
+	now := time.Now()
+	trigger.ExpiresAt = now.Add(models.GTTMaxValidity)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(models.GTTMaxValidity)) {
+			return errors.New("expiry must be in the future and within one year")
+		}
+		trigger.ExpiresAt = *req.ExpiresAt
+	}
+
+	trigger.Type = req.Type
+	trigger.Legs = req.Legs
+	return nil
+}
+
+// onTick reports whether a price is a multiple of the tick size, within float tolerance
+func onTick(price, tickSize float64) bool {
+	remainder := math.Mod(price, tickSize)
+	return remainder <= 0.000001 || tickSize-remainder <= 0.000001
+}
+
+func legLabel(kind string) string {
+	if kind == models.GTTLegTarget {
+		return "target"
+	}
+	return "stop-loss"
+}
@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	broadIndexSymbol  = "AEQ-ALL"
+	defaultIndexBase  = 1000.0
+	indexSyncInterval = time.Hour
+	indexValueEvery   = time.Minute
+)
+
+var nonAlnum = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// IndexService maintains benchmark and sector indices as synthetic instruments with their own MarketData and Candles
+type IndexService struct {
+	indexRepo         *repositories.IndexRepository
+	instrumentRepo    *repositories.InstrumentRepository
+	marketDataRepo    *repositories.MarketDataRepository
+	candleBuilder     *CandleBuilder
+	priceAlertService *PriceAlertService
+	auditService      *AuditService
+	stopChan          chan struct{}
+
+	lastSync       time.Time
+	lastValueWrite time.Time
+}
+
+func NewIndexService(
+	indexRepo *repositories.IndexRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	candleBuilder *CandleBuilder,
+	priceAlertService *PriceAlertService,
+	auditService *AuditService,
+) *IndexService {
+	return &IndexService{
+		indexRepo:         indexRepo,
+		instrumentRepo:    instrumentRepo,
+		marketDataRepo:    marketDataRepo,
+		candleBuilder:     candleBuilder,
+		priceAlertService: priceAlertService,
+		auditService:      auditService,
+		stopChan:          make(chan struct{}),
+	}
+}
+
+type IndexConstituentRequest struct {
+	InstrumentID string  `json:"instrumentId"`
+	Weight       float64 `json:"weight,omitempty"`
+}
+
+type CreateIndexRequest struct {
+	Symbol       string                    `json:"symbol"`
+	Name         string                    `json:"name"`
+	Method       string                    `json:"method"`
+	Constituents []IndexConstituentRequest `json:"constituents"`
+	BaseValue    float64                   `json:"baseValue,omitempty"`
+	IsDefault    bool                      `json:"isDefault"`
+}
+
+// Start begins index calculation (same cadence as the pricing engine)
+func (s *IndexService) Start() {
+	ticker := time.NewTicker(3 * time.Second)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.tick()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("Index engine started (polling 3s)")
+}
+
+func (s *IndexService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *IndexService) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if time.Since(s.lastSync) >= indexSyncInterval {
+		if err := s.SyncManagedIndices(ctx); err != nil {
+			log.Printf("[Index] Failed to sync managed indices: %v", err)
+		}
+		s.lastSync = time.Now()
+	}
+
+	indices, err := s.indexRepo.FindAll(ctx, true)
+	if err != nil || len(indices) == 0 {
+		return
+	}
+
+	prices, err := s.priceMap(ctx)
+	if err != nil {
+		log.Printf("[Index] Failed to load prices: %v", err)
+		return
+	}
+
+	writeValues := time.Since(s.lastValueWrite) >= indexValueEvery
+	today, _ := istDayBounds(time.Now())
+
+	for _, index := range indices {
+		level := indexLevel(index, prices)
+		if level <= 0 {
+			continue
+		}
+		if err := s.publishLevel(ctx, index, level); err != nil {
+			log.Printf("[Index] Failed to publish %s: %v", index.Symbol, err)
+			continue
+		}
+		if writeValues {
+			if err := s.indexRepo.UpsertDailyValue(ctx, index.ID, today, level); err != nil {
+				log.Printf("[Index] Failed to record daily value for %s: %v", index.Symbol, err)
+			}
+		}
+	}
+	if writeValues {
+		s.lastValueWrite = time.Now()
+	}
+}
+
+// publishLevel writes the index level as the synthetic instrument's market data and feeds the candle builder
+func (s *IndexService) publishLevel(ctx context.Context, index *models.BenchmarkIndex, level float64) error {
+	data, err := s.marketDataRepo.FindByInstrumentID(ctx, index.InstrumentID.Hex())
+	if err != nil {
+		return err
+	}
+
+	today, _ := istDayBounds(time.Now())
+	if data == nil {
+		data = &models.MarketData{
+			InstrumentID: index.InstrumentID,
+			Symbol:       index.Symbol,
+			PrevClose:    level,
+			Open:         level,
+			High:         level,
+			Low:          level,
+		}
+	} else if data.UpdatedAt.Before(today) {
+		// New session: roll yesterday's last level into PrevClose
+		data.PrevClose = data.LastPrice
+		data.Open, data.High, data.Low = level, level, level
+	}
+
+	data.LastPrice = level
+	data.Change = level - data.PrevClose
+	if data.PrevClose > 0 {
+		data.ChangePct = (data.Change / data.PrevClose) * 100
+	}
+	if level > data.High {
+		data.High = level
+	}
+	if level < data.Low || data.Low == 0 {
+		data.Low = level
+	}
+
+	if s.candleBuilder != nil {
+		s.candleBuilder.OnPriceTick(index.InstrumentID, level, 0)
+	}
+	if s.priceAlertService != nil {
+		go s.priceAlertService.CheckAlerts(context.Background(), index.InstrumentID.Hex(), level)
+	}
+
+	return s.marketDataRepo.Upsert(ctx, data)
+}
+
+func (s *IndexService) GetIndices(ctx context.Context) ([]*models.BenchmarkIndex, error) {
+	return s.indexRepo.FindAll(ctx, true)
+}
+
+// CreateIndex validates a custom basket, creates its synthetic instrument and bases it at the current prices
+func (s *IndexService) CreateIndex(ctx context.Context, req CreateIndexRequest) (*models.BenchmarkIndex, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if symbol == "" || req.Name == "" {
+		return nil, errors.New("symbol and name are required")
+	}
+	if existing, _ := s.indexRepo.FindBySymbol(ctx, symbol); existing != nil {
+		return nil, errors.New("an index with this symbol already exists")
+	}
+
+	constituents, err := s.resolveConstituents(req.Method, req.Constituents)
+	if err != nil {
+		return nil, err
+	}
+
+	baseValue := req.BaseValue
+	if baseValue <= 0 {
+		baseValue = defaultIndexBase
+	}
+
+	index := &models.BenchmarkIndex{
+		Symbol:       symbol,
+		Name:         req.Name,
+		Method:       req.Method,
+		Constituents: constituents,
+		BaseValue:    baseValue,
+		IsDefault:    req.IsDefault,
+		IsActive:     true,
+	}
+	if err := s.createIndex(ctx, index); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "INDEX_CREATED", index.ID.Hex(), "BENCHMARK_INDEX",
+		fmt.Sprintf("Benchmark index %s created (%s, %d constituents)", index.Symbol, index.Method, len(index.Constituents)), nil, index)
+	return index, nil
+}
+
+// UpdateConstituents replaces the basket and rebases so the index level is continuous
+func (s *IndexService) UpdateConstituents(ctx context.Context, id string, req CreateIndexRequest) (*models.BenchmarkIndex, error) {
+	index, err := s.indexRepo.FindByID(ctx, id)
+	if err != nil || index == nil {
+		return nil, errors.New("index not found")
+	}
+	if index.Sector != "" || index.Symbol == broadIndexSymbol {
+		return nil, errors.New("system-maintained indices cannot be edited")
+	}
+
+	method := index.Method
+	if req.Method != "" {
+		method = req.Method
+	}
+	constituents, err := s.resolveConstituents(method, req.Constituents)
+	if err != nil {
+		return nil, err
+	}
+
+	old := *index
+	prices, err := s.priceMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rebaseIndex(index, method, constituents, prices)
+	if req.Name != "" {
+		index.Name = req.Name
+	}
+	if req.IsDefault && !index.IsDefault {
+		s.clearDefault(ctx)
+	}
+	index.IsDefault = req.IsDefault
+
+	if err := s.indexRepo.Update(ctx, index); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "INDEX_REBALANCED", index.ID.Hex(), "BENCHMARK_INDEX",
+		fmt.Sprintf("Benchmark index %s rebalanced", index.Symbol), old, index)
+	return index, nil
+}
+
+// SyncManagedIndices keeps the broad-market index and one equal-weighted index per sector in line with listed instruments
+func (s *IndexService) SyncManagedIndices(ctx context.Context) error {
+	instruments, err := s.instrumentRepo.FindAll(map[string]interface{}{"status": "ACTIVE"})
+	if err != nil {
+		return err
+	}
+
+	var all []*models.Instrument
+	bySector := make(map[string][]*models.Instrument)
+	for _, inst := range instruments {
+		if inst.Type == models.InstrumentTypeIndex {
+			continue
+		}
+		all = append(all, inst)
+		if inst.Sector != "" {
+			bySector[inst.Sector] = append(bySector[inst.Sector], inst)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	prices, err := s.priceMap(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.syncManagedIndex(ctx, broadIndexSymbol, "Aequitas All-Share", "", all, prices); err != nil {
+		return err
+	}
+	for sector, members := range bySector {
+		symbol := "SEC-" + strings.Trim(nonAlnum.ReplaceAllString(strings.ToUpper(sector), "-"), "-")
+		if err := s.syncManagedIndex(ctx, symbol, sector+" Sector", sector, members, prices); err != nil {
+			log.Printf("[Index] Failed to sync sector index %s: %v", symbol, err)
+		}
+	}
+	return nil
+}
+
+func (s *IndexService) syncManagedIndex(ctx context.Context, symbol, name, sector string, members []*models.Instrument, prices map[primitive.ObjectID]float64) error {
+	constituents := make([]models.IndexConstituent, 0, len(members))
+	for _, inst := range members {
+		constituents = append(constituents, models.IndexConstituent{InstrumentID: inst.ID, Symbol: inst.Symbol})
+	}
+
+	index, err := s.indexRepo.FindBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	if index == nil {
+		index = &models.BenchmarkIndex{
+			Symbol:       symbol,
+			Name:         name,
+			Method:       models.IndexEqualWeighted,
+			Constituents: constituents,
+			BaseValue:    defaultIndexBase,
+			Sector:       sector,
+			IsDefault:    symbol == broadIndexSymbol,
+			IsActive:     true,
+		}
+		return s.createIndex(ctx, index)
+	}
+
+	if sameConstituents(index.Constituents, constituents) {
+		return nil
+	}
+	rebaseIndex(index, index.Method, constituents, prices)
+	return s.indexRepo.Update(ctx, index)
+}
+
+// createIndex creates the synthetic instrument, bases the index at current prices and saves it
+func (s *IndexService) createIndex(ctx context.Context, index *models.BenchmarkIndex) error {
+	prices, err := s.priceMap(ctx)
+	if err != nil {
+		return err
+	}
+
+	instrument := &models.Instrument{
+		Symbol:   index.Symbol,
+		Name:     index.Name,
+		Exchange: snapshotExchange,
+		Type:     models.InstrumentTypeIndex,
+		Sector:   index.Sector,
+		LotSize:  1,
+		TickSize: 0.01,
+		Status:   "ACTIVE",
+	}
+	if err := s.instrumentRepo.Create(instrument); err != nil {
+		return err
+	}
+	index.InstrumentID = instrument.ID
+
+	setBasePrices(index.Constituents, prices)
+	if index.Method == models.IndexPriceWeighted {
+		index.Divisor = sumBasePrices(index.Constituents) / index.BaseValue
+	}
+
+	if index.IsDefault {
+		s.clearDefault(ctx)
+	}
+	return s.indexRepo.Create(ctx, index)
+}
+
+func (s *IndexService) clearDefault(ctx context.Context) {
+	current, _ := s.indexRepo.FindDefault(ctx)
+	if current != nil {
+		current.IsDefault = false
+		s.indexRepo.Update(ctx, current)
+	}
+}
+
+func (s *IndexService) resolveConstituents(method string, reqs []IndexConstituentRequest) ([]models.IndexConstituent, error) {
+	if method != models.IndexPriceWeighted && method != models.IndexEqualWeighted && method != models.IndexCustomWeighted {
+		return nil, errors.New("method must be PRICE_WEIGHTED, EQUAL_WEIGHTED or CUSTOM_WEIGHTED")
+	}
+	if len(reqs) == 0 {
+		return nil, errors.New("an index needs at least one constituent")
+	}
+
+	var totalWeight float64
+	seen := make(map[string]bool)
+	constituents := make([]models.IndexConstituent, 0, len(reqs))
+	for _, r := range reqs {
+		if seen[r.InstrumentID] {
+			return nil, fmt.Errorf("duplicate constituent: %s", r.InstrumentID)
+		}
+		seen[r.InstrumentID] = true
+
+		inst, err := s.instrumentRepo.FindByID(r.InstrumentID)
+		if err != nil {
+			return nil, fmt.Errorf("constituent %s: %w", r.InstrumentID, err)
+		}
+		if inst.Type == models.InstrumentTypeIndex {
+			return nil, fmt.Errorf("%s is an index and cannot be a constituent", inst.Symbol)
+		}
+		if method == models.IndexCustomWeighted && r.Weight <= 0 {
+			return nil, fmt.Errorf("%s needs a positive weight", inst.Symbol)
+		}
+
+		totalWeight += r.Weight
+		constituents = append(constituents, models.IndexConstituent{InstrumentID: inst.ID, Symbol: inst.Symbol, Weight: r.Weight})
+	}
+
+	if method == models.IndexCustomWeighted {
+		for i := range constituents {
+			constituents[i].Weight /= totalWeight
+		}
+	}
+	return constituents, nil
+}
+
+func (s *IndexService) priceMap(ctx context.Context) (map[primitive.ObjectID]float64, error) {
+	all, err := s.marketDataRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[primitive.ObjectID]float64, len(all))
+	for _, d := range all {
+		prices[d.InstrumentID] = d.LastPrice
+	}
+	return prices, nil
+}
+
+// indexLevel computes the current level; constituents without a price are held at their base price
+func indexLevel(index *models.BenchmarkIndex, prices map[primitive.ObjectID]float64) float64 {
+	if len(index.Constituents) == 0 {
+		return 0
+	}
+
+	price := func(c models.IndexConstituent) float64 {
+		if p, ok := prices[c.InstrumentID]; ok && p > 0 {
+			return p
+		}
+		return c.BasePrice
+	}
+
+	switch index.Method {
+	case models.IndexPriceWeighted:
+		if index.Divisor <= 0 {
+			return 0
+		}
+		var sum float64
+		for _, c := range index.Constituents {
+			sum += price(c)
+		}
+		return sum / index.Divisor
+	case models.IndexCustomWeighted:
+		var level float64
+		for _, c := range index.Constituents {
+			if c.BasePrice > 0 {
+				level += c.Weight * price(c) / c.BasePrice
+			}
+		}
+		return index.BaseValue * level
+	default:
+		var sum float64
+		var n int
+		for _, c := range index.Constituents {
+			if c.BasePrice > 0 {
+				sum += price(c) / c.BasePrice
+				n++
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		return index.BaseValue * sum / float64(n)
+	}
+}
+
+// rebaseIndex swaps in new constituents at the current level so the series has no jump
+func rebaseIndex(index *models.BenchmarkIndex, method string, constituents []models.IndexConstituent, prices map[primitive.ObjectID]float64) {
+	level := index.BaseValue
+	if len(index.Constituents) > 0 && index.BaseValue > 0 {
+		if current := indexLevel(index, prices); current > 0 {
+			level = current
+		}
+	}
+	if level <= 0 {
+		level = defaultIndexBase
+	}
+
+	setBasePrices(constituents, prices)
+
+	index.Method = method
+	index.Constituents = constituents
+	index.BaseValue = level
+	index.Divisor = 0
+	if method == models.IndexPriceWeighted {
+		index.Divisor = sumBasePrices(constituents) / level
+	}
+}
+
+func setBasePrices(constituents []models.IndexConstituent, prices map[primitive.ObjectID]float64) {
+	for i := range constituents {
+		constituents[i].BasePrice = prices[constituents[i].InstrumentID]
+		if constituents[i].BasePrice <= 0 {
+			constituents[i].BasePrice = 1 // Unpriced instruments join at par until they tick
+		}
+	}
+}
+
+func sumBasePrices(constituents []models.IndexConstituent) float64 {
+	var sum float64
+	for _, c := range constituents {
+		sum += c.BasePrice
+	}
+	return sum
+}
+
+func sameConstituents(a, b []models.IndexConstituent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := func(cs []models.IndexConstituent) []string {
+		out := make([]string, len(cs))
+		for i, c := range cs {
+			out[i] = c.InstrumentID.Hex()
+		}
+		sort.Strings(out)
+		return out
+	}
+	x, y := ids(a), ids(b)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDailyLevels returns index closes keyed by IST day for benchmark comparison
+func (s *IndexService) GetDailyLevels(ctx context.Context, index *models.BenchmarkIndex, from, to time.Time) ([]models.IndexValue, error) {
+	return s.indexRepo.GetDailyValues(ctx, index.ID, from, to)
+}
+
+// ResolveBenchmark finds an index by symbol, or the default benchmark if symbol is empty
+func (s *IndexService) ResolveBenchmark(ctx context.Context, symbol string) (*models.BenchmarkIndex, error) {
+	if symbol == "" {
+		return s.indexRepo.FindDefault(ctx)
+	}
+	return s.indexRepo.FindBySymbol(ctx, strings.ToUpper(symbol))
+}
@@ -22,7 +22,7 @@ func NewJITService(repo *repositories.JITRepository, auditService *AuditService)
 
 func (s *JITService) RequestAccess(ctx context.Context, makerID primitive.ObjectID, action string, resourceID primitive.ObjectID, amount float64, reason string, duration int) (*models.JITRequest, error) {
 	isDualAuth := false
-	if action == "HALT_MARKET" || action == "RESUME_MARKET" || action == "CONFIG_UPDATE" {
+	if action == "HALT_MARKET" || action == "RESUME_MARKET" || action == "CONFIG_UPDATE" || action == "CHARGE_SCHEDULE_UPDATE" || action == "RISK_PARAMS_UPDATE" {
 		isDualAuth = true
 	}
 	// Wallet adjustments always go through JIT but don't require dual-auth per user request
@@ -4,40 +4,57 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+)
+
+const (
+	liquidationTargetRatio = 0.8 // Liquidate until Equity / initial margin requirement recovers above this
+	maxLiquidationSteps    = 20  // Safety bound on orders per liquidation run
 )
 
 type MarginMonitorService struct {
 	accountRepo         *repositories.TradingAccountRepository
 	portfolioService    *PortfolioService
+	orderService        *OrderService
+	marketService       *MarketService
+	marginService       *MarginService
 	notificationService *NotificationService
+	auditService        *AuditService
 	stopChan            chan struct{}
-	// Hysteresis tracking to prevent false alerts
-	userMarginStatus map[string]*MarginStatus
-}
 
-// MarginStatus tracks alert state for hysteresis
-type MarginStatus struct {
-	LastRatio           float64
-	ConsecutiveWarnings int
-	LastAlertTime       time.Time
-	AlertLevel          string // "OK", "WARNING", "CRITICAL"
+	// Held for a whole pass so overlapping checks skip instead of racing on persisted hysteresis state
+	checkMu sync.Mutex
+
+	// Users with a liquidation in flight, so overlapping triggers don't double-close positions
+	liquidationMu sync.Mutex
+	liquidating   map[string]bool
 }
 
 func NewMarginMonitorService(
 	accountRepo *repositories.TradingAccountRepository,
 	portfolioService *PortfolioService,
+	orderService *OrderService,
+	marketService *MarketService,
+	marginService *MarginService,
 	notificationService *NotificationService,
+	auditService *AuditService,
 ) *MarginMonitorService {
 	return &MarginMonitorService{
 		accountRepo:         accountRepo,
 		portfolioService:    portfolioService,
+		orderService:        orderService,
+		marketService:       marketService,
+		marginService:       marginService,
 		notificationService: notificationService,
+		auditService:        auditService,
 		stopChan:            make(chan struct{}),
-		userMarginStatus:    make(map[string]*MarginStatus),
+		liquidating:         make(map[string]bool),
 	}
 }
 
@@ -67,6 +84,12 @@ func (s *MarginMonitorService) Stop() {
 
 // CheckMargins iterates active accounts and checks risk
 func (s *MarginMonitorService) CheckMargins() {
+	if !s.checkMu.TryLock() {
+		log.Println("[MarginMonitor] Previous check still running, skipping")
+		return
+	}
+	defer s.checkMu.Unlock()
+
 	// 1. Find accounts with active positions (Blocked Margin > 0)
 	accounts, err := s.accountRepo.FindAccountsWithPositions()
 	if err != nil {
@@ -81,121 +104,313 @@ func (s *MarginMonitorService) CheckMargins() {
 	log.Printf("[MarginMonitor] Checking margin for %d active accounts", len(accounts))
 
 	for _, account := range accounts {
-		userID := account.UserID.Hex()
+		if err := s.checkAccount(context.Background(), account); err != nil {
+			log.Printf("[MarginMonitor] Check failed for user %s: %v", account.UserID.Hex(), err)
+		}
+	}
+}
+
+// checkAccount marks one account to market and advances its persisted alert state
+func (s *MarginMonitorService) checkAccount(ctx context.Context, account *models.TradingAccount) error {
+	userID := account.UserID.Hex()
+	ctx = utils.WithAccountID(ctx, account.ID.Hex())
+
+	// 2. Re-mark shorts to the current price; this also values equity without persisting a snapshot
+	margin, err := s.marginService.MarkAccount(ctx, account)
+	if err != nil {
+		return fmt.Errorf("failed to mark to market: %w", err)
+	}
+	if margin.CallRaised {
+		s.notificationService.SendNotification(
+			ctx,
+			userID,
+			models.NotificationTypeAlert,
+			"Margin Call",
+			fmt.Sprintf("Your equity (₹%.2f) is below the margin requirement (₹%.2f). Add ₹%.2f or reduce positions.",
+				margin.Equity, margin.InitialRequired, margin.MarginCall.Shortfall),
+			map[string]interface{}{"level": "MARGIN_CALL", "equity": margin.Equity, "required": margin.InitialRequired, "shortfall": margin.MarginCall.Shortfall},
+			nil,
+		)
+	}
+
+	health, err := s.marginService.GetMarginHealth(ctx, account)
+	if err != nil {
+		return fmt.Errorf("failed to load margin health: %w", err)
+	}
+
+	equity := margin.Equity
+	blocked := margin.InitialRequired
+	health.Equity = equity
+	health.Required = blocked
+
+	if blocked == 0 {
+		// No open shorts left after marking
+		health.RecordRatio(0, time.Now())
+		health.AlertLevel = models.MarginAlertOK
+		health.ConsecutiveBreaches = 0
+		health.ConsecutiveCritical = 0
+		health.EscalationStage = models.EscalationNone
+		return s.marginService.SaveMarginHealth(ctx, health)
+	}
+
+	// 3. Risk Calculation
+	// Health = Equity / BlockedMargin
+	// Example:
+	// Short 1L. Cash 20k. Blocked 20k. Equity 20k.
+	// Price +10%. Value 1.1L.
+	// Equity = 20k + 1L - 1.1L = 10k.
+	// Blocked = 20k.
+	// Ratio = 10k / 20k = 0.5.
+	ratio := equity / blocked
+	health.RecordRatio(ratio, time.Now())
+
+	// HYSTERESIS LOGIC: Require sustained breach to prevent false alerts
+	const minCooldown = 5 * time.Minute
+	cooledDown := health.LastAlertAt == nil || time.Since(*health.LastAlertAt) > minCooldown
+
+	if ratio < 0.5 {
+		// CRITICAL threshold; earlier warning-band readings don't count towards confirmation
+		health.ConsecutiveBreaches++
+		health.ConsecutiveCritical++
+
+		// Only alert if:
+		// 1. We've seen 2+ consecutive critical readings, AND
+		// 2. It's been at least 5 minutes since last alert
+		if health.ConsecutiveCritical >= 2 && cooledDown {
+			log.Printf("[MarginMonitor] CRITICAL: User %s Ratio %.2f (sustained). Equity %.2f, Blocked %.2f",
+				userID, ratio, equity, blocked)
+
+			s.notificationService.SendNotification(
+				ctx,
+				userID,
+				models.NotificationTypeAlert,
+				"MARGIN CALL: CRITICAL",
+				fmt.Sprintf("Your account equity (₹%.2f) is critically low. Immediate funds required or positions will be auto-liquidated.", equity),
+				map[string]interface{}{"level": "CRITICAL", "equity": equity, "margin": blocked, "ratio": ratio},
+				nil,
+			)
+
+			now := time.Now()
+			health.LastAlertAt = &now
+			health.AlertLevel = models.MarginAlertCritical
+			if health.EscalationStage < models.EscalationCritical {
+				health.EscalationStage = models.EscalationCritical
+			}
+		} else if health.ConsecutiveCritical == 1 {
+			log.Printf("[MarginMonitor] CRITICAL threshold detected for user %s (1st warning, waiting for confirmation)", userID)
+		}
+
+		// Auto-liquidate only on a confirmed breach, so one bad or stale price cannot force a square-off
+		if health.ConsecutiveCritical >= 2 {
+			health.EscalationStage = models.EscalationLiquidation
+			// Persist first: liquidation re-marks the account and can take several fills
+			if err := s.marginService.SaveMarginHealth(ctx, health); err != nil {
+				return fmt.Errorf("failed to save margin health: %w", err)
+			}
+			if err := s.LiquidateUserPositions(ctx, userID, fmt.Sprintf("margin ratio %.2f", ratio)); err != nil {
+				log.Printf("[MarginMonitor] Auto-liquidation for user %s: %v", userID, err)
+			}
+			return nil
+		}
+
+	} else if ratio < 1.0 {
+		// WARNING threshold
+		health.ConsecutiveBreaches++
+		health.ConsecutiveCritical = 0
+
+		// Only alert if:
+		// 1. We've seen 2+ consecutive warnings, AND
+		// 2. It's been at least 5 minutes since last alert, AND
+		// 3. Not already in CRITICAL state
+		if health.ConsecutiveBreaches >= 2 && cooledDown && health.AlertLevel != models.MarginAlertCritical {
+			log.Printf("[MarginMonitor] WARNING: User %s Ratio %.2f (sustained)", userID, ratio)
+
+			s.notificationService.SendNotification(
+				ctx,
+				userID,
+				models.NotificationTypeAlert,
+				"Margin Warning",
+				fmt.Sprintf("Your equity (₹%.2f) has dropped below the blocked margin requirement. Please add funds.", equity),
+				map[string]interface{}{"level": "WARNING", "equity": equity, "margin": blocked, "ratio": ratio},
+				nil,
+			)
+
+			now := time.Now()
+			health.LastAlertAt = &now
+			health.AlertLevel = models.MarginAlertWarning
+			if health.EscalationStage < models.EscalationWarned {
+				health.EscalationStage = models.EscalationWarned
+			}
+		} else if health.ConsecutiveBreaches == 1 {
+			log.Printf("[MarginMonitor] WARNING threshold detected for user %s (1st warning, waiting for confirmation)", userID)
+		}
+	} else {
+		// Ratio is healthy - reset warnings
+		if health.ConsecutiveBreaches > 0 {
+			log.Printf("[MarginMonitor] User %s margin recovered. Ratio %.2f. Resetting warnings.", userID, ratio)
+		}
+		health.ConsecutiveBreaches = 0
+		health.ConsecutiveCritical = 0
+		health.AlertLevel = models.MarginAlertOK
+		health.EscalationStage = models.EscalationNone
+	}
 
-		// 2. Calculate Real-Time Equity
-		// We use CaptureSnapshot logic but might not want to save it every time?
-		// For now, saving it is fine (audit trail).
-		snapshot, err := s.portfolioService.CaptureSnapshot(context.Background(), userID)
+	return s.marginService.SaveMarginHealth(ctx, health)
+}
+
+// LiquidateUserPositions squares off positions in priority order until the margin ratio recovers.
+// Priority 1: shorts whose unrealized loss exceeds 50% of their blocked margin (largest loss first).
+// Priority 2: the position with the largest unrealized loss, then the largest remaining short.
+// Only the trading account the context is scoped to is liquidated.
+func (s *MarginMonitorService) LiquidateUserPositions(ctx context.Context, userID string, trigger string) error {
+	key := userID + "/" + utils.AccountIDFromContext(ctx)
+	s.liquidationMu.Lock()
+	if s.liquidating[key] {
+		s.liquidationMu.Unlock()
+		return nil
+	}
+	s.liquidating[key] = true
+	s.liquidationMu.Unlock()
+
+	defer func() {
+		s.liquidationMu.Lock()
+		delete(s.liquidating, key)
+		s.liquidationMu.Unlock()
+	}()
+
+	isOpen, _, err := s.marketService.IsMarketOpen(snapshotExchange)
+	if err != nil {
+		return fmt.Errorf("failed to check market hours: %w", err)
+	}
+	if !isOpen {
+		log.Printf("[MarginMonitor] Liquidation for user %s deferred: market closed (%s)", userID, trigger)
+		return nil
+	}
+
+	log.Printf("[MarginMonitor] AUTO-LIQUIDATION started for user %s (%s)", userID, trigger)
+	s.auditService.Log(userID, "System", "SYSTEM", "AUTO_LIQUIDATION_STARTED", userID, "TRADING_ACCOUNT",
+		fmt.Sprintf("Auto-liquidation triggered: %s", trigger), nil, nil)
+
+	var ratio float64
+	liquidated := 0
+	for step := 0; step < maxLiquidationSteps; step++ {
+		account, err := s.accountRepo.FindByUserID(ctx, userID)
+		if err != nil || account == nil {
+			return fmt.Errorf("failed to load account: %v", err)
+		}
+
+		// Same equity / initial requirement the monitor decided on, so the run stops where the breach ends
+		margin, _, err := s.marginService.evaluate(ctx, account)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate margin: %w", err)
+		}
+		if margin.InitialRequired <= 0 {
+			break // No short exposure left
+		}
+		ratio = margin.Equity / margin.InitialRequired
+		if ratio > liquidationTargetRatio {
+			break
+		}
+
+		// The snapshot only ranks positions by unrealized loss
+		snapshot, holdings, err := s.portfolioService.buildSnapshot(ctx, userID, false)
 		if err != nil {
-			log.Printf("[MarginMonitor] Failed to snapshot user %s: %v", userID, err)
-			continue
+			return fmt.Errorf("failed to value portfolio: %w", err)
 		}
 
-		equity := snapshot.TotalEquity
-		blocked := account.BlockedMargin
+		target := selectLiquidationTarget(holdings, snapshot.Holdings)
+		if target == nil {
+			break
+		}
 
-		if blocked == 0 {
-			continue // Should not happen given query, but safe check
+		reason := fmt.Sprintf("margin ratio %.2f below critical threshold", ratio)
+		order, err := s.orderService.ForceExecuteLiquidation(ctx, target, reason)
+		if err != nil {
+			return fmt.Errorf("failed to liquidate %s: %w", target.Symbol, err)
 		}
 
-		// 3. Risk Calculation
-		// Health = Equity / BlockedMargin
-		// Standard: Blocked Margin is ~20%.
-		// If Health < 1.0, it means Equity < 20% of Exposure. (Maintenance Level?)
-		// Actually, if Initial Margin is 20%. And Equity drops BELOW it.
-		// That means you have LOST money.
+		liquidated++
+		log.Printf("[MarginMonitor] LIQUIDATED %s %d %s for user %s (ratio %.2f)", order.Side, order.Quantity, order.Symbol, userID, ratio)
+		s.auditService.Log(userID, "System", "SYSTEM", "AUTO_LIQUIDATION_EXECUTED", order.ID.Hex(), "ORDER",
+			fmt.Sprintf("Liquidated %d %s (%s) at margin ratio %.2f", order.Quantity, order.Symbol, target.PositionType, ratio), nil, order)
 
-		// Example:
-		// Short 1L. Cash 20k. Blocked 20k. Equity 20k.
-		// Price +10%. Value 1.1L.
-		// Equity = 20k + 1L - 1.1L = 10k.
-		// Blocked = 20k.
-		// Ratio = 10k / 20k = 0.5.
+		s.notificationService.SendNotification(
+			context.Background(),
+			userID,
+			models.NotificationTypeAlert,
+			"CRITICAL: Urgent Auto-Liquidation Executed",
+			fmt.Sprintf("Your %s position of %d %s was liquidated at market because your margin ratio fell to %.2f.",
+				target.PositionType, order.Quantity, order.Symbol, ratio),
+			map[string]interface{}{"level": "LIQUIDATION", "symbol": order.Symbol, "quantity": order.Quantity, "ratio": ratio, "orderId": order.ID.Hex()},
+			nil,
+		)
+	}
 
-		ratio := equity / blocked
+	s.auditService.Log(userID, "System", "SYSTEM", "AUTO_LIQUIDATION_COMPLETED", userID, "TRADING_ACCOUNT",
+		fmt.Sprintf("Auto-liquidation finished at margin ratio %.2f", ratio), nil, nil)
 
-		// Get or create margin status for this user
-		status, exists := s.userMarginStatus[userID]
-		if !exists {
-			status = &MarginStatus{
-				LastRatio:           ratio,
-				ConsecutiveWarnings: 0,
-				LastAlertTime:       time.Time{},
-				AlertLevel:          "OK",
+	// The open margin call is closed by liquidation; a re-mark raises a fresh one if a shortfall remains
+	if liquidated > 0 {
+		if account, err := s.accountRepo.FindByUserID(ctx, userID); err == nil && account != nil {
+			if err := s.marginService.ResolveMarginCall(ctx, account.ID, models.MarginCallLiquidated); err != nil {
+				log.Printf("[MarginMonitor] Failed to resolve margin call for user %s: %v", userID, err)
 			}
-			s.userMarginStatus[userID] = status
-		}
-
-		// Update last ratio
-		status.LastRatio = ratio
-
-		// HYSTERESIS LOGIC: Require sustained breach to prevent false alerts
-		const minCooldown = 5 * time.Minute
-		timeSinceLastAlert := time.Since(status.LastAlertTime)
-
-		if ratio < 0.5 {
-			// CRITICAL threshold
-			status.ConsecutiveWarnings++
-
-			// Only alert if:
-			// 1. We've seen 2+ consecutive critical readings, AND
-			// 2. It's been at least 5 minutes since last alert
-			if status.ConsecutiveWarnings >= 2 && timeSinceLastAlert > minCooldown {
-				log.Printf("[MarginMonitor] CRITICAL: User %s Ratio %.2f (sustained). Equity %.2f, Blocked %.2f",
-					userID, ratio, equity, blocked)
-
-				s.notificationService.SendNotification(
-					context.Background(),
-					userID,
-					models.NotificationTypeAlert,
-					"MARGIN CALL: CRITICAL",
-					fmt.Sprintf("Your account equity (₹%.2f) is critically low. Immediate funds required or positions will be auto-liquidated.", equity),
-					map[string]interface{}{"level": "CRITICAL", "equity": equity, "margin": blocked, "ratio": ratio},
-					nil,
-				)
-
-				status.LastAlertTime = time.Now()
-				status.AlertLevel = "CRITICAL"
-			} else if status.ConsecutiveWarnings == 1 {
-				log.Printf("[MarginMonitor] CRITICAL threshold detected for user %s (1st warning, waiting for confirmation)", userID)
+			if _, err := s.marginService.MarkAccount(ctx, account); err != nil {
+				log.Printf("[MarginMonitor] Failed to re-mark user %s after liquidation: %v", userID, err)
 			}
+		}
+	}
 
-			// TODO: Auto-Liquidate (Future Phase)
-
-		} else if ratio < 1.0 {
-			// WARNING threshold
-			status.ConsecutiveWarnings++
-
-			// Only alert if:
-			// 1. We've seen 2+ consecutive warnings, AND
-			// 2. It's been at least 5 minutes since last alert, AND
-			// 3. Not already in CRITICAL state
-			if status.ConsecutiveWarnings >= 2 && timeSinceLastAlert > minCooldown && status.AlertLevel != "CRITICAL" {
-				log.Printf("[MarginMonitor] WARNING: User %s Ratio %.2f (sustained)", userID, ratio)
-
-				s.notificationService.SendNotification(
-					context.Background(),
-					userID,
-					models.NotificationTypeAlert,
-					"Margin Warning",
-					fmt.Sprintf("Your equity (₹%.2f) has dropped below the blocked margin requirement. Please add funds.", equity),
-					map[string]interface{}{"level": "WARNING", "equity": equity, "margin": blocked, "ratio": ratio},
-					nil,
-				)
-
-				status.LastAlertTime = time.Now()
-				status.AlertLevel = "WARNING"
-			} else if status.ConsecutiveWarnings == 1 {
-				log.Printf("[MarginMonitor] WARNING threshold detected for user %s (1st warning, waiting for confirmation)", userID)
-			}
-		} else {
-			// Ratio is healthy - reset warnings
-			if status.ConsecutiveWarnings > 0 {
-				log.Printf("[MarginMonitor] User %s margin recovered. Ratio %.2f. Resetting warnings.", userID, ratio)
-			}
-			status.ConsecutiveWarnings = 0
-			status.AlertLevel = "OK"
+	return nil
+}
+
+// selectLiquidationTarget picks the next open position to square off. Shorts go first, since only
+// covering them lowers the short margin requirement; longs are sold once no shorts remain.
+func selectLiquidationTarget(holdings []models.Holding, valued []models.SnapshotHolding) *models.Holding {
+	pnl := make(map[string]float64, len(valued))
+	for _, v := range valued {
+		pnl[v.InstrumentID.Hex()] = v.UnrealizedPL
+	}
+
+	var shorts, longs []*models.Holding
+	for i := range holdings {
+		switch {
+		case holdings[i].Quantity <= 0:
+		case holdings[i].PositionType == models.PositionShort:
+			shorts = append(shorts, &holdings[i])
+		default:
+			longs = append(longs, &holdings[i])
+		}
+	}
+	open := shorts
+	if len(open) == 0 {
+		open = longs
+	}
+	if len(open) == 0 {
+		return nil
+	}
+
+	loss := func(h *models.Holding) float64 { return -pnl[h.InstrumentID.Hex()] }
+	sort.SliceStable(open, func(i, j int) bool { return loss(open[i]) > loss(open[j]) })
+
+	// Priority 1: shorts losing more than half their blocked margin
+	for _, h := range open {
+		if h.PositionType == models.PositionShort && loss(h) > 0.5*h.BlockedMargin {
+			return h
+		}
+	}
+
+	// Priority 2: largest unrealized loss
+	if loss(open[0]) > 0 {
+		return open[0]
+	}
+
+	// Nothing is losing: release the most margin
+	largest := open[0]
+	for _, h := range open[1:] {
+		if h.BlockedMargin > largest.BlockedMargin {
+			largest = h
 		}
 	}
+	return largest
 }
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Platform defaults for instruments without an override (20% initial, 15% maintenance, 5x leverage)
+const (
+	defaultVaRRate         = 0.15
+	defaultELMRate         = 0.05
+	defaultMaintenanceRate = 0.15
+	defaultMaxLeverage     = 5.0
+	defaultMISMarginFactor = 0.5 // Intraday positions need half the delivery margin
+)
+
+// MarginService owns per-instrument risk parameters and marks short positions to market
+type MarginService struct {
+	riskParamsRepo *repositories.RiskParamsRepository
+	marginCallRepo *repositories.MarginCallRepository
+	healthRepo     *repositories.MarginHealthRepository
+	portfolioRepo  *repositories.PortfolioRepository
+	accountRepo    *repositories.TradingAccountRepository
+	marketDataRepo *repositories.MarketDataRepository
+	instrumentRepo *repositories.InstrumentRepository
+	auditService   *AuditService
+}
+
+func NewMarginService(
+	riskParamsRepo *repositories.RiskParamsRepository,
+	marginCallRepo *repositories.MarginCallRepository,
+	healthRepo *repositories.MarginHealthRepository,
+	portfolioRepo *repositories.PortfolioRepository,
+	accountRepo *repositories.TradingAccountRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	auditService *AuditService,
+) *MarginService {
+	return &MarginService{
+		riskParamsRepo: riskParamsRepo,
+		marginCallRepo: marginCallRepo,
+		healthRepo:     healthRepo,
+		portfolioRepo:  portfolioRepo,
+		accountRepo:    accountRepo,
+		marketDataRepo: marketDataRepo,
+		instrumentRepo: instrumentRepo,
+		auditService:   auditService,
+	}
+}
+
+// PositionMargin is the margin requirement of one short position at the mark price
+type PositionMargin struct {
+	InstrumentID      primitive.ObjectID  `json:"instrumentId"`
+	Symbol            string              `json:"symbol"`
+	ProductType       string              `json:"productType"`
+	Quantity          int                 `json:"quantity"`
+	MarkPrice         float64             `json:"markPrice"`
+	PositionValue     float64             `json:"positionValue"`
+	InitialMargin     float64             `json:"initialMargin"`
+	MaintenanceMargin float64             `json:"maintenanceMargin"`
+	Status            models.MarginStatus `json:"status"`
+}
+
+// AccountMargin summarises an account's requirements against its equity
+type AccountMargin struct {
+	AccountID           primitive.ObjectID        `json:"accountId"`
+	Equity              float64                   `json:"equity"`
+	CashBalance         float64                   `json:"cashBalance"`
+	InitialRequired     float64                   `json:"initialRequired"`
+	MaintenanceRequired float64                   `json:"maintenanceRequired"`
+	FreeMargin          float64                   `json:"freeMargin"` // Equity - initial requirement
+	Status              models.MarginStatus       `json:"status"`
+	Positions           []PositionMargin          `json:"positions"`
+	MarginCall          *models.AccountMarginCall `json:"marginCall,omitempty"`
+
+	CallRaised bool `json:"-"` // A new margin call was raised by this mark
+}
+
+// DefaultRiskParams returns the platform-wide rates
+func DefaultRiskParams() models.InstrumentRiskParams {
+	return models.InstrumentRiskParams{
+		VaRRate:         defaultVaRRate,
+		ELMRate:         defaultELMRate,
+		MaintenanceRate: defaultMaintenanceRate,
+		MaxLeverage:     defaultMaxLeverage,
+		MISMarginFactor: defaultMISMarginFactor,
+	}
+}
+
+// GetRiskParams returns the instrument's override, or the platform defaults
+func (s *MarginService) GetRiskParams(ctx context.Context, instrumentID primitive.ObjectID) (models.InstrumentRiskParams, error) {
+	params, err := s.riskParamsRepo.FindByInstrumentID(ctx, instrumentID)
+	if err != nil {
+		return models.InstrumentRiskParams{}, err
+	}
+	if params == nil {
+		defaults := DefaultRiskParams()
+		defaults.InstrumentID = instrumentID
+		return defaults, nil
+	}
+	if params.MISMarginFactor == 0 {
+		params.MISMarginFactor = defaultMISMarginFactor // Overrides saved before MIS existed
+	}
+	return *params, nil
+}
+
+func (s *MarginService) ListRiskParams(ctx context.Context) ([]models.InstrumentRiskParams, error) {
+	return s.riskParamsRepo.FindAll(ctx)
+}
+
+// UpdateRiskParams validates and stores an instrument override; new rates apply from the next mark
+func (s *MarginService) UpdateRiskParams(ctx context.Context, instrumentID string, params models.InstrumentRiskParams, adminID string) (*models.InstrumentRiskParams, error) {
+	instrument, err := s.instrumentRepo.FindByID(instrumentID)
+	if err != nil || instrument == nil {
+		return nil, errors.New("instrument not found")
+	}
+
+	if params.VaRRate < 0 || params.ELMRate < 0 || params.InitialRate() <= 0 || params.InitialRate() > 1 {
+		return nil, errors.New("VaR and ELM rates must be non-negative and sum to between 0 and 1")
+	}
+	if params.MaintenanceRate <= 0 || params.MaintenanceRate > params.InitialRate() {
+		return nil, errors.New("maintenance rate must be positive and no higher than the initial rate")
+	}
+	if params.MaxLeverage < 1 {
+		return nil, errors.New("max leverage must be at least 1")
+	}
+	if params.MISMarginFactor == 0 {
+		params.MISMarginFactor = defaultMISMarginFactor
+	}
+	if params.MISMarginFactor < 0 || params.MISMarginFactor > 1 {
+		return nil, errors.New("MIS margin factor must be between 0 and 1")
+	}
+
+	old, _ := s.GetRiskParams(ctx, instrument.ID)
+
+	params.InstrumentID = instrument.ID
+	params.Symbol = instrument.Symbol
+	params.UpdatedBy, _ = primitive.ObjectIDFromHex(adminID)
+	if err := s.riskParamsRepo.Upsert(ctx, &params); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "RISK_PARAMS_UPDATED", instrument.ID.Hex(), "INSTRUMENT",
+		fmt.Sprintf("%s margin set to VaR %.2f%% + ELM %.2f%%, maintenance %.2f%%, leverage %.1fx, MIS factor %.2f",
+			instrument.Symbol, params.VaRRate*100, params.ELMRate*100, params.MaintenanceRate*100, params.MaxLeverage, params.MISMarginFactor),
+		old, params)
+	return &params, nil
+}
+
+// GetAccountMargin values the user's margin position without persisting anything
+func (s *MarginService) GetAccountMargin(ctx context.Context, userID string) (*AccountMargin, error) {
+	account, err := s.accountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	margin, _, err := s.evaluate(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	margin.MarginCall, _ = s.marginCallRepo.FindOpenByAccount(ctx, account.ID)
+	return margin, nil
+}
+
+// MarkAccount re-marks every short to the current price, resizes BlockedMargin up or down,
+// and raises or resolves the account's margin call
+func (s *MarginService) MarkAccount(ctx context.Context, account *models.TradingAccount) (*AccountMargin, error) {
+	margin, holdingIDs, err := s.evaluate(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range margin.Positions {
+		if err := s.portfolioRepo.MarkHolding(ctx, holdingIDs[i], p.MarkPrice, p.InitialMargin, p.MaintenanceMargin, p.Status); err != nil {
+			return nil, fmt.Errorf("failed to mark %s: %w", p.Symbol, err)
+		}
+	}
+
+	if math.Abs(account.BlockedMargin-margin.InitialRequired) > 0.01 {
+		if err := s.accountRepo.SetBlockedMargin(ctx, account.ID, margin.InitialRequired); err != nil {
+			return nil, fmt.Errorf("failed to update blocked margin: %w", err)
+		}
+		account.BlockedMargin = margin.InitialRequired
+	}
+
+	open, err := s.marginCallRepo.FindOpenByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case margin.FreeMargin < 0 && open == nil:
+		call := &models.AccountMarginCall{
+			UserID:    account.UserID,
+			AccountID: account.ID,
+			Status:    models.MarginCallOpen,
+			Equity:    margin.Equity,
+			Required:  margin.InitialRequired,
+			Shortfall: -margin.FreeMargin,
+			RaisedAt:  time.Now(),
+		}
+		if err := s.marginCallRepo.Create(ctx, call); err != nil {
+			return nil, err
+		}
+		s.auditService.Log(account.UserID.Hex(), "System", "SYSTEM", "MARGIN_CALL_RAISED", call.ID.Hex(), "MARGIN_CALL",
+			fmt.Sprintf("Margin call: equity ₹%.2f vs requirement ₹%.2f (shortfall ₹%.2f)", call.Equity, call.Required, call.Shortfall), nil, call)
+		margin.MarginCall = call
+		margin.CallRaised = true
+	case margin.FreeMargin >= 0 && open != nil:
+		if err := s.ResolveMarginCall(ctx, account.ID, models.MarginCallMet); err != nil {
+			return nil, err
+		}
+	default:
+		margin.MarginCall = open
+	}
+
+	return margin, nil
+}
+
+// ResolveMarginCall closes the account's open margin call, if any
+func (s *MarginService) ResolveMarginCall(ctx context.Context, accountID primitive.ObjectID, status string) error {
+	open, err := s.marginCallRepo.FindOpenByAccount(ctx, accountID)
+	if err != nil || open == nil {
+		return err
+	}
+	if err := s.marginCallRepo.Resolve(ctx, open.ID, status); err != nil {
+		return err
+	}
+	s.auditService.Log(open.UserID.Hex(), "System", "SYSTEM", "MARGIN_CALL_RESOLVED", open.ID.Hex(), "MARGIN_CALL",
+		fmt.Sprintf("Margin call %s", status), open, nil)
+	return nil
+}
+
+func (s *MarginService) GetMarginCalls(ctx context.Context, userID string) ([]models.AccountMarginCall, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.marginCallRepo.FindByUserID(ctx, uid, 50)
+}
+
+// GetMarginHealth loads the monitor state for an account, starting a fresh record if none exists
+func (s *MarginService) GetMarginHealth(ctx context.Context, account *models.TradingAccount) (*models.MarginHealth, error) {
+	health, err := s.healthRepo.FindByAccountID(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if health == nil {
+		health = &models.MarginHealth{
+			AccountID:    account.ID,
+			UserID:       account.UserID,
+			AlertLevel:   models.MarginAlertOK,
+			RatioHistory: make([]models.MarginRatioPoint, 0),
+		}
+	}
+	return health, nil
+}
+
+func (s *MarginService) SaveMarginHealth(ctx context.Context, health *models.MarginHealth) error {
+	return s.healthRepo.Upsert(ctx, health)
+}
+
+// ListMarginHealth returns every monitored account, most at-risk first; accounts without exposure sort last
+func (s *MarginService) ListMarginHealth(ctx context.Context) ([]models.MarginHealth, error) {
+	health, err := s.healthRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := map[string]int{models.MarginAlertCritical: 0, models.MarginAlertWarning: 1, models.MarginAlertOK: 2}
+	sort.SliceStable(health, func(i, j int) bool {
+		a, b := health[i], health[j]
+		if (a.Required > 0) != (b.Required > 0) {
+			return a.Required > 0
+		}
+		if severity[a.AlertLevel] != severity[b.AlertLevel] {
+			return severity[a.AlertLevel] < severity[b.AlertLevel]
+		}
+		return a.Ratio < b.Ratio
+	})
+	return health, nil
+}
+
+// evaluate prices all holdings at the last traded price and sizes short requirements.
+// The returned holding IDs are aligned with Positions.
+func (s *MarginService) evaluate(ctx context.Context, account *models.TradingAccount) (*AccountMargin, []primitive.ObjectID, error) {
+	holdings, err := s.portfolioRepo.GetHoldings(utils.WithAccountID(ctx, account.ID.Hex()), account.UserID.Hex())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+
+	margin := &AccountMargin{
+		AccountID:   account.ID,
+		CashBalance: account.Balance,
+		Equity:      account.Balance,
+		Positions:   make([]PositionMargin, 0),
+	}
+	var holdingIDs []primitive.ObjectID
+
+	for _, h := range holdings {
+		if h.Quantity <= 0 {
+			continue
+		}
+
+		price := h.AvgEntryPrice
+		if data, err := s.marketDataRepo.FindByInstrumentID(ctx, h.InstrumentID.Hex()); err == nil && data != nil && data.LastPrice > 0 {
+			price = data.LastPrice
+		}
+		value := float64(h.Quantity) * price
+
+		if h.PositionType != models.PositionShort {
+			margin.Equity += value
+			continue
+		}
+		margin.Equity -= value
+
+		params, err := s.GetRiskParams(ctx, h.InstrumentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		position := PositionMargin{
+			InstrumentID:      h.InstrumentID,
+			Symbol:            h.Symbol,
+			ProductType:       productTypeOf(h.ProductType),
+			Quantity:          h.Quantity,
+			MarkPrice:         price,
+			PositionValue:     value,
+			InitialMargin:     value * params.InitialRateFor(h.ProductType),
+			MaintenanceMargin: value * params.MaintenanceRateFor(h.ProductType),
+		}
+		margin.InitialRequired += position.InitialMargin
+		margin.MaintenanceRequired += position.MaintenanceMargin
+		margin.Positions = append(margin.Positions, position)
+		holdingIDs = append(holdingIDs, h.ID)
+	}
+
+	margin.FreeMargin = margin.Equity - margin.InitialRequired
+	switch {
+	case margin.Equity < margin.MaintenanceRequired:
+		margin.Status = models.MarginCritical
+	case margin.FreeMargin < 0:
+		margin.Status = models.MarginCall
+	default:
+		margin.Status = models.MarginOK
+	}
+	for i := range margin.Positions {
+		margin.Positions[i].Status = margin.Status
+	}
+
+	return margin, holdingIDs, nil
+}
+
+// productTypeOf treats positions opened before product types existed as delivery
+func productTypeOf(productType string) string {
+	if productType == "" {
+		return models.ProductTypeCNC
+	}
+	return productType
+}
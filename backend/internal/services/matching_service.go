@@ -9,7 +9,9 @@ import (
 	"aequitas/internal/config"
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -22,6 +24,8 @@ type MatchingService struct {
 	portfolioService    *PortfolioService
 	notificationService *NotificationService
 	auditService        *AuditService
+	chargeService       *ChargeService
+	borrowService       *BorrowService
 	stopChan            chan struct{}
 }
 
@@ -34,6 +38,8 @@ func NewMatchingService(
 	portfolioService *PortfolioService,
 	notificationService *NotificationService,
 	auditService *AuditService,
+	chargeService *ChargeService,
+	borrowService *BorrowService,
 ) *MatchingService {
 	return &MatchingService{
 		config:              cfg,
@@ -44,6 +50,8 @@ func NewMatchingService(
 		portfolioService:    portfolioService,
 		notificationService: notificationService,
 		auditService:        auditService,
+		chargeService:       chargeService,
+		borrowService:       borrowService,
 		stopChan:            make(chan struct{}),
 	}
 }
@@ -55,13 +63,14 @@ func (s *MatchingService) Start() {
 			select {
 			case <-ticker.C:
 				s.MatchLimitOrders(context.Background())
+				s.MatchMultiLegOrders(context.Background())
 			case <-s.stopChan:
 				ticker.Stop()
 				return
 			}
 		}
 	}()
-	log.Println("Matching engine started (polling limit orders 3s)")
+	log.Println("Matching engine started (polling limit and multi-leg orders 3s)")
 }
 
 func (s *MatchingService) Stop() {
@@ -70,6 +79,7 @@ func (s *MatchingService) Stop() {
 
 // ExecuteMarketOrder performs an immediate fill for an order at current LTP
 func (s *MatchingService) ExecuteMarketOrder(ctx context.Context, order *models.Order) (*models.Trade, error) {
+	ctx = utils.WithAccountID(ctx, order.AccountID.Hex()) // Settle against the account that placed the order
 	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, order.InstrumentID.Hex())
 	if err != nil || marketData == nil {
 		return nil, fmt.Errorf("matching engine: market data unavailable for %s", order.Symbol)
@@ -84,46 +94,25 @@ func (s *MatchingService) ExecuteMarketOrder(ctx context.Context, order *models.
 	}
 	defer session.EndSession(ctx)
 
-	var trade *models.Trade
+	var trades []*models.Trade
+	snapshot := *order // fillOrder updates the order in place; restore it on retry or abort
 	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// 2. Create trade record
-		t, err := s.createTrade(sessCtx, order, executionPrice)
+		*order = snapshot
+		// 2-5. Trades, order status, settlement, holdings and borrows for every fill slice
+		t, err := s.fillOrder(sessCtx, order, executionPrice)
 		if err != nil {
 			return nil, err
 		}
-		trade = t
-
-		// 3. Update order status
-		order.Status = "FILLED"
-		order.FilledQuantity = order.Quantity
-		order.AvgFillPrice = executionPrice
-		now := time.Now()
-		order.FilledAt = &now
-
-		_, err = s.orderRepo.Update(sessCtx, order)
-		if err != nil {
-			return nil, err
-		}
-
-		// 4. Update Finance (Settlement)
-		err = s.accountService.SettleTrade(sessCtx, order.UserID.Hex(), trade.NetValue, trade.TradeID, trade.Side)
-		if err != nil {
-			return nil, err
-		}
-
-		// 5. Update Portfolio (Holdings)
-		err = s.portfolioService.UpdatePosition(sessCtx, trade)
-		if err != nil {
-			return nil, err
-		}
-
+		trades = t
 		return nil, nil
 	})
 
 	if err != nil {
+		*order = snapshot
 		log.Printf("ERROR: Market Order %s failed within transaction: %v", order.OrderID, err)
 		return nil, err
 	}
+	s.portfolioService.ProcessTradeAnalytics(trades)
 
 	// 6. Audit Log
 	s.auditService.Log(order.UserID.Hex(), "System", "SYSTEM", "ORDER_FILLED",
@@ -145,7 +134,7 @@ func (s *MatchingService) ExecuteMarketOrder(ctx context.Context, order *models.
 	}()
 
 	log.Printf("MATCHED: Market Order %s FILLED at ₹%.2f (Qty: %d)", order.OrderID, executionPrice, order.Quantity)
-	return trade, nil
+	return trades[len(trades)-1], nil
 }
 
 // MatchLimitOrders scans for NEW limit orders and matches them against current LTP
@@ -195,34 +184,16 @@ func (s *MatchingService) MatchLimitOrders(ctx context.Context) {
 				continue
 			}
 
-			_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-				trade, err := s.createTrade(sessCtx, order, fillPrice)
-				if err != nil {
-					return nil, err
-				}
-
-				order.Status = "FILLED"
-				order.FilledQuantity = order.Quantity
-				order.AvgFillPrice = fillPrice
-				now := time.Now()
-				order.FilledAt = &now
-
-				_, err = s.orderRepo.Update(sessCtx, order)
+			var trades []*models.Trade
+			orderCtx := utils.WithAccountID(ctx, order.AccountID.Hex())
+			snapshot := *order // fillOrder updates the order in place; restore it on retry or abort
+			_, err = session.WithTransaction(orderCtx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+				*order = snapshot
+				t, err := s.fillOrder(sessCtx, order, fillPrice)
 				if err != nil {
 					return nil, err
 				}
-
-				// Update Finance (Settlement)
-				settleErr := s.accountService.SettleTrade(sessCtx, order.UserID.Hex(), trade.NetValue, trade.TradeID, trade.Side)
-				if settleErr != nil {
-					return nil, settleErr
-				}
-
-				// Update Portfolio (Holdings)
-				portfolioErr := s.portfolioService.UpdatePosition(sessCtx, trade)
-				if portfolioErr != nil {
-					return nil, portfolioErr
-				}
+				trades = t
 
 				// All good
 				return nil, nil
@@ -231,14 +202,29 @@ func (s *MatchingService) MatchLimitOrders(ctx context.Context) {
 			session.EndSession(ctx)
 
 			if err != nil {
+				*order = snapshot
 				log.Printf("ERROR: Limit Order %s failed within transaction: %v", order.OrderID, err)
 				continue
 			}
+			s.portfolioService.ProcessTradeAnalytics(trades)
+
+			filled := 0
+			for _, t := range trades {
+				filled += t.Quantity
+			}
+			action, title := "ORDER_FILLED", "Order Filled"
+			message := fmt.Sprintf("Your LIMIT %s order for %d %s was filled at ₹%.2f", order.Side, order.Quantity, order.Symbol, fillPrice)
+			if order.Status != "FILLED" {
+				// An iceberg slice filled; the rest of the order keeps working
+				action, title = "ORDER_PARTIALLY_FILLED", "Order Partially Filled"
+				message = fmt.Sprintf("%d of your LIMIT %s order for %d %s was filled at ₹%.2f (%d remaining)",
+					filled, order.Side, order.Quantity, order.Symbol, fillPrice, order.RemainingQuantity())
+			}
 
 			// 6. Audit Log
-			s.auditService.Log(order.UserID.Hex(), "System", "SYSTEM", "ORDER_FILLED",
+			s.auditService.Log(order.UserID.Hex(), "System", "SYSTEM", action,
 				order.ID.Hex(), "ORDER",
-				fmt.Sprintf("FILL %d %s @ ₹%.2f (Limit)", order.Quantity, order.Symbol, fillPrice),
+				fmt.Sprintf("FILL %d %s @ ₹%.2f (Limit)", filled, order.Symbol, fillPrice),
 				nil, order)
 
 			// 7. Send Notification (outside transaction)
@@ -248,14 +234,14 @@ func (s *MatchingService) MatchLimitOrders(ctx context.Context) {
 					context.Background(),
 					orderToNotify.UserID.Hex(),
 					models.NotificationTypeOrder,
-					"Order Filled",
-					fmt.Sprintf("Your LIMIT %s order for %d %s was filled at ₹%.2f", orderToNotify.Side, orderToNotify.Quantity, orderToNotify.Symbol, fillPrice),
+					title,
+					message,
 					map[string]interface{}{"orderId": orderToNotify.ID.Hex(), "symbol": orderToNotify.Symbol},
 					nil,
 				)
 			}()
 
-			log.Printf("MATCHED: Limit Order %s FILLED at ₹%.2f (Target: ₹%.2f, Qty: %d)", order.OrderID, fillPrice, *order.Price, order.Quantity)
+			log.Printf("MATCHED: Limit Order %s %s at ₹%.2f (Target: ₹%.2f, Qty: %d/%d)", order.OrderID, order.Status, fillPrice, *order.Price, order.FilledQuantity, order.Quantity)
 		} else {
 			// Order NOT matched in this cycle
 			if order.Validity == "IOC" {
@@ -263,7 +249,11 @@ func (s *MatchingService) MatchLimitOrders(ctx context.Context) {
 				log.Printf("IOC Order %s not filled immediately, CANCELLING", order.OrderID)
 
 				order.Status = "CANCELLED"
+				order.StatusReason = models.OrderReasonIOCUnfilled
 				_, _ = s.orderRepo.Update(ctx, order)
+				if err := s.borrowService.ReleaseOrderLocate(ctx, order.ID); err != nil {
+					log.Printf("Failed to release locate for IOC order %s: %v", order.OrderID, err)
+				}
 
 				// Send Cancellation Notification
 				orderToCancel := order // Capture for goroutine
@@ -283,45 +273,308 @@ func (s *MatchingService) MatchLimitOrders(ctx context.Context) {
 	}
 }
 
-func (s *MatchingService) createTrade(ctx context.Context, order *models.Order, price float64) (*models.Trade, error) {
-	value := float64(order.Quantity) * price
+// ExecuteMultiLeg fills every leg of a multi-leg order in one transaction at the given prices; if any leg fails
+// nothing is filled. SELL legs go first so their proceeds settle before the BUY legs are paid for.
+func (s *MatchingService) ExecuteMultiLeg(ctx context.Context, parent *models.Order, legs []*models.Order, prices map[primitive.ObjectID]float64) error {
+	ctx = utils.WithAccountID(ctx, parent.AccountID.Hex())
+	net, ok := parent.NetPrice(prices)
+	if !ok || len(legs) != len(parent.Legs) {
+		return fmt.Errorf("matching engine: missing legs or prices for %s", parent.OrderID)
+	}
 
-	// Commission: 0.05%
-	// Calculate commission: Min(TradeValue * Rate, MaxCap)
-	rawCommission := value * s.config.CommissionRate
-	commission := rawCommission
-	if s.config.MaxCommission > 0 && commission > s.config.MaxCommission {
-		commission = s.config.MaxCommission
+	ordered := make([]*models.Order, 0, len(legs))
+	for _, side := range []string{"SELL", "BUY"} {
+		for _, leg := range legs {
+			if leg.Side == side {
+				ordered = append(ordered, leg)
+			}
+		}
 	}
 
-	// Flat fee from config (if any, default 0)
-	flatFee := s.config.FlatFee
+	// fillOrder updates the orders in place; keep copies to restore on retry or abort
+	snapshots := make([]models.Order, len(legs))
+	for i, leg := range legs {
+		snapshots[i] = *leg
+	}
+	parentSnapshot := *parent
 
-	totalFees := commission + flatFee
-	var netValue float64
+	session, err := s.orderRepo.GetDatabase().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	var trades []*models.Trade
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// Start every attempt from the pre-fill state in case the transaction is retried
+		for i, leg := range legs {
+			*leg = snapshots[i]
+		}
+		*parent = parentSnapshot
+		trades = trades[:0]
+		for _, leg := range ordered {
+			t, err := s.fillOrder(sessCtx, leg, prices[leg.InstrumentID])
+			if err != nil {
+				return nil, fmt.Errorf("%s leg: %w", leg.Symbol, err)
+			}
+			trades = append(trades, t...)
+		}
+
+		now := time.Now()
+		for i := range parent.Legs {
+			parent.Legs[i].FillPrice = prices[parent.Legs[i].InstrumentID]
+		}
+		parent.FilledQuantity = parent.Quantity
+		parent.AvgFillPrice = net
+		parent.Status = "FILLED"
+		parent.FilledAt = &now
+		if _, err := s.orderRepo.Update(sessCtx, parent); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+
+	if err != nil {
+		for i, leg := range legs {
+			*leg = snapshots[i]
+		}
+		*parent = parentSnapshot
+		log.Printf("ERROR: Multi-leg Order %s failed within transaction: %v", parent.OrderID, err)
+		return err
+	}
+	s.portfolioService.ProcessTradeAnalytics(trades)
+
+	s.auditService.Log(parent.UserID.Hex(), "System", "SYSTEM", "ORDER_FILLED",
+		parent.ID.Hex(), "ORDER",
+		fmt.Sprintf("FILL %d x %s @ net ₹%.2f (Multi-leg)", parent.Quantity, parent.Symbol, net),
+		nil, parent)
+
+	go func() {
+		_ = s.notificationService.SendNotification(
+			context.Background(),
+			parent.UserID.Hex(),
+			models.NotificationTypeOrder,
+			"Order Filled",
+			fmt.Sprintf("Your multi-leg order %d x %s was filled on all legs at a net ₹%.2f", parent.Quantity, parent.Symbol, net),
+			map[string]interface{}{"orderId": parent.ID.Hex(), "symbol": parent.Symbol},
+			nil,
+		)
+	}()
+
+	log.Printf("MATCHED: Multi-leg Order %s FILLED at net ₹%.2f (Units: %d)", parent.OrderID, net, parent.Quantity)
+	return nil
+}
+
+// MatchMultiLegOrders fills working multi-leg orders once their net price reaches the limit
+func (s *MatchingService) MatchMultiLegOrders(ctx context.Context) {
+	orders, err := s.orderRepo.FindNewMultiLegOrders(ctx)
+	if err != nil {
+		log.Printf("Matching engine error: failed to fetch multi-leg orders: %v", err)
+		return
+	}
+
+	for _, parent := range orders {
+		legs, err := s.orderRepo.FindByParentOrderID(ctx, parent.ID)
+		if err != nil {
+			continue
+		}
 
+		prices := make(map[primitive.ObjectID]float64)
+		for _, leg := range legs {
+			if marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, leg.InstrumentID.Hex()); err == nil && marketData != nil {
+				prices[leg.InstrumentID] = marketData.LastPrice
+			}
+		}
+
+		// Net is signed: a debit must come in at or under the limit, a credit at or over it
+		net, ok := parent.NetPrice(prices)
+		if ok && parent.Price != nil && net <= *parent.Price {
+			if err := s.ExecuteMultiLeg(ctx, parent, legs, prices); err == nil {
+				continue
+			}
+		}
+
+		if parent.Validity == "IOC" {
+			log.Printf("IOC Multi-leg Order %s not filled immediately, CANCELLING", parent.OrderID)
+			orderCtx := utils.WithAccountID(ctx, parent.AccountID.Hex())
+			for _, leg := range legs {
+				if leg.Status != "NEW" {
+					continue
+				}
+				leg.Status = "CANCELLED"
+				leg.StatusReason = models.OrderReasonIOCUnfilled
+				_, _ = s.orderRepo.Update(orderCtx, leg)
+				if err := s.borrowService.ReleaseOrderLocate(orderCtx, leg.ID); err != nil {
+					log.Printf("Failed to release locate for IOC leg %s: %v", leg.OrderID, err)
+				}
+			}
+			parent.Status = "CANCELLED"
+			parent.StatusReason = models.OrderReasonIOCUnfilled
+			_, _ = s.orderRepo.Update(orderCtx, parent)
+
+			orderToCancel := parent // Capture for goroutine
+			go func() {
+				_ = s.notificationService.SendNotification(
+					context.Background(),
+					orderToCancel.UserID.Hex(),
+					models.NotificationTypeOrder,
+					"IOC Order Cancelled",
+					fmt.Sprintf("Your IOC multi-leg order %d x %s was cancelled because it could not be filled immediately.", orderToCancel.Quantity, orderToCancel.Symbol),
+					map[string]interface{}{"orderId": orderToCancel.ID.Hex(), "symbol": orderToCancel.Symbol},
+					nil,
+				)
+			}()
+		}
+	}
+}
+
+// settleTrade books the cash leg; forced covers may overdraw because the loss has already been incurred
+func (s *MatchingService) settleTrade(ctx context.Context, order *models.Order, trade *models.Trade) error {
+	if order.Origin == models.OrderOriginLiquidation || order.Origin == models.OrderOriginBuyIn || order.Origin == models.OrderOriginSquareOff {
+		return s.accountService.SettleForcedTrade(ctx, order.UserID.Hex(), trade.NetValue, trade.TradeID, trade.Side)
+	}
+	return s.accountService.SettleTrade(ctx, order.UserID.Hex(), trade.NetValue, trade.TradeID, trade.Side)
+}
+
+// fillSlice is the part of an order that fills under one intent
+type fillSlice struct {
+	Intent   string
+	Quantity int
+}
+
+// fillSlices splits a fill of quantity by the position it fills against. Netting orders close any
+// opposite position first and open the remainder; every other order fills entirely under its own intent.
+func (s *MatchingService) fillSlices(ctx context.Context, order *models.Order, quantity int) ([]fillSlice, error) {
+	if !order.Netting {
+		return []fillSlice{{Intent: order.Intent, Quantity: quantity}}, nil
+	}
+
+	openIntent, closeIntent, opposite := models.IntentOpenLong, models.IntentCloseShort, models.PositionShort
+	if order.Side == "SELL" {
+		openIntent, closeIntent, opposite = models.IntentOpenShort, models.IntentCloseLong, models.PositionLong
+	}
+
+	holding, err := s.portfolioService.GetHolding(ctx, order.UserID.Hex(), order.InstrumentID.Hex())
+	if err != nil && err.Error() != "holding not found" {
+		return nil, err
+	}
+
+	closeQty := 0
+	if holding != nil && holding.Quantity > 0 && holding.PositionType == opposite {
+		closeQty = holding.Quantity
+		if closeQty > quantity {
+			closeQty = quantity
+		}
+	}
+
+	slices := make([]fillSlice, 0, 2)
+	if closeQty > 0 {
+		slices = append(slices, fillSlice{Intent: string(closeIntent), Quantity: closeQty})
+	}
+	if quantity > closeQty {
+		slices = append(slices, fillSlice{Intent: string(openIntent), Quantity: quantity - closeQty})
+	}
+	return slices, nil
+}
+
+// fillOrder fills the displayed quantity of an order at one price inside the caller's transaction, booking
+// a trade, settlement, holding update and borrow update per slice in order. An iceberg fills one disclosed
+// slice and stays NEW with refreshed time priority until its total quantity is done.
+func (s *MatchingService) fillOrder(sessCtx mongo.SessionContext, order *models.Order, price float64) ([]*models.Trade, error) {
+	quantity := order.DisplayedQuantity()
+	if quantity <= 0 {
+		return nil, fmt.Errorf("order %s has no quantity left to fill", order.OrderID)
+	}
+	slices, err := s.fillSlices(sessCtx, order, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	filledBefore := order.FilledQuantity
+	order.FilledQuantity += quantity
+	order.AvgFillPrice = (order.AvgFillPrice*float64(filledBefore) + price*float64(quantity)) / float64(order.FilledQuantity)
+	now := time.Now()
+	if order.RemainingQuantity() == 0 {
+		order.Status = "FILLED"
+		order.FilledAt = &now
+	} else {
+		order.PriorityAt = now // The next slice joins the back of the queue
+	}
+	if _, err := s.orderRepo.Update(sessCtx, order); err != nil {
+		return nil, err
+	}
+
+	trades := make([]*models.Trade, 0, len(slices))
+	for i, slice := range slices {
+		seq := 0
+		if len(slices) > 1 {
+			seq = i + 1
+		}
+		trade, err := s.createTrade(sessCtx, order, slice, seq, price)
+		if err != nil {
+			return nil, err
+		}
+
+		// Update Finance (Settlement)
+		if err := s.settleTrade(sessCtx, order, trade); err != nil {
+			return nil, err
+		}
+
+		// Update Portfolio (Holdings)
+		if err := s.portfolioService.UpdatePosition(sessCtx, trade); err != nil {
+			return nil, err
+		}
+
+		// Activate or return the stock borrow
+		if err := s.borrowService.ApplyFill(sessCtx, order, trade); err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+// createTrade books one slice of a fill; seq numbers the slices of a split fill and is 0 otherwise
+func (s *MatchingService) createTrade(ctx context.Context, order *models.Order, slice fillSlice, seq int, price float64) (*models.Trade, error) {
+	value := float64(slice.Quantity) * price
+
+	productType := productTypeOf(order.ProductType)
+	charges, scheduleVersion, err := s.chargeService.Calculate(ctx, productType, order.Side, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute charges: %w", err)
+	}
+
+	tradeID := fmt.Sprintf("%s-T-%d", order.OrderID, time.Now().Unix()%10000)
+	if seq > 0 {
+		tradeID = fmt.Sprintf("%s-%d", tradeID, seq)
+	}
+
+	var netValue float64
 	if order.Side == "BUY" {
-		netValue = value + totalFees
+		netValue = value + charges.Total
 	} else {
-		netValue = value - totalFees
+		netValue = value - charges.Total
 	}
 
 	trade := &models.Trade{
-		TradeID:      fmt.Sprintf("%s-T-%d", order.OrderID, time.Now().Unix()%10000),
-		OrderID:      order.ID,
-		UserID:       order.UserID,
-		AccountID:    order.AccountID,
-		InstrumentID: order.InstrumentID,
-		Symbol:       order.Symbol,
-		Side:         order.Side,
-		Intent:       order.Intent,
-		Quantity:     order.Quantity,
-		Price:        price,
-		Value:        value,
-		Commission:   commission,
-		Fees:         flatFee,
-		NetValue:     netValue,
-		ExecutedAt:   time.Now(),
+		TradeID:               tradeID,
+		OrderID:               order.ID,
+		UserID:                order.UserID,
+		AccountID:             order.AccountID,
+		InstrumentID:          order.InstrumentID,
+		Symbol:                order.Symbol,
+		Side:                  order.Side,
+		Intent:                slice.Intent,
+		Quantity:              slice.Quantity,
+		Price:                 price,
+		Value:                 value,
+		NetValue:              netValue,
+		ProductType:           productType,
+		Charges:               charges,
+		Commission:            charges.Brokerage,
+		Fees:                  charges.Statutory(),
+		ChargeScheduleVersion: scheduleVersion,
+		ExecutedAt:            time.Now(),
 	}
 
 	return s.tradeRepo.Create(ctx, trade)
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// multiLegPlan is a validated leg ready to be booked as a LEG child order
+type multiLegPlan struct {
+	leg        *models.OrderLeg
+	instrument *models.Instrument
+	price      float64
+	product    string
+}
+
+// placeMultiLeg validates a MULTI_LEG order on the combined position, books a LEG child order per
+// instrument and, without a net limit price, executes every leg at once
+func (s *OrderService) placeMultiLeg(ctx context.Context, userID string, req models.Order) (*models.Order, error) {
+	if len(req.Legs) < models.MinOrderLegs || len(req.Legs) > models.MaxOrderLegs {
+		return nil, fmt.Errorf("multi-leg orders need %d to %d legs", models.MinOrderLegs, models.MaxOrderLegs)
+	}
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if req.Validity == "" {
+		req.Validity = "DAY"
+	}
+	if req.Validity != "DAY" && req.Validity != "IOC" && req.Validity != "GTC" {
+		return nil, errors.New("invalid validity. Must be DAY, IOC, or GTC")
+	}
+	if req.Price == nil && req.Validity == "GTC" {
+		return nil, errors.New("multi-leg orders without a net price cannot be GTC")
+	}
+	if req.ProductType != "" && req.ProductType != models.ProductTypeCNC && req.ProductType != models.ProductTypeMIS {
+		return nil, errors.New("invalid product type. Must be CNC or MIS")
+	}
+	if req.DisclosedQuantity != 0 || req.Netting || req.Condition != nil {
+		return nil, errors.New("multi-leg orders cannot be iceberg, netting or conditional")
+	}
+
+	suitability, err := s.suitabilityService.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suitability profile: %v", err)
+	}
+	if suitability == nil || !suitability.Eligibility.AdvancedOrders {
+		return nil, errors.New("MULTI_LEG orders require a completed suitability questionnaire with trading experience")
+	}
+
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	ctx = utils.WithAccountID(ctx, account.ID.Hex())
+
+	// 1. Per-leg checks: instrument, lot size, intent, and the position each leg trades against
+	plans := make([]multiLegPlan, len(req.Legs))
+	seen := make(map[primitive.ObjectID]bool)
+	prices := make(map[primitive.ObjectID]float64)
+	for i := range req.Legs {
+		leg := &req.Legs[i]
+		plan, err := s.planLeg(ctx, userID, leg, req.Quantity, req.ProductType, suitability)
+		if err != nil {
+			return nil, fmt.Errorf("leg %d: %w", i+1, err)
+		}
+		if seen[leg.InstrumentID] {
+			return nil, fmt.Errorf("leg %d: %s appears in more than one leg", i+1, leg.Symbol)
+		}
+		seen[leg.InstrumentID] = true
+		prices[leg.InstrumentID] = plan.price
+		plans[i] = plan
+	}
+
+	// 2. Funds and margin on the combined position: the net cash of all legs plus short margin,
+	// partly offset where long legs hedge the short ones
+	var buyValue, sellValue, longOpenValue, shortOpenValue, shortMargin float64
+	for _, plan := range plans {
+		value := plan.price * float64(plan.leg.Quantity)
+		if plan.leg.Side == "BUY" {
+			buyValue += value
+		} else {
+			sellValue += value
+		}
+		switch plan.leg.Intent {
+		case string(models.IntentOpenLong):
+			longOpenValue += value
+		case string(models.IntentOpenShort):
+			params, err := s.marginService.GetRiskParams(ctx, plan.instrument.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load risk parameters: %v", err)
+			}
+			shortOpenValue += value
+			shortMargin += value * params.InitialRateFor(plan.product)
+		}
+	}
+	hedged := 0.0
+	if shortOpenValue > 0 {
+		hedged = math.Min(longOpenValue, shortOpenValue) / shortOpenValue
+	}
+	required := math.Max(buyValue-sellValue, 0) + shortMargin*(1-models.SpreadMarginOffset*hedged)
+	if available := account.Balance - account.BlockedMargin; available < required {
+		return nil, fmt.Errorf("insufficient funds for the combined position. Required: ₹%0.2f, Available: ₹%0.2f", required, available)
+	}
+
+	// 3. Pre-trade risk rules per leg
+	for _, plan := range plans {
+		leg := &models.Order{
+			InstrumentID: plan.instrument.ID, Symbol: plan.instrument.Symbol, Side: plan.leg.Side, OrderType: "LEG",
+			Quantity: plan.leg.Quantity, Intent: plan.leg.Intent, ProductType: plan.product, Price: &plan.price,
+		}
+		openQty := 0
+		if plan.leg.Intent == string(models.IntentOpenLong) || plan.leg.Intent == string(models.IntentOpenShort) {
+			openQty = plan.leg.Quantity
+		}
+		if err := s.riskEngine.Evaluate(ctx, &PreTradeOrder{
+			Account: account, Instrument: plan.instrument, Order: leg, OpenQuantity: openQty, Price: plan.price,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Book the parent, then a LEG child per instrument
+	symbols := make([]string, len(plans))
+	for i, plan := range plans {
+		symbols[i] = plan.instrument.Symbol
+	}
+	net, _ := req.NetPrice(prices)
+	userUID, _ := primitive.ObjectIDFromHex(userID)
+	req.UserID = userUID
+	req.AccountID = account.ID
+	req.InstrumentID = plans[0].instrument.ID
+	req.Symbol = strings.Join(symbols, "/")
+	req.Side = "BUY" // Net debit; a spread that is sold for a credit shows SELL
+	if net < 0 {
+		req.Side = "SELL"
+	}
+	req.Intent = ""
+	req.ProductType = plans[0].product
+	req.Origin = models.OrderOriginUser
+	req.Status = "NEW"
+	req.OrderID = fmt.Sprintf("ORD-%d", time.Now().UnixNano())
+	req.ValidatedAt = time.Now()
+
+	parent, err := s.orderRepo.Create(ctx, &req)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			if existing, replayErr := s.replayOrder(ctx, userID, &req); existing != nil || replayErr != nil {
+				return existing, replayErr
+			}
+		}
+		return nil, err
+	}
+
+	legs := make([]*models.Order, 0, len(plans))
+	for i, plan := range plans {
+		leg, err := s.bookLeg(ctx, account, parent, plan, i)
+		if err != nil {
+			s.abandonMultiLeg(ctx, parent, legs, "REJECTED", models.OrderReasonLegFailed)
+			return nil, fmt.Errorf("leg %d: %w", i+1, err)
+		}
+		parent.Legs[i].OrderID = &leg.ID
+		legs = append(legs, leg)
+	}
+	if _, err := s.orderRepo.Update(ctx, parent); err != nil {
+		s.abandonMultiLeg(ctx, parent, legs, "REJECTED", models.OrderReasonLegFailed)
+		return nil, err
+	}
+
+	// 5. Without a net limit, execute all legs now or reject the whole order
+	if parent.Price == nil {
+		if err := s.matchingService.ExecuteMultiLeg(ctx, parent, legs, prices); err != nil {
+			s.abandonMultiLeg(ctx, parent, legs, "REJECTED", models.OrderReasonLegFailed)
+			return nil, fmt.Errorf("multi-leg order could not be filled on all legs: %v", err)
+		}
+	}
+
+	s.auditService.LogFromContext(ctx, "ORDER_PLACED", parent.ID.Hex(), "ORDER",
+		fmt.Sprintf("MULTI_LEG %d x %s", parent.Quantity, parent.Symbol), nil, parent)
+	return parent, nil
+}
+
+// planLeg validates one leg the way PlaceOrder validates a single order, minus funds which are checked together
+func (s *OrderService) planLeg(ctx context.Context, userID string, leg *models.OrderLeg, units int, productType string, suitability *models.SuitabilityProfile) (multiLegPlan, error) {
+	if leg.Side != "BUY" && leg.Side != "SELL" {
+		return multiLegPlan{}, errors.New("invalid side. Must be BUY or SELL")
+	}
+	if leg.Ratio == 0 {
+		leg.Ratio = 1
+	}
+	if leg.Ratio < 0 {
+		return multiLegPlan{}, errors.New("ratio must be positive")
+	}
+
+	instrument, err := s.instrumentRepo.FindByID(leg.InstrumentID.Hex())
+	if err != nil || instrument == nil {
+		return multiLegPlan{}, errors.New("instrument not found or inactive")
+	}
+	if instrument.Status != "ACTIVE" || instrument.Type == models.InstrumentTypeIndex {
+		return multiLegPlan{}, errors.New("instrument is not active for trading")
+	}
+	leg.Symbol = instrument.Symbol
+	leg.Quantity = leg.Ratio * units
+	if leg.Quantity%instrument.LotSize != 0 {
+		return multiLegPlan{}, fmt.Errorf("%s quantity %d must be a multiple of lot size (%d)", instrument.Symbol, leg.Quantity, instrument.LotSize)
+	}
+
+	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, instrument.ID.Hex())
+	if err != nil || marketData == nil {
+		return multiLegPlan{}, fmt.Errorf("market data unavailable for %s", instrument.Symbol)
+	}
+
+	if leg.Intent == "" {
+		leg.Intent = string(models.IntentOpenLong)
+		if leg.Side == "SELL" {
+			leg.Intent = string(models.IntentCloseLong)
+		}
+	}
+	buying := leg.Intent == string(models.IntentOpenLong) || leg.Intent == string(models.IntentCloseShort)
+	selling := leg.Intent == string(models.IntentCloseLong) || leg.Intent == string(models.IntentOpenShort)
+	if (leg.Side == "BUY" && !buying) || (leg.Side == "SELL" && !selling) {
+		return multiLegPlan{}, fmt.Errorf("invalid intent %s for a %s leg", leg.Intent, leg.Side)
+	}
+
+	position, err := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
+	if err != nil && err.Error() != "holding not found" {
+		return multiLegPlan{}, fmt.Errorf("failed to check existing position: %v", err)
+	}
+	hasLong := position != nil && position.Quantity > 0 && position.PositionType != models.PositionShort
+	hasShort := position != nil && position.Quantity > 0 && position.PositionType == models.PositionShort
+
+	switch leg.Intent {
+	case string(models.IntentCloseLong), string(models.IntentCloseShort):
+		if (leg.Intent == string(models.IntentCloseLong) && !hasLong) || (leg.Intent == string(models.IntentCloseShort) && !hasShort) {
+			return multiLegPlan{}, fmt.Errorf("no position in %s to close", instrument.Symbol)
+		}
+		pendingQty, err := s.orderRepo.GetPendingQuantity(ctx, userID, instrument.ID.Hex(), leg.Intent)
+		if err != nil {
+			return multiLegPlan{}, fmt.Errorf("failed to check pending orders: %v", err)
+		}
+		if position.Quantity < pendingQty+leg.Quantity {
+			return multiLegPlan{}, fmt.Errorf("insufficient %s position. Open: %d, Committed: %d, Requested: %d", instrument.Symbol, position.Quantity, pendingQty, leg.Quantity)
+		}
+	case string(models.IntentOpenLong):
+		if hasShort {
+			return multiLegPlan{}, fmt.Errorf("cannot open long: you have a SHORT position in %s", instrument.Symbol)
+		}
+	case string(models.IntentOpenShort):
+		if hasLong {
+			return multiLegPlan{}, fmt.Errorf("cannot open short: you have a LONG position in %s", instrument.Symbol)
+		}
+		if !instrument.IsShortable {
+			return multiLegPlan{}, fmt.Errorf("%s is not eligible for short selling", instrument.Symbol)
+		}
+		if !suitability.HasCurrentDisclosure() {
+			return multiLegPlan{}, fmt.Errorf("short selling requires acceptance of risk disclosure version %s", models.RiskDisclosureVersion)
+		}
+		if !suitability.Eligibility.ShortSelling {
+			return multiLegPlan{}, errors.New("your suitability profile does not permit short selling")
+		}
+	}
+
+	// Closing legs keep the position's product; an open position cannot mix CNC and MIS
+	product := productTypeOf(productType)
+	if position != nil && position.Quantity > 0 {
+		held := productTypeOf(position.ProductType)
+		if productType != "" && held != product && (leg.Intent == string(models.IntentOpenLong) || leg.Intent == string(models.IntentOpenShort)) {
+			return multiLegPlan{}, fmt.Errorf("cannot add %s to your existing %s position in %s", product, held, instrument.Symbol)
+		}
+		product = held
+	}
+
+	return multiLegPlan{leg: leg, instrument: instrument, price: marketData.LastPrice, product: product}, nil
+}
+
+// bookLeg creates the LEG child order for one leg, taking a locate for short legs
+func (s *OrderService) bookLeg(ctx context.Context, account *models.TradingAccount, parent *models.Order, plan multiLegPlan, i int) (*models.Order, error) {
+	var locate *models.BorrowRecord
+	if plan.leg.Intent == string(models.IntentOpenShort) {
+		var err error
+		if locate, err = s.borrowService.Locate(ctx, account, plan.instrument, plan.leg.Quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	leg, err := s.orderRepo.Create(ctx, &models.Order{
+		OrderID:       fmt.Sprintf("%s-L%d", parent.OrderID, i+1),
+		UserID:        parent.UserID,
+		AccountID:     parent.AccountID,
+		InstrumentID:  plan.instrument.ID,
+		Symbol:        plan.instrument.Symbol,
+		Side:          plan.leg.Side,
+		OrderType:     "LEG",
+		Quantity:      plan.leg.Quantity,
+		Intent:        plan.leg.Intent,
+		Validity:      parent.Validity,
+		ProductType:   plan.product,
+		ParentOrderID: &parent.ID,
+		Status:        "NEW",
+		Source:        parent.Source,
+		Origin:        parent.Origin,
+		ClientOrderID: fmt.Sprintf("%s-L%d", parent.OrderID, i+1),
+		ValidatedAt:   parent.ValidatedAt,
+	})
+	if err != nil {
+		_ = s.borrowService.ReleaseLocate(ctx, locate)
+		return nil, err
+	}
+	if locate != nil {
+		if err := s.borrowService.AttachOrder(ctx, locate, leg.ID); err != nil {
+			log.Printf("ERROR: Failed to attach locate %s to leg %s: %v", locate.ID.Hex(), leg.OrderID, err)
+		}
+	}
+	return leg, nil
+}
+
+// abandonMultiLeg closes a multi-leg order and its unfilled legs with the given status and reason code, releasing locates
+func (s *OrderService) abandonMultiLeg(ctx context.Context, parent *models.Order, legs []*models.Order, status string, reason string) {
+	for _, leg := range legs {
+		if leg.Status != "NEW" {
+			continue
+		}
+		leg.Status = status
+		leg.StatusReason = reason
+		if _, err := s.orderRepo.Update(ctx, leg); err != nil {
+			log.Printf("ERROR: Failed to close leg %s: %v", leg.OrderID, err)
+		}
+		if err := s.borrowService.ReleaseOrderLocate(ctx, leg.ID); err != nil {
+			log.Printf("ERROR: Failed to release locate for leg %s: %v", leg.OrderID, err)
+		}
+	}
+	parent.Status = status
+	parent.StatusReason = reason
+	if _, err := s.orderRepo.Update(ctx, parent); err != nil {
+		log.Printf("ERROR: Failed to close multi-leg order %s: %v", parent.OrderID, err)
+	}
+}
+
+// cancelMultiLeg cancels a working multi-leg order together with its legs
+func (s *OrderService) cancelMultiLeg(ctx context.Context, parent *models.Order) (*models.Order, error) {
+	old := *parent
+	legs, err := s.orderRepo.FindByParentOrderID(ctx, parent.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.abandonMultiLeg(ctx, parent, legs, "CANCELLED", models.OrderReasonUserRequest)
+
+	go func() {
+		_ = s.notificationService.SendNotification(context.Background(), parent.UserID.Hex(), models.NotificationTypeOrder,
+			"Order Cancelled", fmt.Sprintf("Your multi-leg order %d x %s has been cancelled.", parent.Quantity, parent.Symbol),
+			map[string]interface{}{"orderId": parent.ID.Hex(), "symbol": parent.Symbol}, nil)
+	}()
+
+	s.auditService.LogFromContext(ctx, "ORDER_CANCELLED", parent.ID.Hex(), "ORDER",
+		fmt.Sprintf("CANCEL MULTI_LEG: %s (%s)", parent.OrderID, parent.Symbol), old, parent)
+	return parent, nil
+}
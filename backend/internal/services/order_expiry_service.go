@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+)
+
+// OrderExpiryService expires DAY orders that are still working once the trading session closes
+type OrderExpiryService struct {
+	orderRepo    *repositories.OrderRepository
+	marketRepo   *repositories.MarketRepository
+	orderService *OrderService
+	stopChan     chan struct{}
+
+	lastRun string // YYYY-MM-DD
+}
+
+func NewOrderExpiryService(
+	orderRepo *repositories.OrderRepository,
+	marketRepo *repositories.MarketRepository,
+	orderService *OrderService,
+) *OrderExpiryService {
+	return &OrderExpiryService{
+		orderRepo:    orderRepo,
+		marketRepo:   marketRepo,
+		orderService: orderService,
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start begins the end-of-session expiry scheduler
+func (s *OrderExpiryService) Start() {
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		s.tick()
+		for {
+			select {
+			case <-ticker.C:
+				s.tick()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("DAY order expiry scheduler started")
+}
+
+// Stop gracefully shuts down the scheduler
+func (s *OrderExpiryService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *OrderExpiryService) tick() {
+	now := utils.GetISTTime()
+	today, _ := istDayBounds(now)
+	todayKey := today.Format("2006-01-02")
+	if s.lastRun == todayKey {
+		return
+	}
+	closeAt, ok := exchangeSessionClose(s.marketRepo, snapshotExchange, today)
+	if !ok || now.Before(closeAt) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	defer cancel()
+	if err := s.expireBefore(ctx, closeAt); err != nil {
+		log.Printf("[OrderExpiry] Failed to expire DAY orders: %v", err)
+		return
+	}
+	s.lastRun = todayKey
+}
+
+// expireBefore expires DAY orders placed before the session close; orders placed after it wait for the next session
+func (s *OrderExpiryService) expireBefore(ctx context.Context, closeAt time.Time) error {
+	orders, err := s.orderRepo.FindOpenDayOrders(ctx, closeAt)
+	if err != nil {
+		return err
+	}
+
+	expired := 0
+	for _, order := range orders {
+		if err := s.orderService.ExpireOrder(ctx, order); err != nil {
+			// Usually filled or cancelled since it was listed, which the repository refuses as a stale write
+			log.Printf("[OrderExpiry] Skipped order %s: %v", order.OrderID, err)
+			continue
+		}
+		expired++
+	}
+	if expired > 0 {
+		log.Printf("[OrderExpiry] Expired %d DAY orders at session close", expired)
+	}
+	return nil
+}
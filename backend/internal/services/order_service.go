@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"strings"
 	"time"
 
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type OrderService struct {
@@ -19,10 +22,15 @@ type OrderService struct {
 	instrumentRepo      *repositories.InstrumentRepository
 	tradingAccountRepo  *repositories.TradingAccountRepository
 	marketDataRepo      *repositories.MarketDataRepository
+	candleRepo          *repositories.CandleRepository
 	matchingService     *MatchingService
 	portfolioService    *PortfolioService
 	notificationService *NotificationService
 	auditService        *AuditService
+	marginService       *MarginService
+	borrowService       *BorrowService
+	suitabilityService  *SuitabilityService
+	riskEngine          *RiskEngineService
 }
 
 func NewOrderService(
@@ -34,20 +42,74 @@ func NewOrderService(
 	portfolioService *PortfolioService,
 	notificationService *NotificationService,
 	auditService *AuditService,
+	marginService *MarginService,
+	borrowService *BorrowService,
+	suitabilityService *SuitabilityService,
+	riskEngine *RiskEngineService,
+	candleRepo *repositories.CandleRepository,
 ) *OrderService {
 	return &OrderService{
 		orderRepo:           orderRepo,
 		instrumentRepo:      instrumentRepo,
 		tradingAccountRepo:  tradingAccountRepo,
 		marketDataRepo:      marketDataRepo,
+		candleRepo:          candleRepo,
 		matchingService:     matchingService,
 		portfolioService:    portfolioService,
 		notificationService: notificationService,
 		auditService:        auditService,
+		marginService:       marginService,
+		borrowService:       borrowService,
+		suitabilityService:  suitabilityService,
+		riskEngine:          riskEngine,
 	}
 }
 
+// replayOrder returns the order already placed with req's client order ID, or nil if there is none.
+// The ID must be reused for the same order within models.IdempotencyRetention.
+func (s *OrderService) replayOrder(ctx context.Context, userID string, req *models.Order) (*models.Order, error) {
+	if req.ClientOrderID == "" {
+		return nil, nil
+	}
+	userUID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	// Client order IDs are per account; resolve the default account when the request names none
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	existing, err := s.orderRepo.FindByClientOrderID(utils.WithAccountID(ctx, account.ID.Hex()), userUID, req.ClientOrderID)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if time.Since(existing.CreatedAt) > models.IdempotencyRetention {
+		return nil, fmt.Errorf("client order ID %s was already used; choose a new one", req.ClientOrderID)
+	}
+	if existing.InstrumentID != req.InstrumentID || existing.Side != req.Side ||
+		existing.OrderType != req.OrderType || existing.Quantity != req.Quantity {
+		return nil, fmt.Errorf("client order ID %s was already used for a different order", req.ClientOrderID)
+	}
+	existing.Replayed = true
+	return existing, nil
+}
+
 func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models.Order) (*models.Order, error) {
+	// A retried submission returns the order its client order ID already placed
+	if existing, err := s.replayOrder(ctx, userID, &req); existing != nil || err != nil {
+		return existing, err
+	}
+
+	// Multi-leg orders are validated and margined on the combined position
+	if req.OrderType == "MULTI_LEG" {
+		return s.placeMultiLeg(ctx, userID, req)
+	}
+	if len(req.Legs) > 0 {
+		return nil, errors.New("legs are only allowed on MULTI_LEG orders")
+	}
+
 	// 1. Basic Input Validation
 	if req.Side == "" || req.OrderType == "" || req.InstrumentID.IsZero() || req.Quantity <= 0 {
 		return nil, errors.New("side, type, instrument, and quantity are mandatory fields")
@@ -59,7 +121,7 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 	}
 
 	// Validate order type
-	validOrderTypes := []string{"MARKET", "LIMIT", "STOP", "STOP_LIMIT", "TRAILING_STOP"}
+	validOrderTypes := []string{"MARKET", "LIMIT", "STOP", "STOP_LIMIT", "TRAILING_STOP", "CONDITIONAL"}
 	isValidType := false
 	for _, validType := range validOrderTypes {
 		if req.OrderType == validType {
@@ -68,7 +130,21 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 		}
 	}
 	if !isValidType {
-		return nil, errors.New("invalid order type. Must be MARKET, LIMIT, STOP, STOP_LIMIT, or TRAILING_STOP")
+		return nil, errors.New("invalid order type. Must be MARKET, LIMIT, STOP, STOP_LIMIT, TRAILING_STOP, CONDITIONAL, or MULTI_LEG")
+	}
+
+	// Advanced order types depend on the user's suitability profile
+	suitability, err := s.suitabilityService.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suitability profile: %v", err)
+	}
+	if (req.OrderType == "STOP_LIMIT" || req.OrderType == "TRAILING_STOP" || req.OrderType == "CONDITIONAL") &&
+		(suitability == nil || !suitability.Eligibility.AdvancedOrders) {
+		return nil, fmt.Errorf("%s orders require a completed suitability questionnaire with trading experience", req.OrderType)
+	}
+
+	if req.OrderType != "CONDITIONAL" && req.Condition != nil {
+		return nil, errors.New("a condition is only allowed on CONDITIONAL orders")
 	}
 
 	// Market orders must not have a price
@@ -94,6 +170,27 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 		return nil, errors.New("market orders cannot be GTC")
 	}
 
+	// Iceberg orders show one disclosed slice of a LIMIT order at a time
+	if req.DisclosedQuantity != 0 {
+		if req.OrderType != "LIMIT" {
+			return nil, errors.New("disclosed quantity is only allowed on LIMIT orders")
+		}
+		if req.Validity == "IOC" {
+			return nil, errors.New("disclosed quantity is not allowed on IOC orders")
+		}
+		if req.DisclosedQuantity < 0 || req.DisclosedQuantity >= req.Quantity {
+			return nil, errors.New("disclosed quantity must be positive and less than the order quantity")
+		}
+		if float64(req.DisclosedQuantity) < float64(req.Quantity)*models.MinDisclosedFraction {
+			return nil, fmt.Errorf("disclosed quantity must be at least %.0f%% of the order quantity", models.MinDisclosedFraction*100)
+		}
+	}
+
+	// Validate Product Type (empty is resolved once the intent is known)
+	if req.ProductType != "" && req.ProductType != models.ProductTypeCNC && req.ProductType != models.ProductTypeMIS {
+		return nil, errors.New("invalid product type. Must be CNC or MIS")
+	}
+
 	// 2. Get Instrument for Validation (needed for stop order validation)
 	instrument, err := s.instrumentRepo.FindByID(req.InstrumentID.Hex())
 	if err != nil || instrument == nil {
@@ -102,6 +199,9 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 	if instrument.Status != "ACTIVE" {
 		return nil, errors.New("instrument is not active for trading")
 	}
+	if instrument.Type == models.InstrumentTypeIndex {
+		return nil, errors.New("indices are not tradable")
+	}
 
 	// 3. Stop Order Validation (if applicable)
 	if req.OrderType == "STOP" || req.OrderType == "STOP_LIMIT" || req.OrderType == "TRAILING_STOP" {
@@ -122,10 +222,20 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 		}
 	}
 
+	// 3b. Conditional orders wait for their condition, then place a MARKET or LIMIT order
+	if req.OrderType == "CONDITIONAL" {
+		if err := s.validateCondition(ctx, &req, instrument); err != nil {
+			return nil, err
+		}
+	}
+
 	// 4. Lot Size Validation
 	if req.Quantity%instrument.LotSize != 0 {
 		return nil, fmt.Errorf("quantity must be a multiple of lot size (%d)", instrument.LotSize)
 	}
+	if req.DisclosedQuantity%instrument.LotSize != 0 {
+		return nil, fmt.Errorf("disclosed quantity must be a multiple of lot size (%d)", instrument.LotSize)
+	}
 
 	// 6. Price & Tick Size Validation
 	var orderPrice float64
@@ -141,7 +251,9 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 			return nil, fmt.Errorf("price must be a multiple of tick size (%v)", instrument.TickSize)
 		}
 		orderPrice = *req.Price
-	} else if req.OrderType == "MARKET" {
+	} else if req.OrderType == "CONDITIONAL" && req.LimitPrice != nil {
+		orderPrice = *req.LimitPrice
+	} else if req.OrderType == "MARKET" || req.OrderType == "CONDITIONAL" {
 		// Fetch LTP for risk check
 		marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, instrument.ID.Hex())
 		if err != nil || marketData == nil {
@@ -168,6 +280,7 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 	if err != nil || account == nil {
 		return nil, errors.New("trading account not found")
 	}
+	ctx = utils.WithAccountID(ctx, account.ID.Hex()) // Positions, working orders and limits are per account
 
 	// Risk Check based on side
 	// 8. Intent Validation & Specific Checks
@@ -194,118 +307,172 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 		return nil, errors.New("invalid intent for SELL order")
 	}
 
-	// Specific Validation Logic
-	if req.Intent == string(models.IntentOpenLong) {
-		// Check for conflicting SHORT position
-		existingHolding, err := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
-		if err != nil && err.Error() != "holding not found" {
-			return nil, fmt.Errorf("failed to check existing position: %v", err)
-		}
-
-		// Only block if there's an ACTIVE short position (quantity > 0)
-		if existingHolding != nil && existingHolding.PositionType == models.PositionShort && existingHolding.Quantity > 0 {
-			return nil, fmt.Errorf("cannot open long position: you already have a SHORT position of %d shares in %s. Please close your short position first",
-				existingHolding.Quantity, instrument.Symbol)
-		}
-	} else if req.Intent == string(models.IntentOpenShort) {
-		// 1. Check for conflicting LONG position
-		existingHolding, err := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
-		if err != nil && err.Error() != "holding not found" {
-			return nil, fmt.Errorf("failed to check existing position: %v", err)
-		}
+	position, err := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
+	if err != nil && err.Error() != "holding not found" {
+		return nil, fmt.Errorf("failed to check existing position: %v", err)
+	}
 
-		// Only block if there's an ACTIVE long position (quantity > 0)
-		if existingHolding != nil && existingHolding.PositionType == models.PositionLong && existingHolding.Quantity > 0 {
-			return nil, fmt.Errorf("cannot open short position: you already have a LONG position of %d shares in %s. Please close your long position first",
-				existingHolding.Quantity, instrument.Symbol)
+	// Netting orders close any opposite position first and open the remainder in the same fill.
+	// The order carries the intent of its first slice; the matching engine re-infers each slice at fill time.
+	closeQty, openQty := 0, req.Quantity
+	openIntent := req.Intent
+	if req.Netting {
+		openIntent = string(models.IntentOpenLong)
+		closeIntent, opposite := string(models.IntentCloseShort), models.PositionShort
+		if req.Side == "SELL" {
+			openIntent = string(models.IntentOpenShort)
+			closeIntent, opposite = string(models.IntentCloseLong), models.PositionLong
 		}
+		req.Intent = openIntent
 
-		// 2. Check if instrument is shortable
-		if !instrument.IsShortable {
-			return nil, errors.New("this instrument is not eligible for short selling")
+		if position != nil && position.Quantity > 0 && position.PositionType == opposite {
+			pendingQty, err := s.orderRepo.GetPendingQuantity(ctx, userID, instrument.ID.Hex(), closeIntent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check pending orders: %v", err)
+			}
+			if pendingQty > 0 {
+				return nil, fmt.Errorf("cannot place netting order: %d shares of your %s position are already committed to pending orders", pendingQty, instrument.Symbol)
+			}
+			closeQty = req.Quantity
+			if closeQty > position.Quantity {
+				closeQty = position.Quantity
+			}
+			openQty = req.Quantity - closeQty
+			req.Intent = closeIntent
 		}
+	} else if req.Intent == string(models.IntentCloseLong) || req.Intent == string(models.IntentCloseShort) {
+		closeQty, openQty = req.Quantity, 0
+	}
 
-		// 3. Check Margin Availability (20% Requirement)
-		// Margin = Price * Qty * 0.20
-		requiredMargin := orderPrice * float64(req.Quantity) * 0.20
-
-		// Check available funds
-		if account.Balance-account.BlockedMargin < requiredMargin {
-			return nil, fmt.Errorf("insufficient margin. Required: ₹%0.2f, Available: ₹%0.2f", requiredMargin, account.Balance-account.BlockedMargin)
+	// Closing orders take the position's product; an open position cannot mix CNC and MIS
+	if position != nil && position.Quantity > 0 {
+		held := productTypeOf(position.ProductType)
+		if req.Intent == string(models.IntentCloseLong) || req.Intent == string(models.IntentCloseShort) {
+			req.ProductType = held // A reversal keeps the product of the position it flips
+		} else if req.ProductType != "" && req.ProductType != held {
+			return nil, fmt.Errorf("cannot add %s to your existing %s position in %s", req.ProductType, held, instrument.Symbol)
+		} else {
+			req.ProductType = held
 		}
+	}
+	req.ProductType = productTypeOf(req.ProductType)
 
-		// 4. Position Size Limit (Risk Control)
-		// Maximum position value = 5x account balance (5x leverage)
-		positionValue := orderPrice * float64(req.Quantity)
-		maxPositionValue := account.Balance * 5.0
-
-		if positionValue > maxPositionValue {
-			return nil, fmt.Errorf("position size exceeds maximum allowed (5x leverage). Position value: ₹%.2f, Max allowed: ₹%.2f",
-				positionValue, maxPositionValue)
-		}
+	// Intraday positions are squared off the same session
+	if req.ProductType == models.ProductTypeMIS && req.Validity == "GTC" {
+		return nil, errors.New("MIS orders cannot be GTC")
+	}
 
-		// 5. Quantity Limit (Prevent Integer Overflow)
-		const maxQuantity = 1_000_000 // 1 million shares
-		if req.Quantity > maxQuantity {
-			return nil, fmt.Errorf("quantity exceeds maximum allowed (%d shares)", maxQuantity)
-		}
-	} else if req.Intent == string(models.IntentCloseShort) {
+	// Specific Validation Logic
+	if closeQty > 0 && req.Intent == string(models.IntentCloseShort) {
 		// Validate that we have a short position to cover
-		holding, err := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
-		if err != nil {
-			return nil, fmt.Errorf("failed to validate holdings: %v", err)
-		}
-		if holding == nil || holding.PositionType != models.PositionShort {
+		if position == nil || position.PositionType != models.PositionShort {
 			return nil, errors.New("no short position found to cover")
 		}
 
 		// Check Pending Orders to prevent Over-Covering
-		pendingQty, err := s.orderRepo.GetPendingQuantity(userID, instrument.ID.Hex(), req.Intent)
+		pendingQty, err := s.orderRepo.GetPendingQuantity(ctx, userID, instrument.ID.Hex(), req.Intent)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check pending orders: %v", err)
 		}
 
-		totalCommitted := pendingQty + req.Quantity
-		if holding.Quantity < totalCommitted {
-			return nil, fmt.Errorf("insufficient short quantity. Open: %d, Committed: %d, Converting: %d", holding.Quantity, pendingQty, req.Quantity)
+		totalCommitted := pendingQty + closeQty
+		if position.Quantity < totalCommitted {
+			return nil, fmt.Errorf("insufficient short quantity. Open: %d, Committed: %d, Converting: %d", position.Quantity, pendingQty, closeQty)
 		}
-
-	} else if req.Intent == string(models.IntentCloseLong) {
+	} else if closeQty > 0 && req.Intent == string(models.IntentCloseLong) {
 		// Standard Sell Check
-		holding, err := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
-		if err != nil {
-			return nil, fmt.Errorf("failed to validate holdings: %v", err)
-		}
-		if holding == nil || holding.PositionType == models.PositionShort {
+		if position == nil || position.PositionType == models.PositionShort {
 			return nil, errors.New("no long position found to sell")
 		}
 
 		// Check Pending Orders to prevent Over-Selling
-		pendingQty, err := s.orderRepo.GetPendingQuantity(userID, instrument.ID.Hex(), req.Intent)
+		pendingQty, err := s.orderRepo.GetPendingQuantity(ctx, userID, instrument.ID.Hex(), req.Intent)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check pending orders: %v", err)
 		}
 
-		totalCommitted := pendingQty + req.Quantity
-		if holding.Quantity < totalCommitted {
-			return nil, fmt.Errorf("insufficient holdings to sell. Owned: %d, Committed: %d, Requested: %d", holding.Quantity, pendingQty, req.Quantity)
+		totalCommitted := pendingQty + closeQty
+		if position.Quantity < totalCommitted {
+			return nil, fmt.Errorf("insufficient holdings to sell. Owned: %d, Committed: %d, Requested: %d", position.Quantity, pendingQty, closeQty)
 		}
-	} else if req.Intent == string(models.IntentOpenLong) {
+	}
+
+	if openQty > 0 && openIntent == string(models.IntentOpenLong) {
+		// Only block if there's an ACTIVE short position (quantity > 0) that this order does not close
+		if closeQty == 0 && position != nil && position.PositionType == models.PositionShort && position.Quantity > 0 {
+			return nil, fmt.Errorf("cannot open long position: you already have a SHORT position of %d shares in %s. Please close your short position first or place a netting order",
+				position.Quantity, instrument.Symbol)
+		}
+
 		// Standard Buy Check (Full Cash)
-		requiredFunds := float64(req.Quantity) * orderPrice
+		requiredFunds := float64(openQty) * orderPrice
 		if account.Balance-account.BlockedMargin < requiredFunds {
 			return nil, fmt.Errorf("insufficient funds. Required: ₹%0.2f, Available: ₹%0.2f", requiredFunds, account.Balance-account.BlockedMargin)
 		}
+	} else if openQty > 0 && openIntent == string(models.IntentOpenShort) {
+		// 1. Only block if there's an ACTIVE long position (quantity > 0) that this order does not close
+		if closeQty == 0 && position != nil && position.PositionType == models.PositionLong && position.Quantity > 0 {
+			return nil, fmt.Errorf("cannot open short position: you already have a LONG position of %d shares in %s. Please close your long position first or place a netting order",
+				position.Quantity, instrument.Symbol)
+		}
+
+		// 2. Check if instrument is shortable
+		if !instrument.IsShortable {
+			return nil, errors.New("this instrument is not eligible for short selling")
+		}
+
+		// 2b. The user must have accepted the current risk disclosure and be suitable for shorting
+		if !suitability.HasCurrentDisclosure() {
+			return nil, fmt.Errorf("short selling requires acceptance of risk disclosure version %s", models.RiskDisclosureVersion)
+		}
+		if !suitability.Eligibility.ShortSelling {
+			return nil, errors.New("your suitability profile does not permit short selling")
+		}
+
+		// 3. Check Margin Availability (instrument initial rate: VaR + ELM, reduced for MIS)
+		params, err := s.marginService.GetRiskParams(ctx, instrument.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load risk parameters: %v", err)
+		}
+		requiredMargin := orderPrice * float64(openQty) * params.InitialRateFor(req.ProductType)
+
+		// Check available funds
+		if account.Balance-account.BlockedMargin < requiredMargin {
+			return nil, fmt.Errorf("insufficient margin. Required: ₹%0.2f, Available: ₹%0.2f", requiredMargin, account.Balance-account.BlockedMargin)
+		}
+
+		// 4. Position Size Limit (Risk Control)
+		// Maximum position value = MaxLeverage (higher for MIS) x account balance, capped by the user's suitability
+		positionValue := orderPrice * float64(openQty)
+		maxLeverage := math.Min(params.MaxLeverageFor(req.ProductType), suitability.Eligibility.MaxLeverage)
+		maxPositionValue := account.Balance * maxLeverage
+
+		if positionValue > maxPositionValue {
+			return nil, fmt.Errorf("position size exceeds maximum allowed (%.1fx leverage). Position value: ₹%.2f, Max allowed: ₹%.2f",
+				maxLeverage, positionValue, maxPositionValue)
+		}
+	}
+
+	// Pre-trade risk engine (order size, exposure, fat-finger, throttling, daily loss)
+	if err := s.riskEngine.Evaluate(ctx, &PreTradeOrder{
+		Account:      account,
+		Instrument:   instrument,
+		Order:        &req,
+		OpenQuantity: openQty,
+		Price:        orderPrice,
+	}); err != nil {
+		return nil, err
 	}
 
 	// 9. Finalize Order
 	userUID, _ := primitive.ObjectIDFromHex(userID)
 	req.UserID = userUID
 	req.AccountID = account.ID
+	req.Origin = models.OrderOriginUser // System origins are never accepted from clients
 
 	// Set status based on order type
-	if req.OrderType == "STOP" || req.OrderType == "STOP_LIMIT" || req.OrderType == "TRAILING_STOP" {
-		req.Status = "PENDING" // Stop orders start as PENDING
+	if req.OrderType == "STOP" || req.OrderType == "STOP_LIMIT" || req.OrderType == "TRAILING_STOP" || req.OrderType == "CONDITIONAL" {
+		req.Status = "PENDING" // Stop and conditional orders start as PENDING
 	} else {
 		req.Status = "NEW" // Regular orders start as NEW
 	}
@@ -313,10 +480,31 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 	req.OrderID = fmt.Sprintf("ORD-%d", time.Now().UnixNano())
 	req.ValidatedAt = time.Now()
 
+	// Short sales need borrowable stock; stop orders locate when they trigger
+	var locate *models.BorrowRecord
+	if openIntent == string(models.IntentOpenShort) && openQty > 0 && req.Status == "NEW" {
+		locate, err = s.borrowService.Locate(ctx, account, instrument, openQty)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	order, err := s.orderRepo.Create(ctx, &req)
 	if err != nil {
+		_ = s.borrowService.ReleaseLocate(ctx, locate)
+		// A concurrent retry with the same client order ID won the insert
+		if mongo.IsDuplicateKeyError(err) {
+			if existing, replayErr := s.replayOrder(ctx, userID, &req); existing != nil || replayErr != nil {
+				return existing, replayErr
+			}
+		}
 		return nil, err
 	}
+	if locate != nil {
+		if err := s.borrowService.AttachOrder(ctx, locate, order.ID); err != nil {
+			log.Printf("ERROR: Failed to attach locate %s to order %s: %v", locate.ID.Hex(), order.OrderID, err)
+		}
+	}
 
 	// 9. Immediate Execution for MARKET orders
 	if order.OrderType == "MARKET" {
@@ -335,10 +523,143 @@ func (s *OrderService) PlaceOrder(ctx context.Context, userID string, req models
 
 	return order, nil
 }
+// ForceSquareOff closes an intraday (MIS) position ahead of market close
+func (s *OrderService) ForceSquareOff(ctx context.Context, holding *models.Holding, reason string) (*models.Order, error) {
+	ctx = utils.WithAccountID(ctx, holding.AccountID.Hex())
+	current, err := s.portfolioService.GetHolding(ctx, holding.UserID.Hex(), holding.InstrumentID.Hex())
+	if err != nil || current == nil || current.Quantity <= 0 || current.ProductType != models.ProductTypeMIS {
+		return nil, errors.New("no open MIS position to square off")
+	}
+	return s.forceClose(ctx, current, current.Quantity, models.OrderOriginSquareOff, "SQOFF", reason)
+}
+
+// ForceExecuteLiquidation squares off an entire position with a system MARKET order.
+// Balance and margin checks are skipped, but the position must exist and the fill still goes through the matching engine.
+func (s *OrderService) ForceExecuteLiquidation(ctx context.Context, holding *models.Holding, reason string) (*models.Order, error) {
+	ctx = utils.WithAccountID(ctx, holding.AccountID.Hex())
+	current, err := s.portfolioService.GetHolding(ctx, holding.UserID.Hex(), holding.InstrumentID.Hex())
+	if err != nil || current == nil || current.Quantity <= 0 {
+		return nil, errors.New("no open position to liquidate")
+	}
+	return s.forceClose(ctx, current, current.Quantity, models.OrderOriginLiquidation, "LIQ", reason)
+}
+
+// ExecuteBuyIn force-covers the short behind a recalled borrow at market
+func (s *OrderService) ExecuteBuyIn(ctx context.Context, borrowID string, reason string) (*models.Order, error) {
+	borrow, err := s.borrowService.GetBorrow(ctx, borrowID)
+	if err != nil {
+		return nil, err
+	}
+	if borrow.Status != models.BorrowStatusActive && borrow.Status != models.BorrowStatusRecalled {
+		return nil, fmt.Errorf("cannot buy in borrow with status: %s", borrow.Status)
+	}
+	ctx = utils.WithAccountID(ctx, borrow.AccountID.Hex())
+
+	current, err := s.portfolioService.GetHolding(ctx, borrow.UserID.Hex(), borrow.InstrumentID.Hex())
+	if err != nil || current == nil || current.PositionType != models.PositionShort || current.Quantity <= 0 {
+		return nil, errors.New("no open short position for this borrow")
+	}
+
+	quantity := borrow.Quantity
+	if quantity > current.Quantity {
+		quantity = current.Quantity
+	}
+	return s.forceClose(ctx, current, quantity, models.OrderOriginBuyIn, "BUYIN", reason)
+}
+
+// forceClose cancels working orders on the instrument and closes quantity of the position with a system MARKET order
+func (s *OrderService) forceClose(ctx context.Context, current *models.Holding, quantity int, origin string, prefix string, reason string) (*models.Order, error) {
+	ctx = utils.WithAccountID(ctx, current.AccountID.Hex())
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, current.UserID.Hex())
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+
+	// Working orders on the instrument would over-close once the position is flat
+	working, err := s.orderRepo.FindActiveByUserAndInstrument(ctx, current.UserID, current.InstrumentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load working orders: %v", err)
+	}
+	for _, o := range working {
+		old := *o
+		o.Status = "CANCELLED"
+		o.StatusReason = models.OrderReasonForcedClose
+		if _, err := s.orderRepo.Update(ctx, o); err != nil {
+			return nil, fmt.Errorf("failed to cancel working order %s: %v", o.OrderID, err)
+		}
+		if err := s.borrowService.ReleaseOrderLocate(ctx, o.ID); err != nil {
+			log.Printf("ERROR: Failed to release locate for order %s: %v", o.OrderID, err)
+		}
+		s.auditService.Log(o.UserID.Hex(), "System", "SYSTEM", "ORDER_CANCELLED", o.ID.Hex(), "ORDER",
+			fmt.Sprintf("CANCEL %s: %s (%s) ahead of %s", o.Side, o.OrderID, o.Symbol, strings.ToLower(origin)), old, o)
+	}
+
+	order := models.Order{
+		UserID:       current.UserID,
+		AccountID:    account.ID,
+		InstrumentID: current.InstrumentID,
+		Symbol:       current.Symbol,
+		OrderType:    "MARKET",
+		Quantity:     quantity,
+		Validity:     "DAY",
+		ProductType:  productTypeOf(current.ProductType),
+		Status:       "NEW",
+		Source:       "SYSTEM",
+		Origin:       origin,
+	}
+	if current.PositionType == models.PositionShort {
+		order.Side = "BUY"
+		order.Intent = string(models.IntentCloseShort)
+	} else {
+		order.Side = "SELL"
+		order.Intent = string(models.IntentCloseLong)
+	}
+	order.OrderID = fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+	order.ValidatedAt = time.Now()
+
+	created, err := s.orderRepo.Create(ctx, &order)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.Log(created.UserID.Hex(), "System", "SYSTEM", origin+"_ORDER_PLACED", created.ID.Hex(), "ORDER",
+		fmt.Sprintf("%s %s %d %s: %s", origin, created.Side, created.Quantity, created.Symbol, reason), nil, created)
+
+	if _, err := s.matchingService.ExecuteMarketOrder(ctx, created); err != nil {
+		old := *created
+		created.Status = "REJECTED"
+		created.StatusReason = models.OrderReasonExecutionFailed
+		created.StatusMessage = err.Error()
+		_, _ = s.orderRepo.Update(ctx, created)
+		s.auditService.Log(created.UserID.Hex(), "System", "SYSTEM", origin+"_ORDER_FAILED", created.ID.Hex(), "ORDER",
+			fmt.Sprintf("%s of %s failed: %v", origin, created.Symbol, err), old, created)
+		return nil, fmt.Errorf("%s execution failed: %w", strings.ToLower(origin), err)
+	}
+
+	return created, nil
+}
+
 func (s *OrderService) GetUserOrders(ctx context.Context, userID string, filters map[string]interface{}, skip int, limit int) ([]*models.Order, int64, error) {
 	return s.orderRepo.FindByUserID(ctx, userID, filters, skip, limit)
 }
 
+// GetOrder returns one of the user's orders with its full event timeline
+func (s *OrderService) GetOrder(ctx context.Context, userID string, orderID string) (*models.OrderDetail, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order == nil {
+		return nil, errors.New("order not found")
+	}
+	if order.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	timeline, err := s.orderRepo.FindEvents(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OrderDetail{Order: order, Timeline: timeline}, nil
+}
+
 func (s *OrderService) CancelOrder(ctx context.Context, userID string, orderID string) (*models.Order, error) {
 	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil || order == nil {
@@ -350,25 +671,42 @@ func (s *OrderService) CancelOrder(ctx context.Context, userID string, orderID s
 		return nil, errors.New("unauthorized")
 	}
 
-	// Only NEW and PENDING orders can be cancelled
-	if order.Status != "NEW" && order.Status != "PENDING" {
+	// Only working (NEW and PENDING) orders can be cancelled
+	if !models.CanTransitionOrder(order.Status, models.OrderStatusCancelled) {
 		return nil, fmt.Errorf("cannot cancel order with status: %s", order.Status)
 	}
 
+	// Legs of a multi-leg order only cancel together, through their parent
+	switch order.OrderType {
+	case "LEG":
+		return nil, errors.New("cannot cancel a single leg; cancel the multi-leg order instead")
+	case "MULTI_LEG":
+		return s.cancelMultiLeg(ctx, order)
+	}
+
 	order.Status = "CANCELLED"
+	order.StatusReason = models.OrderReasonUserRequest
 	updatedOrder, err := s.orderRepo.Update(ctx, order)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.borrowService.ReleaseOrderLocate(ctx, order.ID); err != nil {
+		log.Printf("ERROR: Failed to release locate for order %s: %v", order.OrderID, err)
+	}
 
 	// Send Notification
+	message := fmt.Sprintf("Your %s order for %d %s has been cancelled.", order.Side, order.Quantity, order.Symbol)
+	if order.FilledQuantity > 0 {
+		message = fmt.Sprintf("The unfilled %d of your %s order for %d %s has been cancelled.",
+			order.RemainingQuantity(), order.Side, order.Quantity, order.Symbol)
+	}
 	go func() {
 		_ = s.notificationService.SendNotification(
 			context.Background(),
 			userID,
 			models.NotificationTypeOrder,
 			"Order Cancelled",
-			fmt.Sprintf("Your %s order for %d %s has been cancelled.", order.Side, order.Quantity, order.Symbol),
+			message,
 			map[string]interface{}{"orderId": order.ID.Hex(), "symbol": order.Symbol},
 			nil,
 		)
@@ -382,7 +720,61 @@ func (s *OrderService) CancelOrder(ctx context.Context, userID string, orderID s
 	return updatedOrder, nil
 }
 
-func (s *OrderService) ModifyOrder(ctx context.Context, userID string, orderID string, newQuantity int, newPrice *float64) (*models.Order, error) {
+// ExpireOrder closes a DAY order that was still working when its session closed
+func (s *OrderService) ExpireOrder(ctx context.Context, order *models.Order) error {
+	old := *order
+	if order.OrderType == "MULTI_LEG" {
+		legs, err := s.orderRepo.FindByParentOrderID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		s.abandonMultiLeg(ctx, order, legs, models.OrderStatusExpired, models.OrderReasonSessionClosed)
+	} else {
+		order.Status = models.OrderStatusExpired
+		order.StatusReason = models.OrderReasonSessionClosed
+		if _, err := s.orderRepo.Update(ctx, order); err != nil {
+			return err
+		}
+		if err := s.borrowService.ReleaseOrderLocate(ctx, order.ID); err != nil {
+			log.Printf("ERROR: Failed to release locate for order %s: %v", order.OrderID, err)
+		}
+	}
+
+	message := fmt.Sprintf("Your DAY %s order for %d %s expired unfilled at market close.", order.Side, order.Quantity, order.Symbol)
+	if order.FilledQuantity > 0 {
+		message = fmt.Sprintf("The unfilled %d of your DAY %s order for %d %s expired at market close.",
+			order.RemainingQuantity(), order.Side, order.Quantity, order.Symbol)
+	}
+	go func() {
+		_ = s.notificationService.SendNotification(
+			context.Background(),
+			order.UserID.Hex(),
+			models.NotificationTypeOrder,
+			"Order Expired",
+			message,
+			map[string]interface{}{"orderId": order.ID.Hex(), "symbol": order.Symbol},
+			nil,
+		)
+	}()
+
+	s.auditService.LogFromContext(ctx, "ORDER_EXPIRED", order.ID.Hex(), "ORDER",
+		fmt.Sprintf("EXPIRE %s: %s (%s)", order.Side, order.OrderID, order.Symbol), &old, order)
+	return nil
+}
+
+// OrderAmendment is a requested change to a working order. Price applies to LIMIT orders; stop price,
+// limit price and trail fields apply to pending stop orders, where nil fields and a zero quantity keep
+// their current values.
+type OrderAmendment struct {
+	Quantity    int
+	Price       *float64
+	StopPrice   *float64
+	LimitPrice  *float64
+	TrailAmount *float64
+	TrailType   string
+}
+
+func (s *OrderService) ModifyOrder(ctx context.Context, userID string, orderID string, amend OrderAmendment) (*models.Order, error) {
 	// 1. Find and validate order
 	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil || order == nil {
@@ -394,10 +786,22 @@ func (s *OrderService) ModifyOrder(ctx context.Context, userID string, orderID s
 		return nil, errors.New("unauthorized")
 	}
 
-	// 3. Only NEW orders can be modified
+	ctx = utils.WithAccountID(ctx, order.AccountID.Hex())
+
+	// 3. Only NEW orders and pending stops can be modified
+	if order.Status == "PENDING" && (order.OrderType == "STOP" || order.OrderType == "STOP_LIMIT" || order.OrderType == "TRAILING_STOP") {
+		return s.modifyStopOrder(ctx, userID, order, amend)
+	}
 	if order.Status != "NEW" {
 		return nil, fmt.Errorf("cannot modify order with status: %s", order.Status)
 	}
+	if amend.StopPrice != nil || amend.LimitPrice != nil || amend.TrailAmount != nil || amend.TrailType != "" {
+		return nil, errors.New("stop price, limit price and trail can only be changed on pending stop orders")
+	}
+	newQuantity, newPrice := amend.Quantity, amend.Price
+	if order.OrderType == "MULTI_LEG" || order.OrderType == "LEG" {
+		return nil, errors.New("multi-leg orders cannot be modified; cancel and place a new one")
+	}
 
 	// 4. Get instrument for validation
 	instrument, err := s.instrumentRepo.FindByID(order.InstrumentID.Hex())
@@ -406,9 +810,15 @@ func (s *OrderService) ModifyOrder(ctx context.Context, userID string, orderID s
 	}
 
 	// 5. Validate new quantity (lot size)
+	if order.Netting && newQuantity != order.Quantity {
+		return nil, errors.New("cannot change the quantity of a netting order; cancel and place a new one")
+	}
 	if newQuantity <= 0 {
 		return nil, errors.New("quantity must be positive")
 	}
+	if newQuantity <= order.FilledQuantity {
+		return nil, fmt.Errorf("quantity must exceed the %d already filled", order.FilledQuantity)
+	}
 	if newQuantity%instrument.LotSize != 0 {
 		return nil, fmt.Errorf("quantity must be a multiple of lot size (%d)", instrument.LotSize)
 	}
@@ -441,22 +851,222 @@ func (s *OrderService) ModifyOrder(ctx context.Context, userID string, orderID s
 			return nil, errors.New("trading account not found")
 		}
 
-		requiredFunds := float64(newQuantity) * orderPrice
+		requiredFunds := float64(newQuantity-order.FilledQuantity) * orderPrice
 		if account.Balance < requiredFunds {
 			return nil, fmt.Errorf("insufficient balance. Required: %0.2f, Available: %0.2f", requiredFunds, account.Balance)
 		}
 	}
 
-	// 8. Update order
+	// Short sales must hold a locate for the new quantity
+	if order.Intent == string(models.IntentOpenShort) && newQuantity != order.Quantity {
+		if err := s.borrowService.ResizeLocate(ctx, order.ID, instrument, newQuantity-order.FilledQuantity); err != nil {
+			return nil, err
+		}
+	}
+
+	// 8. Update order; a new price or a larger quantity loses time priority
+	now := time.Now()
+	if newQuantity > order.Quantity || (order.OrderType == "LIMIT" && order.Price != nil && *order.Price != *newPrice) {
+		order.PriorityAt = now
+	}
 	order.Quantity = newQuantity
 	if order.OrderType == "LIMIT" {
 		order.Price = newPrice
 	}
-	order.UpdatedAt = time.Now()
 
 	return s.orderRepo.Update(ctx, order)
 }
 
+// modifyStopOrder amends a PENDING stop, stop-limit or trailing-stop order. The amended order is validated
+// like a new one against the current price; a trailing stop keeps its high-water mark and re-trails from it.
+func (s *OrderService) modifyStopOrder(ctx context.Context, userID string, order *models.Order, amend OrderAmendment) (*models.Order, error) {
+	if amend.Quantity < 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if amend.Price != nil {
+		return nil, errors.New("price applies to limit orders; use stop price or limit price to amend a stop order")
+	}
+	if amend.LimitPrice != nil && order.OrderType != "STOP_LIMIT" {
+		return nil, errors.New("limit price can only be changed on stop-limit orders")
+	}
+	if amend.StopPrice != nil && order.OrderType == "TRAILING_STOP" {
+		return nil, errors.New("a trailing stop's price follows the market; change the trail amount instead")
+	}
+	if (amend.TrailAmount != nil || amend.TrailType != "") && order.OrderType != "TRAILING_STOP" {
+		return nil, errors.New("trail amount and type can only be changed on trailing stop orders")
+	}
+
+	instrument, err := s.instrumentRepo.FindByID(order.InstrumentID.Hex())
+	if err != nil || instrument == nil {
+		return nil, errors.New("instrument not found")
+	}
+	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, instrument.ID.Hex())
+	if err != nil || marketData == nil {
+		return nil, errors.New("market data unavailable for stop order validation")
+	}
+	currentPrice := marketData.LastPrice
+
+	amended := *order
+	if amend.Quantity != 0 && amend.Quantity != order.Quantity {
+		if order.Netting {
+			return nil, errors.New("cannot change the quantity of a netting order; cancel and place a new one")
+		}
+		if amend.Quantity%instrument.LotSize != 0 {
+			return nil, fmt.Errorf("quantity must be a multiple of lot size (%d)", instrument.LotSize)
+		}
+		amended.Quantity = amend.Quantity
+	}
+	if amend.StopPrice != nil {
+		amended.StopPrice = amend.StopPrice
+	}
+	if amend.LimitPrice != nil {
+		amended.LimitPrice = amend.LimitPrice
+	}
+	if amend.TrailAmount != nil {
+		amended.TrailAmount = amend.TrailAmount
+	}
+	if amend.TrailType != "" {
+		amended.TrailType = amend.TrailType
+	}
+
+	if err := s.validateStopOrder(&amended, instrument, currentPrice); err != nil {
+		return nil, err
+	}
+	if amended.OrderType == "TRAILING_STOP" && (amend.TrailAmount != nil || amend.TrailType != "") {
+		if err := s.retrailStop(&amended, currentPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	triggerPrice := s.stopReferencePrice(&amended)
+	increased := amended.Quantity > order.Quantity
+
+	// The order released on trigger must still be covered: cash for an opening BUY, margin and borrowable
+	// stock for an opening SELL, the position for a close
+	openQty := amended.Quantity
+	switch amended.Intent {
+	case string(models.IntentOpenLong):
+		requiredFunds := float64(amended.Quantity) * triggerPrice
+		if account.Balance-account.BlockedMargin < requiredFunds {
+			return nil, fmt.Errorf("insufficient funds. Required: ₹%0.2f, Available: ₹%0.2f", requiredFunds, account.Balance-account.BlockedMargin)
+		}
+	case string(models.IntentOpenShort):
+		if increased {
+			if err := s.checkShortStopIncrease(ctx, userID, account, instrument, &amended, triggerPrice); err != nil {
+				return nil, err
+			}
+		}
+	case string(models.IntentCloseLong), string(models.IntentCloseShort):
+		openQty = 0
+		if increased {
+			holding, _ := s.portfolioService.GetHolding(ctx, userID, instrument.ID.Hex())
+			if holding == nil || holding.Quantity < amended.Quantity {
+				return nil, errors.New("quantity exceeds the position this stop order closes")
+			}
+		}
+	}
+
+	// A larger order goes back through the pre-trade risk engine, as at placement
+	if increased {
+		if err := s.riskEngine.Evaluate(ctx, &PreTradeOrder{
+			Account:      account,
+			Instrument:   instrument,
+			Order:        &amended,
+			OpenQuantity: openQty,
+			Price:        triggerPrice,
+			Amending:     true,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.orderRepo.Update(ctx, &amended)
+}
+
+// checkShortStopIncrease repeats PlaceOrder's margin, leverage and borrow checks for a pending OPEN_SHORT
+// stop order whose quantity is raised. Stop orders locate on trigger, so borrow inventory is checked, not reserved.
+func (s *OrderService) checkShortStopIncrease(ctx context.Context, userID string, account *models.TradingAccount, instrument *models.Instrument, order *models.Order, price float64) error {
+	suitability, err := s.suitabilityService.GetProfile(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load suitability profile: %v", err)
+	}
+	params, err := s.marginService.GetRiskParams(ctx, instrument.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load risk parameters: %v", err)
+	}
+
+	positionValue := price * float64(order.Quantity)
+	requiredMargin := positionValue * params.InitialRateFor(order.ProductType)
+	if account.Balance-account.BlockedMargin < requiredMargin {
+		return fmt.Errorf("insufficient margin. Required: ₹%0.2f, Available: ₹%0.2f", requiredMargin, account.Balance-account.BlockedMargin)
+	}
+	maxLeverage := math.Min(params.MaxLeverageFor(order.ProductType), suitability.Eligibility.MaxLeverage)
+	if maxPositionValue := account.Balance * maxLeverage; positionValue > maxPositionValue {
+		return fmt.Errorf("position size exceeds maximum allowed (%.1fx leverage). Position value: ₹%.2f, Max allowed: ₹%.2f",
+			maxLeverage, positionValue, maxPositionValue)
+	}
+
+	return s.borrowService.CheckAvailable(ctx, instrument, order.Quantity)
+}
+
+// stopReferencePrice is the worst price a triggered stop order should fill at, for the funds check
+func (s *OrderService) stopReferencePrice(order *models.Order) float64 {
+	price := 0.0
+	if order.CurrentStopPrice != nil {
+		price = *order.CurrentStopPrice
+	} else if order.StopPrice != nil {
+		price = *order.StopPrice
+	}
+	if order.OrderType == "STOP_LIMIT" && order.LimitPrice != nil {
+		price = math.Max(price, *order.LimitPrice)
+	}
+	return price
+}
+
+// retrailStop recomputes a trailing stop after its trail changes. The best price seen so far is kept and
+// the stop re-trails from it, so loosening the trail can move the stop back; the new stop must not be
+// through the current price, or the order would trigger on the next check.
+func (s *OrderService) retrailStop(order *models.Order, currentPrice float64) error {
+	var stop float64
+	if order.Side == "SELL" {
+		high := currentPrice
+		if order.HighestPrice != nil && *order.HighestPrice > high {
+			high = *order.HighestPrice
+		}
+		order.HighestPrice = &high
+		if order.TrailType == "PERCENTAGE" {
+			stop = high * (1 - *order.TrailAmount/100)
+		} else {
+			stop = high - *order.TrailAmount
+		}
+		if stop >= currentPrice {
+			return fmt.Errorf("the new trail puts the SELL stop at ₹%.2f, at or above the current price (₹%.2f); use a wider trail", stop, currentPrice)
+		}
+	} else {
+		low := currentPrice
+		if order.LowestPrice != nil && *order.LowestPrice < low {
+			low = *order.LowestPrice
+		}
+		order.LowestPrice = &low
+		if order.TrailType == "PERCENTAGE" {
+			stop = low * (1 + *order.TrailAmount/100)
+		} else {
+			stop = low + *order.TrailAmount
+		}
+		if stop <= currentPrice {
+			return fmt.Errorf("the new trail puts the BUY stop at ₹%.2f, at or below the current price (₹%.2f); use a wider trail", stop, currentPrice)
+		}
+	}
+
+	order.CurrentStopPrice = &stop
+	order.StopPrice = &stop // Kept in step, as when the trail was initialised
+	return nil
+}
+
 // validateStopOrder validates stop-specific order fields
 func (s *OrderService) validateStopOrder(order *models.Order, instrument *models.Instrument, currentPrice float64) error {
 	// 1. Validate stop price for STOP and STOP_LIMIT orders
@@ -540,6 +1150,104 @@ func (s *OrderService) validateStopOrder(order *models.Order, instrument *models
 	return nil
 }
 
+// validateCondition checks a conditional order's clauses, fills in reference symbols, and
+// rejects conditions that already hold
+func (s *OrderService) validateCondition(ctx context.Context, order *models.Order, instrument *models.Instrument) error {
+	cond := order.Condition
+	if cond == nil || len(cond.Clauses) == 0 {
+		return errors.New("condition with at least one clause is required for conditional orders")
+	}
+	if len(cond.Clauses) > models.MaxConditionClauses {
+		return fmt.Errorf("a condition may combine at most %d clauses", models.MaxConditionClauses)
+	}
+	if cond.Logic == "" {
+		cond.Logic = models.ConditionLogicAnd
+	}
+	if cond.Logic != models.ConditionLogicAnd && cond.Logic != models.ConditionLogicOr {
+		return errors.New("condition logic must be AND or OR")
+	}
+
+	now := time.Now()
+	for i := range cond.Clauses {
+		c := &cond.Clauses[i]
+		if c.Type == models.ConditionTime {
+			if c.At == nil || !c.At.After(now) {
+				return errors.New("time condition must be in the future")
+			}
+			c.InstrumentID = nil
+			continue
+		}
+
+		if c.Comparator != models.ComparatorAbove && c.Comparator != models.ComparatorBelow {
+			return errors.New("condition comparator must be ABOVE or BELOW")
+		}
+		ref := instrument
+		if c.InstrumentID != nil && *c.InstrumentID != instrument.ID {
+			other, err := s.instrumentRepo.FindByID(c.InstrumentID.Hex())
+			if err != nil || other == nil || other.Status != "ACTIVE" {
+				return errors.New("condition instrument not found or inactive")
+			}
+			ref = other
+		}
+		c.InstrumentID = &ref.ID
+		c.Symbol = ref.Symbol
+
+		switch c.Type {
+		case models.ConditionPrice:
+			if c.Value <= 0 {
+				return errors.New("price condition level must be positive")
+			}
+		case models.ConditionChangeFromOpen:
+			if c.Value == 0 {
+				return errors.New("change-from-open condition needs a non-zero percentage")
+			}
+		case models.ConditionIndicator:
+			if c.Indicator != models.IndicatorSMA && c.Indicator != models.IndicatorEMA && c.Indicator != models.IndicatorRSI {
+				return errors.New("indicator must be SMA, EMA, or RSI")
+			}
+			if c.Period < 2 || c.Period > 50 {
+				return errors.New("indicator period must be between 2 and 50")
+			}
+			if c.Interval == "" {
+				c.Interval = string(Interval5m)
+			}
+			switch CandleInterval(c.Interval) {
+			case Interval1m, Interval5m, Interval15m, Interval1h, Interval1d:
+			default:
+				return errors.New("indicator interval must be 1m, 5m, 15m, 1h, or 1d")
+			}
+			if c.Indicator == models.IndicatorRSI && (c.Value <= 0 || c.Value >= 100) {
+				return errors.New("RSI level must be between 0 and 100")
+			}
+		default:
+			return errors.New("condition type must be TIME, PRICE, CHANGE_FROM_OPEN, or INDICATOR")
+		}
+	}
+
+	// A LIMIT price turns the triggered order into a LIMIT order
+	if order.LimitPrice != nil {
+		if *order.LimitPrice <= 0 {
+			return errors.New("limit price must be positive")
+		}
+		remainder := math.Mod(*order.LimitPrice, instrument.TickSize)
+		if remainder > 0.000001 && instrument.TickSize-remainder > 0.000001 {
+			return fmt.Errorf("limit price must be a multiple of tick size (%v)", instrument.TickSize)
+		}
+	}
+
+	// Every clause must be computable now, or it could never fire
+	eval := newConditionEvaluator(s.marketDataRepo, s.candleRepo)
+	for i := range cond.Clauses {
+		if _, err := eval.clauseMet(ctx, &cond.Clauses[i], now); err != nil {
+			return err
+		}
+	}
+	if met, _ := eval.Met(ctx, cond, now); met {
+		return errors.New("condition is already met; place a regular order instead")
+	}
+	return nil
+}
+
 // initializeTrailingStop initializes trailing stop fields based on current price
 func (s *OrderService) initializeTrailingStop(order *models.Order, currentPrice float64) {
 	if order.TrailAmount == nil || order.TrailType == "" {
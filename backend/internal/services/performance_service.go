@@ -0,0 +1,479 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+)
+
+const tradingDaysPerYear = 252
+
+type PerformanceService struct {
+	portfolioRepo  *repositories.PortfolioRepository
+	txRepo         *repositories.TransactionRepository
+	accountService *TradingAccountService
+	indexService   *IndexService
+}
+
+func NewPerformanceService(
+	portfolioRepo *repositories.PortfolioRepository,
+	txRepo *repositories.TransactionRepository,
+	accountService *TradingAccountService,
+	indexService *IndexService,
+) *PerformanceService {
+	return &PerformanceService{
+		portfolioRepo:  portfolioRepo,
+		txRepo:         txRepo,
+		accountService: accountService,
+		indexService:   indexService,
+	}
+}
+
+// PerformanceQuery controls the range and parameters of a performance report
+type PerformanceQuery struct {
+	From         time.Time
+	To           time.Time
+	Window       int     // Rolling volatility window in trading days
+	RiskFreeRate float64 // Annual, e.g. 0.065
+	Benchmark    string  // Index symbol; empty uses the default benchmark
+}
+
+type PerformancePoint struct {
+	Date             time.Time `json:"date"`
+	Equity           float64   `json:"equity"`
+	NetFlow          float64   `json:"netFlow"`          // External deposits/withdrawals since previous point
+	DailyReturn      float64   `json:"dailyReturn"`      // Flow-adjusted
+	CumulativeReturn float64   `json:"cumulativeReturn"` // Time-weighted, since start of range
+}
+
+type RollingPoint struct {
+	Date       time.Time `json:"date"`
+	Volatility float64   `json:"volatility"` // Annualised
+}
+
+type PeriodReturn struct {
+	Period string  `json:"period"` // YYYY-MM
+	Return float64 `json:"return"`
+}
+
+type DrawdownInfo struct {
+	MaxDrawdown  float64    `json:"maxDrawdown"` // Negative fraction, e.g. -0.12
+	PeakDate     *time.Time `json:"peakDate"`
+	TroughDate   *time.Time `json:"troughDate"`
+	RecoveryDate *time.Time `json:"recoveryDate"` // Nil if not yet recovered
+}
+
+// BenchmarkComparison measures the portfolio's daily returns against an index over the same days
+type BenchmarkComparison struct {
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name"`
+	Return           float64 `json:"return"`       // Benchmark return over the range
+	ExcessReturn     float64 `json:"excessReturn"` // Portfolio TWR - benchmark return
+	Alpha            float64 `json:"alpha"`        // Jensen's alpha, annualised
+	Beta             float64 `json:"beta"`
+	TrackingError    float64 `json:"trackingError"` // Annualised
+	InformationRatio float64 `json:"informationRatio"`
+	Observations     int     `json:"observations"`
+}
+
+type PerformanceReport struct {
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	StartValue float64   `json:"startValue"`
+	EndValue   float64   `json:"endValue"`
+	NetFlows   float64   `json:"netFlows"`
+	Profit     float64   `json:"profit"` // EndValue - StartValue - NetFlows
+
+	TWR           float64  `json:"twr"`
+	AnnualisedTWR *float64 `json:"annualisedTwr"` // Nil for windows under a year, which are not annualised
+	XIRR          *float64 `json:"xirr"`          // Nil when the cash flows have no solution
+
+	Volatility float64      `json:"volatility"` // Annualised
+	Sharpe     float64      `json:"sharpe"`
+	Sortino    float64      `json:"sortino"`
+	Drawdown   DrawdownInfo `json:"drawdown"`
+
+	Benchmark *BenchmarkComparison `json:"benchmark"` // Nil when no benchmark data covers the range
+
+	RollingVolatility []RollingPoint     `json:"rollingVolatility"`
+	MonthlyReturns    []PeriodReturn     `json:"monthlyReturns"`
+	Series            []PerformancePoint `json:"series"`
+}
+
+// GetPerformance builds a flow-adjusted performance report from portfolio snapshots and the cash ledger
+func (s *PerformanceService) GetPerformance(ctx context.Context, userID string, q PerformanceQuery) (*PerformanceReport, error) {
+	if !q.From.Before(q.To) {
+		return nil, errors.New("from must be before to")
+	}
+
+	account, err := s.accountService.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	snapshots, err := s.portfolioRepo.GetSnapshotsBetween(ctx, userID, q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshots: %w", err)
+	}
+
+	txs, err := s.txRepo.FindCompletedBetween(ctx, account.ID, q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	series := buildPerformanceSeries(snapshots, txs)
+	report := &PerformanceReport{
+		From:              q.From,
+		To:                q.To,
+		RollingVolatility: make([]RollingPoint, 0),
+		MonthlyReturns:    make([]PeriodReturn, 0),
+		Series:            series,
+	}
+	if len(series) < 2 {
+		return report, nil
+	}
+
+	first, last := series[0], series[len(series)-1]
+	report.StartValue = first.Equity
+	report.EndValue = last.Equity
+	for _, p := range series[1:] {
+		report.NetFlows += p.NetFlow
+	}
+	report.Profit = report.EndValue - report.StartValue - report.NetFlows
+
+	returns := make([]float64, 0, len(series)-1)
+	for _, p := range series[1:] {
+		returns = append(returns, p.DailyReturn)
+	}
+
+	report.TWR = last.CumulativeReturn
+	report.AnnualisedTWR = annualiseReturn(report.TWR, last.Date.Sub(first.Date).Hours()/24/365)
+
+	report.XIRR = computeXIRR(series)
+
+	mean, std := meanStdDev(returns)
+	report.Volatility = std * math.Sqrt(tradingDaysPerYear)
+	dailyRf := q.RiskFreeRate / tradingDaysPerYear
+	if std > 0 {
+		report.Sharpe = (mean - dailyRf) / std * math.Sqrt(tradingDaysPerYear)
+	}
+	if downside := downsideDeviation(returns, dailyRf); downside > 0 {
+		report.Sortino = (mean - dailyRf) / downside * math.Sqrt(tradingDaysPerYear)
+	}
+
+	report.Drawdown = computeDrawdown(series)
+	report.RollingVolatility = rollingVolatility(series, q.Window)
+	report.MonthlyReturns = monthlyReturns(series)
+
+	if s.indexService != nil {
+		benchmark, err := s.compareBenchmark(ctx, q, series)
+		if err != nil {
+			return nil, err
+		}
+		report.Benchmark = benchmark
+	}
+
+	return report, nil
+}
+
+// compareBenchmark aligns index closes to the portfolio series (last close at or before each day)
+// and derives alpha, beta, tracking error and information ratio from paired daily returns
+func (s *PerformanceService) compareBenchmark(ctx context.Context, q PerformanceQuery, series []PerformancePoint) (*BenchmarkComparison, error) {
+	index, err := s.indexService.ResolveBenchmark(ctx, q.Benchmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve benchmark: %w", err)
+	}
+	if index == nil {
+		if q.Benchmark != "" {
+			return nil, fmt.Errorf("benchmark %s not found", q.Benchmark)
+		}
+		return nil, nil
+	}
+
+	// Look back a week so the first portfolio day has a prior index close after weekends/holidays
+	values, err := s.indexService.GetDailyLevels(ctx, index, series[0].Date.AddDate(0, 0, -7), series[len(series)-1].Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get benchmark levels: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	levels := make([]float64, len(series))
+	j := -1
+	for i, p := range series {
+		for j+1 < len(values) && !values[j+1].Date.After(p.Date) {
+			j++
+		}
+		if j >= 0 {
+			levels[i] = values[j].Value
+		}
+	}
+
+	var portfolio, bench []float64
+	for i := 1; i < len(series); i++ {
+		if levels[i-1] <= 0 || levels[i] <= 0 {
+			continue
+		}
+		portfolio = append(portfolio, series[i].DailyReturn)
+		bench = append(bench, levels[i]/levels[i-1]-1)
+	}
+	if len(bench) < 2 {
+		return nil, nil
+	}
+
+	growth := 1.0
+	for _, r := range bench {
+		growth *= 1 + r
+	}
+
+	comparison := &BenchmarkComparison{
+		Symbol:       index.Symbol,
+		Name:         index.Name,
+		Return:       growth - 1,
+		ExcessReturn: series[len(series)-1].CumulativeReturn - (growth - 1),
+		Observations: len(bench),
+	}
+
+	meanP, _ := meanStdDev(portfolio)
+	meanB, stdB := meanStdDev(bench)
+	var cov float64
+	active := make([]float64, len(bench))
+	for i := range bench {
+		cov += (portfolio[i] - meanP) * (bench[i] - meanB)
+		active[i] = portfolio[i] - bench[i]
+	}
+	cov /= float64(len(bench) - 1)
+	if stdB > 0 {
+		comparison.Beta = cov / (stdB * stdB)
+	}
+
+	dailyRf := q.RiskFreeRate / tradingDaysPerYear
+	comparison.Alpha = (meanP - dailyRf - comparison.Beta*(meanB-dailyRf)) * tradingDaysPerYear
+
+	meanActive, stdActive := meanStdDev(active)
+	comparison.TrackingError = stdActive * math.Sqrt(tradingDaysPerYear)
+	if comparison.TrackingError > 0 {
+		comparison.InformationRatio = meanActive * tradingDaysPerYear / comparison.TrackingError
+	}
+	return comparison, nil
+}
+
+// buildPerformanceSeries keeps the last snapshot of each IST day and chain-links flow-adjusted daily returns.
+// External flows (deposits, withdrawals, adjustments, transfers) are assumed to arrive at the start of the day.
+func buildPerformanceSeries(snapshots []models.PortfolioSnapshot, txs []*models.Transaction) []PerformancePoint {
+	// Keyed by Unix seconds: each istDayBounds call loads its own *time.Location, so equal days differ as map keys
+	daily := make(map[int64]float64)
+	var days []time.Time
+	for _, snap := range snapshots {
+		day, _ := istDayBounds(snap.Date)
+		if _, seen := daily[day.Unix()]; !seen {
+			days = append(days, day)
+		}
+		daily[day.Unix()] = snap.TotalEquity // Ascending input: last write wins
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	flows := make(map[time.Time]float64)
+	for _, tx := range txs {
+		if !isExternalFlow(tx.Type) {
+			continue
+		}
+		day, _ := istDayBounds(tx.CreatedAt)
+		flows[day] += tx.Amount
+	}
+
+	series := make([]PerformancePoint, 0, len(days))
+	growth := 1.0
+	for i, day := range days {
+		point := PerformancePoint{Date: day, Equity: daily[day.Unix()]}
+		if i > 0 {
+			prev := series[i-1]
+			for d, amount := range flows {
+				if d.After(prev.Date) && !d.After(day) {
+					point.NetFlow += amount
+				}
+			}
+			if base := prev.Equity + point.NetFlow; base > 0 {
+				point.DailyReturn = (point.Equity - prev.Equity - point.NetFlow) / base
+			}
+			growth *= 1 + point.DailyReturn
+		}
+		point.CumulativeReturn = growth - 1
+		series = append(series, point)
+	}
+	return series
+}
+
+// annualiseReturn compounds a period return to a yearly rate. Periods under a year are not
+// annualised, since extrapolating a short run overstates it; the result is nil.
+func annualiseReturn(periodReturn, years float64) *float64 {
+	if years < 1 {
+		return nil
+	}
+	annual := math.Pow(1+periodReturn, 1/years) - 1
+	return &annual
+}
+
+// isExternalFlow reports ledger types that move money into or out of the account rather than earn a return.
+// Transfers between the user's own accounts are external to each account.
+func isExternalFlow(txType string) bool {
+	switch txType {
+	case "DEPOSIT", "WITHDRAWAL", "ADJUSTMENT", "TRANSFER_IN", "TRANSFER_OUT":
+		return true
+	}
+	return false
+}
+
+// computeXIRR solves for the annual rate where the investor's cash flows have zero NPV.
+// Starting equity and deposits are outflows for the investor; ending equity and withdrawals are inflows.
+func computeXIRR(series []PerformancePoint) *float64 {
+	type cashFlow struct {
+		years  float64
+		amount float64
+	}
+
+	start := series[0].Date
+	flows := []cashFlow{{0, -series[0].Equity}}
+	for _, p := range series[1:] {
+		if p.NetFlow != 0 {
+			flows = append(flows, cashFlow{p.Date.Sub(start).Hours() / 24 / 365, -p.NetFlow})
+		}
+	}
+	last := series[len(series)-1]
+	flows = append(flows, cashFlow{last.Date.Sub(start).Hours() / 24 / 365, last.Equity})
+
+	npv := func(rate float64) float64 {
+		var total float64
+		for _, f := range flows {
+			total += f.amount / math.Pow(1+rate, f.years)
+		}
+		return total
+	}
+
+	// Bisection is slower than Newton but cannot diverge on irregular flows
+	lo, hi := -0.9999, 10.0
+	fLo, fHi := npv(lo), npv(hi)
+	if math.IsNaN(fLo) || math.IsNaN(fHi) || fLo*fHi > 0 {
+		return nil
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		fMid := npv(mid)
+		if math.Abs(fMid) < 1e-7 || hi-lo < 1e-10 {
+			return &mid
+		}
+		if fLo*fMid < 0 {
+			hi = mid
+		} else {
+			lo, fLo = mid, fMid
+		}
+	}
+	rate := (lo + hi) / 2
+	return &rate
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+// downsideDeviation only penalises returns below the target
+func downsideDeviation(values []float64, target float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sq float64
+	for _, v := range values {
+		if v < target {
+			sq += (v - target) * (v - target)
+		}
+	}
+	return math.Sqrt(sq / float64(len(values)))
+}
+
+// computeDrawdown works on the time-weighted growth index so deposits don't mask losses
+func computeDrawdown(series []PerformancePoint) DrawdownInfo {
+	var info DrawdownInfo
+
+	peakIdx, maxPeakIdx, troughIdx := 0, -1, -1
+	for i, p := range series {
+		if p.CumulativeReturn > series[peakIdx].CumulativeReturn {
+			peakIdx = i
+		}
+		dd := (1+p.CumulativeReturn)/(1+series[peakIdx].CumulativeReturn) - 1
+		if dd < info.MaxDrawdown {
+			info.MaxDrawdown = dd
+			maxPeakIdx, troughIdx = peakIdx, i
+		}
+	}
+	if troughIdx < 0 {
+		return info
+	}
+
+	peakDate, troughDate := series[maxPeakIdx].Date, series[troughIdx].Date
+	info.PeakDate, info.TroughDate = &peakDate, &troughDate
+	for _, p := range series[troughIdx+1:] {
+		if p.CumulativeReturn >= series[maxPeakIdx].CumulativeReturn {
+			recovery := p.Date
+			info.RecoveryDate = &recovery
+			break
+		}
+	}
+	return info
+}
+
+func rollingVolatility(series []PerformancePoint, window int) []RollingPoint {
+	points := make([]RollingPoint, 0)
+	if window < 2 {
+		return points
+	}
+
+	returns := make([]float64, 0, len(series))
+	for _, p := range series[1:] {
+		returns = append(returns, p.DailyReturn)
+	}
+	for end := window; end <= len(returns); end++ {
+		_, std := meanStdDev(returns[end-window : end])
+		points = append(points, RollingPoint{
+			Date:       series[end].Date,
+			Volatility: std * math.Sqrt(tradingDaysPerYear),
+		})
+	}
+	return points
+}
+
+// monthlyReturns compounds daily returns within each calendar month
+func monthlyReturns(series []PerformancePoint) []PeriodReturn {
+	results := make([]PeriodReturn, 0)
+	for _, p := range series[1:] {
+		period := p.Date.Format("2006-01")
+		if len(results) == 0 || results[len(results)-1].Period != period {
+			results = append(results, PeriodReturn{Period: period})
+		}
+		last := &results[len(results)-1]
+		last.Return = (1+last.Return)*(1+p.DailyReturn) - 1
+	}
+	return results
+}
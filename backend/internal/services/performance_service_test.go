@@ -0,0 +1,159 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/utils"
+)
+
+func istDay(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, utils.GetISTTime().Location())
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestBuildPerformanceSeries(t *testing.T) {
+	d1, d2, d3 := istDay(2024, 1, 1), istDay(2024, 1, 2), istDay(2024, 1, 3)
+	snapshots := []models.PortfolioSnapshot{
+		{Date: d1.Add(10 * time.Hour), TotalEquity: 99000},
+		{Date: d1.Add(15 * time.Hour), TotalEquity: 100000}, // Later snapshot on the same day wins
+		{Date: d2.Add(15 * time.Hour), TotalEquity: 110000},
+		{Date: d3.Add(15 * time.Hour), TotalEquity: 99000},
+	}
+	txs := []*models.Transaction{
+		{Type: "DEPOSIT", Amount: 5000, CreatedAt: d2.Add(9 * time.Hour)},
+		{Type: "TRADE", Amount: -20000, CreatedAt: d2.Add(11 * time.Hour)},
+		{Type: "TRANSFER_OUT", Amount: -10000, CreatedAt: d3.Add(9 * time.Hour)},
+	}
+
+	series := buildPerformanceSeries(snapshots, txs)
+	want := []struct {
+		date             time.Time
+		equity, netFlow  float64
+		dailyReturn, cum float64
+	}{
+		{d1, 100000, 0, 0, 0},
+		{d2, 110000, 5000, 5000.0 / 105000, 5000.0 / 105000},
+		{d3, 99000, -10000, -1000.0 / 100000, (1+5000.0/105000)*(1-0.01) - 1},
+	}
+
+	if len(series) != len(want) {
+		t.Fatalf("len(series) = %d, want %d", len(series), len(want))
+	}
+	for i, w := range want {
+		p := series[i]
+		if !p.Date.Equal(w.date) || p.Equity != w.equity || p.NetFlow != w.netFlow ||
+			!approxEqual(p.DailyReturn, w.dailyReturn) || !approxEqual(p.CumulativeReturn, w.cum) {
+			t.Errorf("series[%d] = %+v, want %+v", i, p, w)
+		}
+	}
+}
+
+func TestComputeXIRR(t *testing.T) {
+	start := istDay(2022, 1, 1)
+	year := func(n int) time.Time { return start.AddDate(0, 0, 365*n) }
+
+	tests := []struct {
+		name   string
+		series []PerformancePoint
+		want   *float64
+	}{
+		{
+			name:   "ten percent over one year",
+			series: []PerformancePoint{{Date: start, Equity: 100}, {Date: year(1), Equity: 110}},
+			want:   floatPtr(0.10),
+		},
+		{
+			name:   "ten percent loss",
+			series: []PerformancePoint{{Date: start, Equity: 100}, {Date: year(1), Equity: 90}},
+			want:   floatPtr(-0.10),
+		},
+		{
+			name:   "compounded over two years",
+			series: []PerformancePoint{{Date: start, Equity: 100}, {Date: year(2), Equity: 121}},
+			want:   floatPtr(0.10),
+		},
+		{
+			name: "deposit midway",
+			series: []PerformancePoint{
+				{Date: start, Equity: 100},
+				{Date: year(1), Equity: 120, NetFlow: 10},
+				{Date: year(2), Equity: 132},
+			},
+			want: floatPtr(0.10),
+		},
+		{
+			name:   "total loss has no rate",
+			series: []PerformancePoint{{Date: start, Equity: 100}, {Date: year(1), Equity: 0}},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeXIRR(tt.series)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("computeXIRR() = %v, want %v", got, tt.want)
+			}
+			if got != nil && math.Abs(*got-*tt.want) > 1e-4 {
+				t.Errorf("computeXIRR() = %v, want %v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnualiseReturn(t *testing.T) {
+	tests := []struct {
+		name  string
+		r     float64
+		years float64
+		want  *float64
+	}{
+		{"under a year is not annualised", 0.05, 0.5, nil},
+		{"one year is unchanged", 0.10, 1, floatPtr(0.10)},
+		{"two years compound", 0.21, 2, floatPtr(0.10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := annualiseReturn(tt.r, tt.years)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("annualiseReturn() = %v, want %v", got, tt.want)
+			}
+			if got != nil && !approxEqual(*got, *tt.want) {
+				t.Errorf("annualiseReturn() = %v, want %v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExternalFlow(t *testing.T) {
+	tests := []struct {
+		txType string
+		want   bool
+	}{
+		{"DEPOSIT", true},
+		{"WITHDRAWAL", true},
+		{"ADJUSTMENT", true},
+		{"TRANSFER_IN", true},
+		{"TRANSFER_OUT", true},
+		{"TRADE", false},
+		{"FEE", false},
+		{"DIVIDEND", false},
+	}
+
+	for _, tt := range tests {
+		if got := isExternalFlow(tt.txType); got != tt.want {
+			t.Errorf("isExternalFlow(%q) = %v, want %v", tt.txType, got, tt.want)
+		}
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
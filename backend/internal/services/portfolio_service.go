@@ -9,6 +9,9 @@ import (
 
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type PortfolioService struct {
@@ -16,6 +19,8 @@ type PortfolioService struct {
 	marketService    *MarketService
 	accountService   *TradingAccountService
 	analyticsService *AnalyticsService
+	marginService    *MarginService
+	gttRepo          *repositories.GTTRepository
 }
 
 func NewPortfolioService(
@@ -23,12 +28,16 @@ func NewPortfolioService(
 	marketService *MarketService,
 	accountService *TradingAccountService,
 	analyticsService *AnalyticsService,
+	marginService *MarginService,
+	gttRepo *repositories.GTTRepository,
 ) *PortfolioService {
 	return &PortfolioService{
 		portfolioRepo:    portfolioRepo,
 		marketService:    marketService,
 		accountService:   accountService,
 		analyticsService: analyticsService,
+		marginService:    marginService,
+		gttRepo:          gttRepo,
 	}
 }
 
@@ -66,6 +75,7 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 
 	totalTradeCost := trade.Price * float64(trade.Quantity)
 	fees := trade.Commission + trade.Fees
+	productType := productTypeOf(trade.ProductType)
 
 	// Determine Intent if missing (Backward Compatibility)
 	intent := trade.Intent
@@ -87,6 +97,7 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 				Symbol:        trade.Symbol,
 				Quantity:      trade.Quantity,
 				PositionType:  models.PositionLong,
+				ProductType:   productType,
 				AvgEntryPrice: trade.Price, // Initial price
 				TotalCost:     totalTradeCost,
 				TotalFees:     fees,
@@ -95,9 +106,12 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 				MarginStatus:  models.MarginOK,
 			}
 		} else {
-			if holding.PositionType == models.PositionShort {
+			if holding.Quantity > 0 && holding.PositionType == models.PositionShort {
 				return errors.New("cannot open long on existing short position. Use CLOSE_SHORT")
 			}
+			if holding.Quantity == 0 {
+				resetFlatHolding(holding, productType)
+			}
 			// timeTypedQty removed
 			newTotalCost := holding.TotalCost + totalTradeCost
 			newQuantity := holding.Quantity + trade.Quantity
@@ -146,6 +160,7 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 				Symbol:        trade.Symbol,
 				Quantity:      trade.Quantity,
 				PositionType:  models.PositionShort,
+				ProductType:   productType,
 				AvgEntryPrice: trade.Price,    // Entry price for short
 				TotalCost:     totalTradeCost, // Tracks total value shorted (Liability)
 				TotalFees:     fees,
@@ -154,9 +169,12 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 				MarginStatus:  models.MarginOK,
 			}
 		} else {
-			if holding.PositionType == models.PositionLong {
+			if holding.Quantity > 0 && holding.PositionType == models.PositionLong {
 				return errors.New("cannot open short on existing long position")
 			}
+			if holding.Quantity == 0 {
+				resetFlatHolding(holding, productType)
+			}
 			newTotalCost := holding.TotalCost + totalTradeCost
 			newQuantity := holding.Quantity + trade.Quantity
 
@@ -172,13 +190,18 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 		}
 
 		// BLOCK MARGIN LOGIC
-		// Requirement: 20% of Value
-		marginToBlock := totalTradeCost * 0.20
+		// Requirement: instrument initial rate (VaR + ELM, scaled for MIS) of value; re-marked by the margin monitor
+		params, err := s.marginService.GetRiskParams(ctx, trade.InstrumentID)
+		if err != nil {
+			return fmt.Errorf("failed to load risk parameters: %v", err)
+		}
+		marginToBlock := totalTradeCost * params.InitialRateFor(holding.ProductType)
 		if err := s.accountService.BlockMargin(ctx, userID, marginToBlock); err != nil {
 			return fmt.Errorf("failed to block margin: %v", err)
 		}
 		holding.BlockedMargin += marginToBlock
 		holding.InitialMargin += marginToBlock
+		holding.MaintenanceMargin += totalTradeCost * params.MaintenanceRateFor(holding.ProductType)
 
 	} else if intent == string(models.IntentCloseShort) {
 		// --- CLOSE SHORT (Buy to Cover) ---
@@ -219,6 +242,7 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 		}
 
 		holding.Quantity -= trade.Quantity
+		holding.MaintenanceMargin *= float64(holding.Quantity) / preTradeQty
 		holding.TotalCost = float64(holding.Quantity) * holding.AvgEntryPrice
 		holding.RealizedPL += pnl
 		holding.TotalFees += fees
@@ -236,34 +260,104 @@ func (s *PortfolioService) UpdatePosition(ctx context.Context, trade *models.Tra
 		return err
 	}
 
+	// Resize or cancel GTT triggers attached to the position
+	if err := s.gttRepo.SyncPosition(ctx, holding.UserID, holding.InstrumentID, holding.Quantity, holding.PositionType); err != nil {
+		return fmt.Errorf("failed to sync GTT triggers: %v", err)
+	}
+
 	log.Printf("[Portfolio] Position updated successfully. New Qty: %d, Avg: %.2f", holding.Quantity, holding.AvgEntryPrice)
+	return nil
+}
 
-	// Trigger Analytics Engine (Diagnostics)
+// resetFlatHolding starts a new position on the row left by a fully closed one; realized P&L and fees carry over
+func resetFlatHolding(holding *models.Holding, productType string) {
+	holding.ProductType = productType
+	holding.AvgEntryPrice = 0
+	holding.TotalCost = 0
+	holding.BlockedMargin = 0
+	holding.InitialMargin = 0
+	holding.MaintenanceMargin = 0
+	holding.MarginStatus = models.MarginOK
+}
+
+// ProcessTradeAnalytics feeds committed trades to the analytics engine in fill order,
+// so the slices of a reversal close the old trade unit before the new one opens
+func (s *PortfolioService) ProcessTradeAnalytics(trades []*models.Trade) {
 	go func() {
-		if err := s.analyticsService.ProcessTrade(context.Background(), trade); err != nil {
-			log.Printf("[Portfolio] Analytics processing error: %v", err)
+		for _, trade := range trades {
+			if err := s.analyticsService.ProcessTrade(context.Background(), trade); err != nil {
+				log.Printf("[Portfolio] Analytics processing error: %v", err)
+			}
 		}
 	}()
-
-	return nil
 }
 
-// CaptureSnapshot calculates current portfolio value and saves a snapshot
+// CaptureSnapshot calculates current portfolio value and saves an intraday snapshot
 func (s *PortfolioService) CaptureSnapshot(ctx context.Context, userID string) (*models.PortfolioSnapshot, error) {
+	snapshot, holdings, err := s.buildSnapshot(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Kind = models.SnapshotKindIntraday
+
+	// CRITICAL: Circuit Breaker for Negative Equity
+	// This prevents platform from owing money due to unlimited short losses
+	if snapshot.TotalEquity < 0 {
+		log.Printf("🚨 CRITICAL ALERT: User %s has NEGATIVE EQUITY: %.2f", userID, snapshot.TotalEquity)
+		log.Printf("   Cash Balance: %.2f, Holdings Value: %.2f", snapshot.CashBalance, snapshot.HoldingsValue)
+
+		// Log all short positions for debugging
+		for _, h := range holdings {
+			if h.PositionType == models.PositionShort {
+				log.Printf("   Short Position: %s Qty:%d AvgEntry:%.2f BlockedMargin:%.2f",
+					h.Symbol, h.Quantity, h.AvgEntryPrice, h.BlockedMargin)
+			}
+		}
+
+		// Alert only: the margin monitor liquidates once two consecutive readings confirm the breach
+	}
+
+	if err := s.portfolioRepo.CreateSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// CaptureEODSnapshot writes the official snapshot for a trading day at session close.
+// Last traded prices are the closing prices, so staleness is not a reason to fall back to cost.
+func (s *PortfolioService) CaptureEODSnapshot(ctx context.Context, userID string, tradingDate time.Time) (*models.PortfolioSnapshot, error) {
+	snapshot, _, err := s.buildSnapshot(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Kind = models.SnapshotKindEOD
+	snapshot.TradingDate = &tradingDate
+
+	if err := s.portfolioRepo.UpsertEODSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// buildSnapshot values cash and holdings at current prices without persisting
+func (s *PortfolioService) buildSnapshot(ctx context.Context, userID string, acceptStale bool) (*models.PortfolioSnapshot, []models.Holding, error) {
 	// 1. Get Cash Balance
 	account, err := s.accountService.GetByUserID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, nil, fmt.Errorf("failed to get account: %w", err)
 	}
+	ctx = utils.WithAccountID(ctx, account.ID.Hex())
 
 	// 2. Get Holdings
 	holdings, err := s.portfolioRepo.GetHoldings(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get holdings: %w", err)
+		return nil, nil, fmt.Errorf("failed to get holdings: %w", err)
 	}
 
 	// 3. Calculate Holdings Value using Live Prices
 	var holdingsValue float64
+	breakdown := make([]models.SnapshotHolding, 0, len(holdings))
 	var instrumentIDs []string
 	for _, h := range holdings {
 		instrumentIDs = append(instrumentIDs, h.InstrumentID.Hex())
@@ -273,16 +367,14 @@ func (s *PortfolioService) CaptureSnapshot(ctx context.Context, userID string) (
 		prices, err := s.marketService.GetBatchPrices(ctx, instrumentIDs)
 		if err != nil {
 			log.Printf("Failed to get prices for snapshot: %v", err)
-			// Decide: Fail or continue with AvgCost? Let's fail for now to ensure data quality
-			// Or soft fail? Let's soft fail and use AvgCost as fallback if price logic is strict
-			// But GetBatchPrices should return what it has.
+			// Soft fail: GetBatchPrices returns what it has, missing prices fall back to AvgEntryPrice
 		}
 
 		priceMap := make(map[string]float64)
 		staleCount := 0
 		for _, p := range prices {
 			// Check if price data is stale (>1 minute old)
-			if time.Since(p.UpdatedAt) > 1*time.Minute {
+			if !acceptStale && time.Since(p.UpdatedAt) > 1*time.Minute {
 				log.Printf("[Portfolio] WARNING: Stale market data for instrument %s (age: %v). Using fallback pricing.",
 					p.InstrumentID.Hex(), time.Since(p.UpdatedAt))
 				staleCount++
@@ -301,55 +393,45 @@ func (s *PortfolioService) CaptureSnapshot(ctx context.Context, userID string) (
 				price = h.AvgEntryPrice // Fallback
 			}
 
-			value := float64(h.Quantity) * price
-			if h.PositionType == models.PositionShort {
-				holdingsValue -= value // Liability subtracts from equity
-			} else {
-				holdingsValue += value // Asset adds to equity
-			}
+			line := valueSnapshotHolding(h.InstrumentID, h.Symbol, h.PositionType, h.Quantity, h.AvgEntryPrice, price)
+			holdingsValue += line.MarketValue // Shorts are negative: liability subtracts from equity
+			breakdown = append(breakdown, line)
 		}
 	}
 
-	// 4. Create Snapshot
-	totalEquity := account.Balance + holdingsValue
-
-	snapshot := &models.PortfolioSnapshot{
+	// 4. Build Snapshot
+	now := time.Now()
+	return &models.PortfolioSnapshot{
 		UserID:        account.UserID,
-		Date:          time.Now(),
-		TotalEquity:   totalEquity,
+		AccountID:     account.ID,
+		Source:        models.SnapshotSourceLive,
+		Date:          now,
+		TotalEquity:   account.Balance + holdingsValue,
 		CashBalance:   account.Balance,
 		HoldingsValue: holdingsValue,
-		CreatedAt:     time.Now(),
-	}
-
-	// CRITICAL: Circuit Breaker for Negative Equity
-	// This prevents platform from owing money due to unlimited short losses
-	if totalEquity < 0 {
-		log.Printf("🚨 CRITICAL ALERT: User %s has NEGATIVE EQUITY: %.2f", userID, totalEquity)
-		log.Printf("   Cash Balance: %.2f, Holdings Value: %.2f", account.Balance, holdingsValue)
-
-		// Log all short positions for debugging
-		for _, h := range holdings {
-			if h.PositionType == models.PositionShort {
-				log.Printf("   Short Position: %s Qty:%d AvgEntry:%.2f BlockedMargin:%.2f",
-					h.Symbol, h.Quantity, h.AvgEntryPrice, h.BlockedMargin)
-			}
-		}
+		Holdings:      breakdown,
+		CreatedAt:     now,
+	}, holdings, nil
+}
 
-		// TODO: Implement auto-liquidation
-		// For now, just log critical alert
-		// In production, this should:
-		// 1. Force liquidate all short positions immediately
-		// 2. Notify admin via PagerDuty/email
-		// 3. Lock account to prevent further trading
-		log.Printf("⚠️  AUTO-LIQUIDATION NOT IMPLEMENTED - Manual intervention required!")
+// valueSnapshotHolding prices a position; short market value is negative
+func valueSnapshotHolding(instrumentID primitive.ObjectID, symbol string, positionType models.PositionType, qty int, avgEntry, price float64) models.SnapshotHolding {
+	value := float64(qty) * price
+	pnl := (price - avgEntry) * float64(qty)
+	if positionType == models.PositionShort {
+		value = -value
+		pnl = -pnl
 	}
-
-	if err := s.portfolioRepo.CreateSnapshot(ctx, snapshot); err != nil {
-		return nil, err
+	return models.SnapshotHolding{
+		InstrumentID:  instrumentID,
+		Symbol:        symbol,
+		PositionType:  positionType,
+		Quantity:      qty,
+		AvgEntryPrice: avgEntry,
+		Price:         price,
+		MarketValue:   value,
+		UnrealizedPL:  pnl,
 	}
-
-	return snapshot, nil
 }
 
 // GetSnapshotHistory returns snapshots for charts
@@ -16,6 +16,7 @@ type PricingService struct {
 	candleRepo        *repositories.CandleRepository
 	candleBuilder     *CandleBuilder
 	priceAlertService *PriceAlertService
+	tickFunc          func(ctx context.Context)
 	stopChan          chan struct{}
 	rng               *rand.Rand
 }
@@ -40,6 +41,11 @@ func NewPricingService(
 	}
 }
 
+// SetTickFunc sets a hook run after every pricing pass, once all prices are updated
+func (s *PricingService) SetTickFunc(fn func(ctx context.Context)) {
+	s.tickFunc = fn
+}
+
 func (s *PricingService) Start() {
 	ticker := time.NewTicker(3 * time.Second)
 	go func() {
@@ -71,6 +77,10 @@ func (s *PricingService) simulatePrices() {
 	}
 
 	for _, inst := range instruments {
+		if inst.Type == models.InstrumentTypeIndex {
+			continue // Index levels are derived by IndexService
+		}
+
 		data, err := s.marketDataRepo.FindByInstrumentID(ctx, inst.ID.Hex())
 		if err != nil {
 			log.Printf("Pricing engine error: failed to fetch market data for %s: %v", inst.Symbol, err)
@@ -159,4 +169,8 @@ func (s *PricingService) simulatePrices() {
 			log.Printf("Pricing engine error: failed to update %s: %v", inst.Symbol, err)
 		}
 	}
+
+	if s.tickFunc != nil {
+		go s.tickFunc(context.Background())
+	}
 }
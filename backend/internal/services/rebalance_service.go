@@ -0,0 +1,602 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RebalanceService trades a portfolio back to target weights and watches saved models for drift
+type RebalanceService struct {
+	modelRepo           *repositories.RebalanceModelRepository
+	instrumentRepo      *repositories.InstrumentRepository
+	marketDataRepo      *repositories.MarketDataRepository
+	tradingAccountRepo  *repositories.TradingAccountRepository
+	portfolioService    *PortfolioService
+	chargeService       *ChargeService
+	basketService       *BasketService
+	notificationService *NotificationService
+	auditService        *AuditService
+	stopChan            chan struct{}
+}
+
+func NewRebalanceService(
+	modelRepo *repositories.RebalanceModelRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	tradingAccountRepo *repositories.TradingAccountRepository,
+	portfolioService *PortfolioService,
+	chargeService *ChargeService,
+	basketService *BasketService,
+	notificationService *NotificationService,
+	auditService *AuditService,
+) *RebalanceService {
+	return &RebalanceService{
+		modelRepo:           modelRepo,
+		instrumentRepo:      instrumentRepo,
+		marketDataRepo:      marketDataRepo,
+		tradingAccountRepo:  tradingAccountRepo,
+		portfolioService:    portfolioService,
+		chargeService:       chargeService,
+		basketService:       basketService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// RebalanceRequest describes target weights, either for a one-off rebalance or a saved model
+type RebalanceRequest struct {
+	Name          string                   `json:"name"`
+	TargetType    string                   `json:"targetType"`
+	Targets       []models.RebalanceTarget `json:"targets"`
+	TolerancePct  float64                  `json:"tolerancePct"`
+	DriftAlertPct float64                  `json:"driftAlertPct"`
+	OrderType     string                   `json:"orderType"`
+}
+
+func (s *RebalanceService) Start() {
+	ticker := time.NewTicker(15 * time.Minute)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.CheckDrift(context.Background())
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("Rebalance drift monitor started (checking every 15m)")
+}
+
+func (s *RebalanceService) Stop() {
+	close(s.stopChan)
+}
+
+// Preview computes the orders that would bring the portfolio back to the targets
+func (s *RebalanceService) Preview(ctx context.Context, userID string, req RebalanceRequest) (*models.RebalancePlan, error) {
+	model, err := modelFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.plan(ctx, userID, model)
+}
+
+// Rebalance computes the plan and submits its orders as a basket
+func (s *RebalanceService) Rebalance(ctx context.Context, userID string, req RebalanceRequest, allOrNone bool) (*models.RebalancePlan, *models.BasketPlacement, error) {
+	model, err := modelFromRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.execute(ctx, userID, model, allOrNone)
+}
+
+func (s *RebalanceService) GetModels(ctx context.Context, userID string) ([]models.RebalanceModel, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.modelRepo.FindByUserID(ctx, uid)
+}
+
+func (s *RebalanceService) GetModel(ctx context.Context, userID, modelID string) (*models.RebalanceModel, error) {
+	return s.getOwned(ctx, userID, modelID)
+}
+
+// SaveModel creates a model for the account in scope, or replaces an existing one when modelID is set
+func (s *RebalanceService) SaveModel(ctx context.Context, userID, modelID string, req RebalanceRequest) (*models.RebalanceModel, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("model name is required")
+	}
+	model, err := modelFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	model.Name = req.Name
+
+	var old *models.RebalanceModel
+	if modelID != "" {
+		existing, err := s.getOwned(ctx, userID, modelID)
+		if err != nil {
+			return nil, err
+		}
+		snapshot := *existing
+		old = &snapshot
+		model.ID, model.UserID, model.AccountID, model.CreatedAt = existing.ID, existing.UserID, existing.AccountID, existing.CreatedAt
+		model.LastRebalancedAt = existing.LastRebalancedAt
+	} else {
+		account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+		if err != nil || account == nil {
+			return nil, errors.New("trading account not found")
+		}
+		model.UserID = account.UserID
+		model.AccountID = account.ID
+	}
+
+	// Resolve symbols and check the targets against the current portfolio before saving
+	if _, err := s.plan(utils.WithAccountID(ctx, model.AccountID.Hex()), userID, model); err != nil {
+		return nil, err
+	}
+
+	if old == nil {
+		err = s.modelRepo.Create(ctx, model)
+	} else {
+		err = s.modelRepo.Update(ctx, model)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("a model named %q already exists", model.Name)
+		}
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "REBALANCE_MODEL_SAVED", model.ID.Hex(), "REBALANCE_MODEL",
+		fmt.Sprintf("Rebalance model %q with %d %s targets", model.Name, len(model.Targets), model.TargetType), old, model)
+	return model, nil
+}
+
+func (s *RebalanceService) DeleteModel(ctx context.Context, userID, modelID string) error {
+	model, err := s.getOwned(ctx, userID, modelID)
+	if err != nil {
+		return err
+	}
+	if err := s.modelRepo.Delete(ctx, model.ID); err != nil {
+		return err
+	}
+	s.auditService.LogFromContext(ctx, "REBALANCE_MODEL_DELETED", model.ID.Hex(), "REBALANCE_MODEL",
+		fmt.Sprintf("Rebalance model %q deleted", model.Name), model, nil)
+	return nil
+}
+
+// PreviewModel computes the orders a saved model would place now
+func (s *RebalanceService) PreviewModel(ctx context.Context, userID, modelID string) (*models.RebalancePlan, error) {
+	model, err := s.getOwned(ctx, userID, modelID)
+	if err != nil {
+		return nil, err
+	}
+	return s.plan(utils.WithAccountID(ctx, model.AccountID.Hex()), userID, model)
+}
+
+// ExecuteModel rebalances the model's account to its targets
+func (s *RebalanceService) ExecuteModel(ctx context.Context, userID, modelID string, allOrNone bool) (*models.RebalancePlan, *models.BasketPlacement, error) {
+	model, err := s.getOwned(ctx, userID, modelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, placement, err := s.execute(utils.WithAccountID(ctx, model.AccountID.Hex()), userID, model, allOrNone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	model.LastRebalancedAt = &now
+	model.LastDrift = plan.MaxDrift
+	if err := s.modelRepo.Update(ctx, model); err != nil {
+		log.Printf("Failed to update rebalance model %s: %v", model.ID.Hex(), err)
+	}
+	return plan, placement, nil
+}
+
+// CheckDrift notifies owners of models whose largest drift has passed their alert threshold, once per day
+func (s *RebalanceService) CheckDrift(ctx context.Context) {
+	rebalanceModels, err := s.modelRepo.FindWithDriftAlerts(ctx)
+	if err != nil {
+		log.Printf("Rebalance monitor error: failed to fetch models: %v", err)
+		return
+	}
+
+	today, _ := istDayBounds(time.Now())
+	for i := range rebalanceModels {
+		model := &rebalanceModels[i]
+		plan, err := s.plan(utils.WithAccountID(ctx, model.AccountID.Hex()), model.UserID.Hex(), model)
+		if err != nil {
+			continue
+		}
+
+		now := time.Now()
+		model.LastDrift = plan.MaxDrift
+		model.LastCheckedAt = &now
+		alert := plan.MaxDrift >= model.DriftAlertPct && (model.LastAlertAt == nil || model.LastAlertAt.Before(today))
+		if alert {
+			model.LastAlertAt = &now
+		}
+		if err := s.modelRepo.Update(ctx, model); err != nil {
+			log.Printf("Rebalance monitor error: failed to update model %s: %v", model.ID.Hex(), err)
+			continue
+		}
+		if !alert {
+			continue
+		}
+
+		message := fmt.Sprintf("Your portfolio has drifted %.1f%% from the %q model (alert at %.1f%%). %d orders would rebalance it.",
+			plan.MaxDrift, model.Name, model.DriftAlertPct, len(plan.Orders))
+		data := map[string]interface{}{"modelId": model.ID.Hex(), "maxDrift": plan.MaxDrift}
+		go func(userID string) {
+			_ = s.notificationService.SendNotification(context.Background(), userID,
+				models.NotificationTypeAlert, "Portfolio Drift Alert", message, data, nil)
+		}(model.UserID.Hex())
+	}
+}
+
+func (s *RebalanceService) execute(ctx context.Context, userID string, model *models.RebalanceModel, allOrNone bool) (*models.RebalancePlan, *models.BasketPlacement, error) {
+	plan, err := s.plan(ctx, userID, model)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(plan.Orders) == 0 {
+		return nil, nil, errors.New("portfolio is already within tolerance of its targets")
+	}
+
+	items := make([]models.BasketItem, len(plan.Orders))
+	for i, order := range plan.Orders {
+		items[i] = models.BasketItem{
+			InstrumentID: order.InstrumentID,
+			Symbol:       order.Symbol,
+			Side:         order.Side,
+			OrderType:    model.OrderType,
+			Quantity:     order.Quantity,
+			Intent:       string(models.IntentOpenLong),
+			ProductType:  models.ProductTypeCNC,
+		}
+		if order.Side == "SELL" {
+			items[i].Intent = string(models.IntentCloseLong)
+		}
+		if model.OrderType == "LIMIT" {
+			price := order.Price
+			items[i].Price = &price
+		}
+	}
+
+	placement, err := s.basketService.PlaceBasket(ctx, userID, items, allOrNone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "REBALANCE_SUBMITTED", placement.BasketID, "ORDER",
+		fmt.Sprintf("Rebalance to %d %s targets: %d orders placed, %d failed (max drift %.2f%%)",
+			len(model.Targets), model.TargetType, placement.Placed, placement.Failed, plan.MaxDrift), nil, plan)
+	return plan, placement, nil
+}
+
+// rebalanceGroup is one target and the instruments that make it up
+type rebalanceGroup struct {
+	row     *models.RebalanceRow
+	members []primitive.ObjectID
+}
+
+// plan compares the account's CNC long holdings and cash with the targets and sizes the fewest lot-rounded
+// orders that bring every out-of-band target back to weight. Holdings the targets do not cover are left alone.
+func (s *RebalanceService) plan(ctx context.Context, userID string, model *models.RebalanceModel) (*models.RebalancePlan, error) {
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	ctx = utils.WithAccountID(ctx, account.ID.Hex())
+
+	holdingList, err := s.portfolioService.GetHoldings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holdings: %v", err)
+	}
+	held := make(map[primitive.ObjectID]int)
+	for _, holding := range holdingList {
+		if holding.Quantity > 0 && holding.PositionType != models.PositionShort && productTypeOf(holding.ProductType) == models.ProductTypeCNC {
+			held[holding.InstrumentID] += holding.Quantity
+		}
+	}
+
+	// 1. Load every instrument the targets or holdings mention, with quotes, in one query each
+	ids := make([]primitive.ObjectID, 0)
+	for id := range held {
+		ids = append(ids, id)
+	}
+	for _, target := range model.Targets {
+		if target.InstrumentID != nil {
+			ids = append(ids, *target.InstrumentID)
+		}
+		ids = append(ids, target.Instruments...)
+	}
+	instrumentList, err := s.instrumentRepo.FindAll(map[string]interface{}{"_id": map[string]interface{}{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	instruments := make(map[primitive.ObjectID]*models.Instrument, len(instrumentList))
+	hexIDs := make([]string, 0, len(instrumentList))
+	for _, instrument := range instrumentList {
+		instruments[instrument.ID] = instrument
+		hexIDs = append(hexIDs, instrument.ID.Hex())
+	}
+	quoteList, err := s.marketDataRepo.FindByInstrumentIDs(ctx, hexIDs)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[primitive.ObjectID]float64, len(quoteList))
+	for _, quote := range quoteList {
+		prices[quote.InstrumentID] = quote.LastPrice
+	}
+
+	// 2. Resolve each target to its instruments
+	groups := make([]rebalanceGroup, 0, len(model.Targets))
+	claimed := make(map[primitive.ObjectID]string)
+	claim := func(id primitive.ObjectID, key string) error {
+		instrument := instruments[id]
+		if instrument == nil || instrument.Status != "ACTIVE" || instrument.Type == models.InstrumentTypeIndex {
+			return fmt.Errorf("%s: instrument not found or inactive", key)
+		}
+		if prices[id] <= 0 {
+			return fmt.Errorf("market data unavailable for %s", instrument.Symbol)
+		}
+		if other, ok := claimed[id]; ok {
+			return fmt.Errorf("%s is covered by both %s and %s", instrument.Symbol, other, key)
+		}
+		claimed[id] = key
+		return nil
+	}
+	for i := range model.Targets {
+		target := &model.Targets[i]
+		group := rebalanceGroup{row: &models.RebalanceRow{TargetWeight: target.Weight}}
+		if model.TargetType == models.RebalanceByInstrument {
+			instrument := instruments[*target.InstrumentID]
+			if instrument != nil {
+				target.Symbol = instrument.Symbol
+			}
+			group.row.Key = target.Symbol
+			if err := claim(*target.InstrumentID, target.Symbol); err != nil {
+				return nil, err
+			}
+			group.members = []primitive.ObjectID{*target.InstrumentID}
+		} else {
+			group.row.Key = target.Sector
+			for id := range held {
+				if instrument := instruments[id]; instrument != nil && strings.EqualFold(instrument.Sector, target.Sector) {
+					if err := claim(id, target.Sector); err != nil {
+						return nil, err
+					}
+					group.members = append(group.members, id)
+				}
+			}
+			for _, id := range target.Instruments {
+				if _, ok := held[id]; ok {
+					continue // Already in the sector's holdings
+				}
+				if instrument := instruments[id]; instrument != nil && !strings.EqualFold(instrument.Sector, target.Sector) {
+					return nil, fmt.Errorf("%s is not in the %s sector", instrument.Symbol, target.Sector)
+				}
+				if err := claim(id, target.Sector); err != nil {
+					return nil, err
+				}
+				group.members = append(group.members, id)
+			}
+			if len(group.members) == 0 {
+				return nil, fmt.Errorf("nothing is held in the %s sector; list the instruments to buy", target.Sector)
+			}
+			sort.Slice(group.members, func(a, b int) bool { return group.members[a].Hex() < group.members[b].Hex() })
+		}
+		groups = append(groups, group)
+	}
+
+	// 3. Weigh the covered holdings and cash against the targets
+	cash := account.Balance - account.BlockedMargin
+	total := math.Max(cash, 0)
+	for _, group := range groups {
+		for _, id := range group.members {
+			group.row.CurrentValue += float64(held[id]) * prices[id]
+		}
+		total += group.row.CurrentValue
+	}
+	if total <= 0 {
+		return nil, errors.New("nothing to rebalance: no cash or holdings in the targets")
+	}
+
+	plan := &models.RebalancePlan{
+		TargetType:     model.TargetType,
+		PortfolioValue: roundPaise(total),
+		Cash:           roundPaise(cash),
+		TolerancePct:   model.TolerancePct,
+		Rows:           make([]models.RebalanceRow, 0, len(groups)),
+		Orders:         make([]models.RebalanceOrder, 0),
+	}
+	if !model.ID.IsZero() {
+		plan.ModelID = &model.ID
+	}
+
+	// 4. Size orders for every target outside its band
+	var sells, buys []models.RebalanceOrder
+	for _, group := range groups {
+		row := group.row
+		row.TargetValue = total * row.TargetWeight / 100
+		row.CurrentWeight = row.CurrentValue / total * 100
+		row.Drift = row.CurrentWeight - row.TargetWeight
+		row.InBand = math.Abs(row.Drift) <= model.TolerancePct
+		plan.MaxDrift = math.Max(plan.MaxDrift, math.Abs(row.Drift))
+
+		if !row.InBand {
+			for _, id := range group.members {
+				instrument, price := instruments[id], prices[id]
+				share := 1 / float64(len(group.members))
+				if row.CurrentValue > 0 {
+					share = float64(held[id]) * price / row.CurrentValue
+				}
+				lots := math.Round(row.TargetValue * share / price / float64(instrument.LotSize))
+				targetQty := int(lots) * instrument.LotSize
+
+				order := models.RebalanceOrder{
+					InstrumentID: id, Symbol: instrument.Symbol, Sector: instrument.Sector, Side: "BUY",
+					Quantity: targetQty - held[id], Price: price, CurrentQuantity: held[id], TargetQuantity: targetQty,
+				}
+				if model.OrderType == "LIMIT" {
+					order.Price = roundToTick(price, instrument.TickSize)
+				}
+				if order.Quantity < 0 {
+					order.Side, order.Quantity = "SELL", -order.Quantity
+					sells = append(sells, order)
+				} else if order.Quantity > 0 {
+					buys = append(buys, order)
+				}
+			}
+		}
+
+		row.CurrentValue = roundPaise(row.CurrentValue)
+		row.TargetValue = roundPaise(row.TargetValue)
+		row.CurrentWeight = math.Round(row.CurrentWeight*100) / 100
+		row.Drift = math.Round(row.Drift*100) / 100
+		plan.Rows = append(plan.Rows, *row)
+	}
+	plan.MaxDrift = math.Round(plan.MaxDrift*100) / 100
+
+	// 5. Estimate charges; sell proceeds fund the buys, which are trimmed a lot at a time if cash runs short
+	schedule, err := s.chargeService.GetActiveSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimate := func(order *models.RebalanceOrder) {
+		order.Value = roundPaise(order.Price * float64(order.Quantity))
+		order.Charges = ComputeCharges(schedule, models.ProductTypeCNC, order.Side, order.Value)
+	}
+	budget := cash
+	for i := range sells {
+		estimate(&sells[i])
+		budget += sells[i].Value - sells[i].Charges.Total
+	}
+	spend := func() float64 {
+		sum := 0.0
+		for i := range buys {
+			estimate(&buys[i])
+			sum += buys[i].Value + buys[i].Charges.Total
+		}
+		return sum
+	}
+	for spent := spend(); spent > budget && len(buys) > 0; spent = spend() {
+		sort.SliceStable(buys, func(a, b int) bool { return buys[a].Value > buys[b].Value })
+		lot := instruments[buys[0].InstrumentID].LotSize
+		buys[0].Quantity -= lot
+		buys[0].TargetQuantity -= lot
+		if buys[0].Quantity <= 0 {
+			buys = buys[1:]
+		}
+	}
+
+	plan.CashAfter = cash
+	for _, order := range sells {
+		plan.CashAfter += order.Value - order.Charges.Total
+		plan.EstimatedCharges += order.Charges.Total
+	}
+	for _, order := range buys {
+		plan.CashAfter -= order.Value + order.Charges.Total
+		plan.EstimatedCharges += order.Charges.Total
+	}
+	plan.CashAfter = roundPaise(plan.CashAfter)
+	plan.EstimatedCharges = roundPaise(plan.EstimatedCharges)
+	plan.Orders = append(append(plan.Orders, sells...), buys...)
+	return plan, nil
+}
+
+// modelFromRequest validates target weights and fills in defaults
+func modelFromRequest(req RebalanceRequest) (*models.RebalanceModel, error) {
+	model := &models.RebalanceModel{
+		Name:          req.Name,
+		TargetType:    strings.ToUpper(req.TargetType),
+		Targets:       req.Targets,
+		TolerancePct:  req.TolerancePct,
+		DriftAlertPct: req.DriftAlertPct,
+		OrderType:     strings.ToUpper(req.OrderType),
+	}
+	if model.TargetType == "" {
+		model.TargetType = models.RebalanceByInstrument
+	}
+	if model.TargetType != models.RebalanceByInstrument && model.TargetType != models.RebalanceBySector {
+		return nil, errors.New("invalid target type. Must be INSTRUMENT or SECTOR")
+	}
+	if model.OrderType == "" {
+		model.OrderType = "MARKET"
+	}
+	if model.OrderType != "MARKET" && model.OrderType != "LIMIT" {
+		return nil, errors.New("invalid order type. Must be MARKET or LIMIT")
+	}
+	if model.TolerancePct <= 0 {
+		model.TolerancePct = models.DefaultRebalanceTolerance
+	}
+	if model.TolerancePct > 50 || model.DriftAlertPct < 0 || model.DriftAlertPct > 100 {
+		return nil, errors.New("tolerance must be at most 50% and the drift alert between 0% and 100%")
+	}
+	if len(model.Targets) == 0 || len(model.Targets) > models.MaxBasketOrders {
+		return nil, fmt.Errorf("a model needs 1 to %d targets", models.MaxBasketOrders)
+	}
+
+	sum := 0.0
+	for i := range model.Targets {
+		target := &model.Targets[i]
+		if target.Weight <= 0 {
+			return nil, fmt.Errorf("target %d: weight must be positive", i+1)
+		}
+		sum += target.Weight
+		if model.TargetType == models.RebalanceByInstrument {
+			if target.InstrumentID == nil || target.InstrumentID.IsZero() {
+				return nil, fmt.Errorf("target %d: instrument is required", i+1)
+			}
+			target.Sector, target.Instruments = "", nil
+		} else {
+			target.Sector = strings.TrimSpace(target.Sector)
+			if target.Sector == "" {
+				return nil, fmt.Errorf("target %d: sector is required", i+1)
+			}
+			target.InstrumentID, target.Symbol = nil, ""
+		}
+	}
+	if sum > 100.0001 {
+		return nil, fmt.Errorf("target weights add up to %.2f%%; they may not exceed 100%%", sum)
+	}
+	return model, nil
+}
+
+// roundToTick rounds a price to the instrument's tick size
+func roundToTick(price, tick float64) float64 {
+	if tick <= 0 {
+		return roundPaise(price)
+	}
+	return roundPaise(math.Round(price/tick) * tick)
+}
+
+func (s *RebalanceService) getOwned(ctx context.Context, userID, modelID string) (*models.RebalanceModel, error) {
+	model, err := s.modelRepo.FindByID(ctx, modelID)
+	if err != nil || model == nil {
+		return nil, errors.New("rebalance model not found")
+	}
+	if model.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return model, nil
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"strings"
+
+	"aequitas/internal/models"
+	"aequitas/internal/utils"
+)
+
+const reportTimeLayout = "02-Jan-2006 15:04:05"
+
+// RenderContractNoteHTML renders a note as a self-contained HTML document (also used as the email body)
+func RenderContractNoteHTML(note *models.ContractNote) string {
+	var b strings.Builder
+	b.WriteString(`<html><body style="font-family: sans-serif; color: #333;">`)
+	fmt.Fprintf(&b, "<h2>Contract Note %s</h2>", html.EscapeString(note.NoteNumber))
+	fmt.Fprintf(&b, "<p>Trade Date: <strong>%s</strong></p>", note.TradeDate.Format("02-Jan-2006"))
+
+	b.WriteString(`<table border="1" cellpadding="4" cellspacing="0" style="border-collapse: collapse; font-size: 13px;">`)
+	b.WriteString("<tr><th>Order</th><th>Trade</th><th>Time</th><th>Symbol</th><th>Side</th><th>Qty</th><th>Price</th><th>Value</th><th>Brokerage</th><th>Statutory</th><th>Net</th></tr>")
+	for _, l := range note.Lines {
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%.2f</td><td>%.2f</td><td>%.2f</td><td>%.2f</td><td>%.2f</td></tr>",
+			html.EscapeString(l.OrderRef), html.EscapeString(l.TradeID), l.ExecutedAt.In(utils.GetISTTime().Location()).Format(reportTimeLayout),
+			html.EscapeString(l.Symbol), l.Side, l.Quantity, l.Price, l.Value, l.Brokerage, l.StatutoryCharges, l.NetAmount)
+	}
+	b.WriteString("</table>")
+
+	b.WriteString(`<table cellpadding="4" style="margin-top: 16px; font-size: 13px;">`)
+	fmt.Fprintf(&b, "<tr><td>Gross Buy Value</td><td>₹%.2f</td></tr>", note.GrossBuyValue)
+	fmt.Fprintf(&b, "<tr><td>Gross Sell Value</td><td>₹%.2f</td></tr>", note.GrossSellValue)
+	fmt.Fprintf(&b, "<tr><td>Total Brokerage</td><td>₹%.2f</td></tr>", note.TotalBrokerage)
+	fmt.Fprintf(&b, "<tr><td>STT / CTT</td><td>₹%.2f</td></tr>", note.Charges.STT)
+	fmt.Fprintf(&b, "<tr><td>Exchange Transaction Charges</td><td>₹%.2f</td></tr>", note.Charges.ExchangeTxnCharges)
+	fmt.Fprintf(&b, "<tr><td>SEBI Fees</td><td>₹%.2f</td></tr>", note.Charges.SEBIFees)
+	fmt.Fprintf(&b, "<tr><td>GST</td><td>₹%.2f</td></tr>", note.Charges.GST)
+	fmt.Fprintf(&b, "<tr><td>Stamp Duty</td><td>₹%.2f</td></tr>", note.Charges.StampDuty)
+	fmt.Fprintf(&b, "<tr><td>Total Statutory Charges</td><td>₹%.2f</td></tr>", note.StatutoryCharges)
+	fmt.Fprintf(&b, "<tr><td><strong>Net Obligation</strong></td><td><strong>₹%.2f %s</strong></td></tr>", absFloat(note.NetObligation), obligationLabel(note.NetObligation))
+	b.WriteString("</table>")
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+// RenderContractNoteCSV renders one row per execution followed by a totals row
+func RenderContractNoteCSV(note *models.ContractNote) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Note Number", "Order ID", "Trade ID", "Executed At", "Symbol", "Side", "Quantity", "Price", "Value",
+		"Brokerage", "STT", "Exchange Txn Charges", "SEBI Fees", "GST", "Stamp Duty", "Statutory Charges", "Net Amount"})
+	for _, l := range note.Lines {
+		w.Write([]string{
+			note.NoteNumber, l.OrderRef, l.TradeID, l.ExecutedAt.In(utils.GetISTTime().Location()).Format(reportTimeLayout),
+			l.Symbol, l.Side, fmt.Sprintf("%d", l.Quantity), money(l.Price), money(l.Value),
+			money(l.Brokerage), money(l.Charges.STT), money(l.Charges.ExchangeTxnCharges), money(l.Charges.SEBIFees),
+			money(l.Charges.GST), money(l.Charges.StampDuty), money(l.StatutoryCharges), money(l.NetAmount),
+		})
+	}
+	w.Write([]string{note.NoteNumber, "", "", "", "TOTAL", "", "", "", "",
+		money(note.TotalBrokerage), money(note.Charges.STT), money(note.Charges.ExchangeTxnCharges), money(note.Charges.SEBIFees),
+		money(note.Charges.GST), money(note.Charges.StampDuty), money(note.StatutoryCharges), money(note.NetObligation)})
+	w.Flush()
+	return buf.Bytes()
+}
+
+// RenderContractNotePDF renders the note as a fixed-width text PDF
+func RenderContractNotePDF(note *models.ContractNote) []byte {
+	lines := []string{
+		"AEQUITAS - CONTRACT NOTE",
+		fmt.Sprintf("Note No: %s    Trade Date: %s", note.NoteNumber, note.TradeDate.Format("02-Jan-2006")),
+		"",
+		fmt.Sprintf("%-14s %-8s %-10s %-4s %7s %10s %12s %9s %9s %12s", "Order", "Time", "Symbol", "Side", "Qty", "Price", "Value", "Brokerage", "Statutory", "Net"),
+		strings.Repeat("-", 105),
+	}
+	for _, l := range note.Lines {
+		lines = append(lines, fmt.Sprintf("%-14s %-8s %-10s %-4s %7d %10.2f %12.2f %9.2f %9.2f %12.2f",
+			truncate(l.OrderRef, 14), l.ExecutedAt.In(utils.GetISTTime().Location()).Format("15:04:05"), truncate(l.Symbol, 10),
+			l.Side, l.Quantity, l.Price, l.Value, l.Brokerage, l.StatutoryCharges, l.NetAmount))
+	}
+	lines = append(lines,
+		strings.Repeat("-", 105),
+		fmt.Sprintf("Gross Buy Value:   %14.2f", note.GrossBuyValue),
+		fmt.Sprintf("Gross Sell Value:  %14.2f", note.GrossSellValue),
+		fmt.Sprintf("Total Brokerage:   %14.2f", note.TotalBrokerage),
+		fmt.Sprintf("  STT / CTT:       %14.2f", note.Charges.STT),
+		fmt.Sprintf("  Exchange Txn:    %14.2f", note.Charges.ExchangeTxnCharges),
+		fmt.Sprintf("  SEBI Fees:       %14.2f", note.Charges.SEBIFees),
+		fmt.Sprintf("  GST:             %14.2f", note.Charges.GST),
+		fmt.Sprintf("  Stamp Duty:      %14.2f", note.Charges.StampDuty),
+		fmt.Sprintf("Statutory Charges: %14.2f", note.StatutoryCharges),
+		fmt.Sprintf("Net Obligation:    %14.2f %s", absFloat(note.NetObligation), obligationLabel(note.NetObligation)),
+	)
+	return utils.RenderTextPDF(lines)
+}
+
+// RenderStatementHTML renders a statement as a self-contained HTML document (also used as the email body)
+func RenderStatementHTML(stmt *models.AccountStatement) string {
+	var b strings.Builder
+	b.WriteString(`<html><body style="font-family: sans-serif; color: #333;">`)
+	fmt.Fprintf(&b, "<h2>Account Statement - %s</h2>", stmt.Period)
+
+	b.WriteString(`<table cellpadding="4" style="font-size: 13px;">`)
+	fmt.Fprintf(&b, "<tr><td>Opening Balance</td><td>₹%.2f</td></tr>", stmt.OpeningBalance)
+	fmt.Fprintf(&b, "<tr><td>Deposits</td><td>₹%.2f</td></tr>", stmt.Deposits)
+	fmt.Fprintf(&b, "<tr><td>Withdrawals</td><td>₹%.2f</td></tr>", stmt.Withdrawals)
+	fmt.Fprintf(&b, "<tr><td>Transfers In</td><td>₹%.2f</td></tr>", stmt.TransfersIn)
+	fmt.Fprintf(&b, "<tr><td>Transfers Out</td><td>₹%.2f</td></tr>", stmt.TransfersOut)
+	fmt.Fprintf(&b, "<tr><td>Trade Settlements</td><td>₹%.2f</td></tr>", stmt.TradeSettlements)
+	fmt.Fprintf(&b, "<tr><td>Adjustments</td><td>₹%.2f</td></tr>", stmt.Adjustments)
+	fmt.Fprintf(&b, "<tr><td>Fees</td><td>₹%.2f</td></tr>", stmt.Fees)
+	fmt.Fprintf(&b, "<tr><td>Other</td><td>₹%.2f</td></tr>", stmt.Other)
+	fmt.Fprintf(&b, "<tr><td><strong>Closing Balance</strong></td><td><strong>₹%.2f</strong></td></tr>", stmt.ClosingBalance)
+	b.WriteString("</table>")
+
+	b.WriteString(`<table border="1" cellpadding="4" cellspacing="0" style="border-collapse: collapse; margin-top: 16px; font-size: 13px;">`)
+	b.WriteString("<tr><th>Date</th><th>Type</th><th>Reference</th><th>Amount</th><th>Balance</th></tr>")
+	for _, e := range stmt.Entries {
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%.2f</td><td>%.2f</td></tr>",
+			e.Date.In(utils.GetISTTime().Location()).Format(reportTimeLayout), e.Type, html.EscapeString(e.Reference), e.Amount, e.Balance)
+	}
+	b.WriteString("</table>")
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+// RenderStatementCSV renders the ledger entries bracketed by opening and closing balance rows
+func RenderStatementCSV(stmt *models.AccountStatement) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Date", "Type", "Reference", "Amount", "Balance"})
+	w.Write([]string{stmt.PeriodStart.Format("2006-01-02"), "OPENING_BALANCE", "", "", money(stmt.OpeningBalance)})
+	for _, e := range stmt.Entries {
+		w.Write([]string{e.Date.In(utils.GetISTTime().Location()).Format(reportTimeLayout), e.Type, e.Reference, money(e.Amount), money(e.Balance)})
+	}
+	w.Write([]string{stmt.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"), "CLOSING_BALANCE", "", "", money(stmt.ClosingBalance)})
+	w.Flush()
+	return buf.Bytes()
+}
+
+// RenderStatementPDF renders the statement as a fixed-width text PDF
+func RenderStatementPDF(stmt *models.AccountStatement) []byte {
+	lines := []string{
+		"AEQUITAS - ACCOUNT STATEMENT",
+		fmt.Sprintf("Period: %s", stmt.Period),
+		"",
+		fmt.Sprintf("Opening Balance:   %14.2f", stmt.OpeningBalance),
+		fmt.Sprintf("Deposits:          %14.2f", stmt.Deposits),
+		fmt.Sprintf("Withdrawals:       %14.2f", stmt.Withdrawals),
+		fmt.Sprintf("Transfers In:      %14.2f", stmt.TransfersIn),
+		fmt.Sprintf("Transfers Out:     %14.2f", stmt.TransfersOut),
+		fmt.Sprintf("Trade Settlements: %14.2f", stmt.TradeSettlements),
+		fmt.Sprintf("Adjustments:       %14.2f", stmt.Adjustments),
+		fmt.Sprintf("Fees:              %14.2f", stmt.Fees),
+		fmt.Sprintf("Other:             %14.2f", stmt.Other),
+		fmt.Sprintf("Closing Balance:   %14.2f", stmt.ClosingBalance),
+		"",
+		fmt.Sprintf("%-20s %-11s %-30s %14s %14s", "Date", "Type", "Reference", "Amount", "Balance"),
+		strings.Repeat("-", 93),
+	}
+	for _, e := range stmt.Entries {
+		lines = append(lines, fmt.Sprintf("%-20s %-11s %-30s %14.2f %14.2f",
+			e.Date.In(utils.GetISTTime().Location()).Format(reportTimeLayout), e.Type, truncate(e.Reference, 30), e.Amount, e.Balance))
+	}
+	return utils.RenderTextPDF(lines)
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+func absFloat(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func obligationLabel(v float64) string {
+	if v < 0 {
+		return "(Payable)"
+	}
+	return "(Receivable)"
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contractNoteCutoff is the IST time after which the day's executions are final (post-market end)
+const contractNoteCutoff = "16:00"
+
+type ReportingService struct {
+	reportRepo  *repositories.ReportRepository
+	tradeRepo   *repositories.TradeRepository
+	orderRepo   *repositories.OrderRepository
+	txRepo      *repositories.TransactionRepository
+	accountRepo *repositories.TradingAccountRepository
+	userRepo    *repositories.UserRepository
+	commService CommunicationProvider
+	stopChan    chan struct{}
+
+	lastNoteRun      string // YYYY-MM-DD of last completed EOD run
+	lastStatementRun string // YYYY-MM of last completed statement run
+}
+
+func NewReportingService(
+	reportRepo *repositories.ReportRepository,
+	tradeRepo *repositories.TradeRepository,
+	orderRepo *repositories.OrderRepository,
+	txRepo *repositories.TransactionRepository,
+	accountRepo *repositories.TradingAccountRepository,
+	userRepo *repositories.UserRepository,
+	commService CommunicationProvider,
+) *ReportingService {
+	return &ReportingService{
+		reportRepo:  reportRepo,
+		tradeRepo:   tradeRepo,
+		orderRepo:   orderRepo,
+		txRepo:      txRepo,
+		accountRepo: accountRepo,
+		userRepo:    userRepo,
+		commService: commService,
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Start begins the end-of-day reporting scheduler
+func (s *ReportingService) Start() {
+	ticker := time.NewTicker(15 * time.Minute)
+	go func() {
+		s.runScheduledJobs()
+		for {
+			select {
+			case <-ticker.C:
+				s.runScheduledJobs()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("Reporting scheduler started (checks every 15m)")
+}
+
+// Stop gracefully shuts down the scheduler
+func (s *ReportingService) Stop() {
+	close(s.stopChan)
+}
+
+// runScheduledJobs generates today's contract notes after the cutoff and last month's statements once per month
+func (s *ReportingService) runScheduledJobs() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := utils.GetISTTime()
+	today := now.Format("2006-01-02")
+	cutoff, _ := utils.CombineDateTime(now, contractNoteCutoff)
+
+	if now.After(cutoff) && s.lastNoteRun != today {
+		notes, err := s.GenerateContractNotes(ctx, now)
+		if err != nil {
+			log.Printf("[Reporting] Contract note run failed: %v", err)
+		} else {
+			s.lastNoteRun = today
+			log.Printf("[Reporting] Generated %d contract notes for %s", len(notes), today)
+			for _, note := range notes {
+				if note.EmailedAt == nil {
+					if err := s.EmailContractNote(ctx, note); err != nil {
+						log.Printf("[Reporting] Failed to email contract note %s: %v", note.NoteNumber, err)
+					}
+				}
+			}
+		}
+	}
+
+	prevMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	period := prevMonth.Format("2006-01")
+	if s.lastStatementRun != period {
+		count, err := s.generateAllStatements(ctx, prevMonth)
+		if err != nil {
+			log.Printf("[Reporting] Statement run for %s failed: %v", period, err)
+		} else {
+			s.lastStatementRun = period
+			log.Printf("[Reporting] Generated %d account statements for %s", count, period)
+		}
+	}
+}
+
+// GenerateContractNotes builds a note for every user who traded on the given IST day
+func (s *ReportingService) GenerateContractNotes(ctx context.Context, day time.Time) ([]*models.ContractNote, error) {
+	from, to := istDayBounds(day)
+
+	userIDs, err := s.tradeRepo.FindTradedUserIDs(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find traded users: %w", err)
+	}
+
+	notes := make([]*models.ContractNote, 0, len(userIDs))
+	for _, userID := range userIDs {
+		accounts, err := s.accountRepo.FindAllByUserID(ctx, userID.Hex())
+		if err != nil {
+			log.Printf("[Reporting] Failed to list accounts for user %s: %v", userID.Hex(), err)
+			continue
+		}
+		for _, account := range accounts {
+			note, err := s.GenerateContractNote(utils.WithAccountID(ctx, account.ID.Hex()), userID.Hex(), day)
+			if err != nil {
+				log.Printf("[Reporting] Failed contract note for account %s: %v", account.ID.Hex(), err)
+				continue
+			}
+			if note != nil {
+				notes = append(notes, note)
+			}
+		}
+	}
+	return notes, nil
+}
+
+// GenerateContractNote builds (or rebuilds) a note for an IST day for the trading account the context is scoped to.
+// Returns nil if there were no trades.
+func (s *ReportingService) GenerateContractNote(ctx context.Context, userID string, day time.Time) (*models.ContractNote, error) {
+	from, to := istDayBounds(day)
+
+	trades, err := s.tradeRepo.FindByUserIDBetween(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(trades) == 0 {
+		return nil, nil
+	}
+
+	accountID := trades[0].AccountID.Hex()
+	note := &models.ContractNote{
+		NoteNumber: fmt.Sprintf("CN-%s-%s", from.Format("20060102"), accountID[len(accountID)-6:]),
+		UserID:     trades[0].UserID,
+		AccountID:  trades[0].AccountID,
+		TradeDate:  from,
+		Lines:      make([]models.ContractNoteLine, 0, len(trades)),
+	}
+
+	orderRefs := make(map[primitive.ObjectID]string)
+	for _, t := range trades {
+		ref, ok := orderRefs[t.OrderID]
+		if !ok {
+			if order, err := s.orderRepo.FindByID(ctx, t.OrderID.Hex()); err == nil && order != nil {
+				ref = order.OrderID
+			}
+			orderRefs[t.OrderID] = ref
+		}
+
+		line := models.ContractNoteLine{
+			TradeID:          t.TradeID,
+			OrderID:          t.OrderID,
+			OrderRef:         ref,
+			ExecutedAt:       t.ExecutedAt,
+			Symbol:           t.Symbol,
+			Side:             t.Side,
+			Quantity:         t.Quantity,
+			Price:            t.Price,
+			Value:            t.Value,
+			Brokerage:        t.Commission,
+			StatutoryCharges: t.Fees,
+			Charges:          t.Charges,
+		}
+		if line.Charges.Total == 0 && t.Commission+t.Fees > 0 {
+			// Trades executed before itemisation only carry the collapsed totals
+			line.Charges = models.TradeCharges{Brokerage: t.Commission, Total: t.Commission + t.Fees}
+		}
+		if t.Side == "BUY" {
+			line.NetAmount = -t.NetValue
+			note.GrossBuyValue += t.Value
+		} else {
+			line.NetAmount = t.NetValue
+			note.GrossSellValue += t.Value
+		}
+
+		note.TotalBrokerage += line.Brokerage
+		note.StatutoryCharges += line.StatutoryCharges
+		note.Charges.Add(line.Charges)
+		note.NetObligation += line.NetAmount
+		note.Lines = append(note.Lines, line)
+	}
+
+	return s.reportRepo.UpsertContractNote(ctx, note)
+}
+
+// GenerateStatement reconciles the cash ledger for a user's account over the calendar month containing 'month'
+func (s *ReportingService) GenerateStatement(ctx context.Context, userID string, month time.Time) (*models.AccountStatement, error) {
+	account, err := s.accountRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	return s.buildStatement(ctx, account, month)
+}
+
+func (s *ReportingService) generateAllStatements(ctx context.Context, month time.Time) (int, error) {
+	accounts, err := s.accountRepo.FindAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range accounts {
+		stmt, err := s.buildStatement(ctx, &accounts[i], month)
+		if err != nil {
+			log.Printf("[Reporting] Failed statement for account %s: %v", accounts[i].ID.Hex(), err)
+			continue
+		}
+		count++
+		if stmt.EmailedAt == nil && len(stmt.Entries) > 0 {
+			if err := s.EmailStatement(ctx, stmt); err != nil {
+				log.Printf("[Reporting] Failed to email statement %s/%s: %v", stmt.UserID.Hex(), stmt.Period, err)
+			}
+		}
+	}
+	return count, nil
+}
+
+func (s *ReportingService) buildStatement(ctx context.Context, account *models.TradingAccount, month time.Time) (*models.AccountStatement, error) {
+	ist := utils.GetISTTime().Location()
+	m := month.In(ist)
+	start := time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, ist)
+	end := start.AddDate(0, 1, 0)
+
+	opening, err := s.txRepo.SumCompletedBefore(ctx, account.ID, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute opening balance: %w", err)
+	}
+
+	txs, err := s.txRepo.FindCompletedBetween(ctx, account.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ledger: %w", err)
+	}
+
+	stmt := &models.AccountStatement{
+		UserID:         account.UserID,
+		AccountID:      account.ID,
+		Period:         start.Format("2006-01"),
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: opening,
+		Entries:        make([]models.StatementEntry, 0, len(txs)),
+	}
+
+	running := opening
+	for _, tx := range txs {
+		switch tx.Type {
+		case "DEPOSIT":
+			stmt.Deposits += tx.Amount
+		case "WITHDRAWAL":
+			stmt.Withdrawals -= tx.Amount // Ledger amounts are signed; withdrawals are stored negative
+		case "TRANSFER_IN":
+			stmt.TransfersIn += tx.Amount
+		case "TRANSFER_OUT":
+			stmt.TransfersOut -= tx.Amount // Stored negative, like withdrawals
+		case "TRADE":
+			stmt.TradeSettlements += tx.Amount
+		case "ADJUSTMENT":
+			stmt.Adjustments += tx.Amount
+		case "FEE":
+			stmt.Fees -= tx.Amount
+		default:
+			// Kept visible rather than folded into a known line
+			stmt.Other += tx.Amount
+		}
+
+		running += tx.Amount
+		stmt.Entries = append(stmt.Entries, models.StatementEntry{
+			Date:      tx.CreatedAt,
+			Type:      tx.Type,
+			Reference: tx.Reference,
+			Amount:    tx.Amount,
+			Balance:   running,
+		})
+	}
+	stmt.ClosingBalance = running
+
+	return s.reportRepo.UpsertStatement(ctx, stmt)
+}
+
+func (s *ReportingService) GetContractNotes(ctx context.Context, userID string) ([]*models.ContractNote, error) {
+	return s.reportRepo.FindContractNotesByUserID(ctx, userID, 90)
+}
+
+func (s *ReportingService) GetStatements(ctx context.Context, userID string) ([]*models.AccountStatement, error) {
+	return s.reportRepo.FindStatementsByUserID(ctx, userID)
+}
+
+// GetContractNote fetches a note and enforces ownership
+func (s *ReportingService) GetContractNote(ctx context.Context, userID, noteID string) (*models.ContractNote, error) {
+	note, err := s.reportRepo.FindContractNoteByID(ctx, noteID)
+	if err != nil || note == nil {
+		return nil, errors.New("contract note not found")
+	}
+	if note.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return note, nil
+}
+
+// GetStatement fetches a statement and enforces ownership
+func (s *ReportingService) GetStatement(ctx context.Context, userID, statementID string) (*models.AccountStatement, error) {
+	stmt, err := s.reportRepo.FindStatementByID(ctx, statementID)
+	if err != nil || stmt == nil {
+		return nil, errors.New("statement not found")
+	}
+	if stmt.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return stmt, nil
+}
+
+// EmailContractNote sends the HTML rendering of a note to the account holder
+func (s *ReportingService) EmailContractNote(ctx context.Context, note *models.ContractNote) error {
+	user, err := s.userRepo.FindByID(note.UserID.Hex())
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+
+	subject := fmt.Sprintf("Aequitas: Contract Note %s", note.NoteNumber)
+	if err := s.commService.SendEmail(user.Email, subject, RenderContractNoteHTML(note)); err != nil {
+		return err
+	}
+	return s.reportRepo.MarkContractNoteEmailed(ctx, note.ID)
+}
+
+// EmailStatement sends the HTML rendering of a statement to the account holder
+func (s *ReportingService) EmailStatement(ctx context.Context, stmt *models.AccountStatement) error {
+	user, err := s.userRepo.FindByID(stmt.UserID.Hex())
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+
+	subject := fmt.Sprintf("Aequitas: Account Statement for %s", stmt.Period)
+	if err := s.commService.SendEmail(user.Email, subject, RenderStatementHTML(stmt)); err != nil {
+		return err
+	}
+	return s.reportRepo.MarkStatementEmailed(ctx, stmt.ID)
+}
+
+// istDayBounds returns [IST midnight, next IST midnight) for the given instant
+func istDayBounds(t time.Time) (time.Time, time.Time) {
+	ist := utils.GetISTTime().Location()
+	d := t.In(ist)
+	start := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, ist)
+	return start, start.AddDate(0, 0, 1)
+}
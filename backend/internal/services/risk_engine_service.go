@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RiskRejectionError is returned when an order fails a pre-trade rule
+type RiskRejectionError struct {
+	Code     string  `json:"ruleCode"`
+	Scope    string  `json:"ruleScope"`
+	Limit    float64 `json:"limit"`
+	Observed float64 `json:"observed"`
+	Message  string  `json:"message"`
+}
+
+func (e *RiskRejectionError) Error() string {
+	return fmt.Sprintf("%s [%s]", e.Message, e.Code)
+}
+
+// PreTradeOrder is what the risk engine sees of an order about to be accepted
+type PreTradeOrder struct {
+	Account      *models.TradingAccount
+	Instrument   *models.Instrument
+	Order        *models.Order
+	OpenQuantity int     // Part of the order that opens or adds exposure; a netting order may close part first
+	Price        float64 // Valuation price: limit/stop price, or LTP plus buffer for market orders
+	LastPrice    float64
+	Amending     bool // An amendment of an order already counted as open
+}
+
+// opensExposure reports whether the order adds to a position rather than only reducing one
+func (o *PreTradeOrder) opensExposure() bool {
+	return o.OpenQuantity > 0
+}
+
+// reverses reports whether the order closes an opposite position before opening
+func (o *PreTradeOrder) reverses() bool {
+	return o.OpenQuantity < o.Order.Quantity
+}
+
+// RiskCheck evaluates one rule against its resolved limit and returns the observed value
+type RiskCheck func(ctx context.Context, o *PreTradeOrder, limit float64) (observed float64, breached bool, err error)
+
+type riskCheckEntry struct {
+	code    string
+	check   RiskCheck
+	message string // Printf format taking observed and limit
+}
+
+// Platform defaults, seeded as GLOBAL rules on startup and then editable
+var defaultRiskRules = []models.RiskRule{
+	{Code: models.RiskRuleMaxOrderQuantity, Limit: 1_000_000, Description: "Maximum shares per order"},
+	{Code: models.RiskRuleMaxOrderValue, Limit: 10_000_000, Description: "Maximum value per order (₹)"},
+	{Code: models.RiskRuleMaxPositionValue, Limit: 25_000_000, Description: "Maximum position value per instrument (₹)"},
+	{Code: models.RiskRuleMaxGrossExposure, Limit: 5, Description: "Maximum gross exposure as a multiple of cash balance"},
+	{Code: models.RiskRuleFatFinger, Limit: 0.10, Description: "Maximum deviation of order price from LTP"},
+	{Code: models.RiskRuleMaxOpenOrders, Limit: 100, Description: "Maximum working orders"},
+	{Code: models.RiskRuleMaxOrdersPerSecond, Limit: 5, Description: "Maximum order submissions per second"},
+	{Code: models.RiskRuleDailyLossLimit, Limit: 100_000, Description: "Maximum loss since previous close (₹) before new exposure is halted for the day"},
+}
+
+// RiskEngineService runs configurable pre-trade checks on every order before it is accepted
+type RiskEngineService struct {
+	ruleRepo       *repositories.RiskRuleRepository
+	orderRepo      *repositories.OrderRepository
+	portfolioRepo  *repositories.PortfolioRepository
+	txRepo         *repositories.TransactionRepository
+	marketDataRepo *repositories.MarketDataRepository
+	marginService  *MarginService
+	auditService   *AuditService
+
+	checks []riskCheckEntry
+
+	// Submission timestamps per user for the order-rate rule
+	rateMu       sync.Mutex
+	recentOrders map[string][]time.Time
+}
+
+func NewRiskEngineService(
+	ruleRepo *repositories.RiskRuleRepository,
+	orderRepo *repositories.OrderRepository,
+	portfolioRepo *repositories.PortfolioRepository,
+	txRepo *repositories.TransactionRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	marginService *MarginService,
+	auditService *AuditService,
+) *RiskEngineService {
+	s := &RiskEngineService{
+		ruleRepo:       ruleRepo,
+		orderRepo:      orderRepo,
+		portfolioRepo:  portfolioRepo,
+		txRepo:         txRepo,
+		marketDataRepo: marketDataRepo,
+		marginService:  marginService,
+		auditService:   auditService,
+		recentOrders:   make(map[string][]time.Time),
+	}
+
+	// Cheap in-memory checks first, valuation-heavy checks last
+	s.RegisterCheck(models.RiskRuleMaxOrdersPerSecond, s.checkOrderRate, "order rate %.0f/s exceeds limit of %.0f/s")
+	s.RegisterCheck(models.RiskRuleMaxOrderQuantity, s.checkOrderQuantity, "order quantity %.0f exceeds limit of %.0f shares")
+	s.RegisterCheck(models.RiskRuleMaxOrderValue, s.checkOrderValue, "order value ₹%.2f exceeds limit of ₹%.2f")
+	s.RegisterCheck(models.RiskRuleFatFinger, s.checkFatFinger, "order price deviates %.4f from LTP, limit is %.4f")
+	s.RegisterCheck(models.RiskRuleMaxOpenOrders, s.checkOpenOrders, "%.0f working orders would exceed limit of %.0f")
+	s.RegisterCheck(models.RiskRuleMaxPositionValue, s.checkPositionValue, "position value ₹%.2f would exceed limit of ₹%.2f")
+	s.RegisterCheck(models.RiskRuleMaxGrossExposure, s.checkGrossExposure, "gross exposure %.2fx cash would exceed limit of %.2fx")
+	s.RegisterCheck(models.RiskRuleDailyLossLimit, s.checkDailyLoss, "loss since previous close ₹%.2f has reached the daily limit of ₹%.2f; new positions are halted for today")
+	return s
+}
+
+// RegisterCheck plugs a rule evaluator into the engine; checks run in registration order
+func (s *RiskEngineService) RegisterCheck(code string, check RiskCheck, message string) {
+	s.checks = append(s.checks, riskCheckEntry{code: code, check: check, message: message})
+}
+
+// EnsureDefaultRules seeds any missing GLOBAL rule without touching edited ones
+func (s *RiskEngineService) EnsureDefaultRules(ctx context.Context) {
+	for _, rule := range defaultRiskRules {
+		rule.Scope = models.RiskScopeGlobal
+		rule.Enabled = true
+		if err := s.ruleRepo.InsertRuleIfMissing(ctx, &rule); err != nil {
+			log.Printf("[RiskEngine] Failed to seed rule %s: %v", rule.Code, err)
+		}
+	}
+}
+
+// Evaluate runs every enabled rule; the first breach is recorded and returned as a *RiskRejectionError
+func (s *RiskEngineService) Evaluate(ctx context.Context, o *PreTradeOrder) error {
+	if o.LastPrice <= 0 {
+		if data, err := s.marketDataRepo.FindByInstrumentID(ctx, o.Instrument.ID.Hex()); err == nil && data != nil {
+			o.LastPrice = data.LastPrice
+		}
+	}
+
+	// A daily-loss halt blocks new exposure until the next trading day
+	if o.opensExposure() {
+		today, _ := istDayBounds(utils.GetISTTime())
+		halt, err := s.ruleRepo.FindHalt(ctx, o.Account.UserID, today)
+		if err != nil {
+			return fmt.Errorf("risk engine: failed to check trading halt: %w", err)
+		}
+		if halt != nil {
+			return s.reject(ctx, o, &RiskRejectionError{
+				Code:     models.RiskRuleDailyLossLimit,
+				Scope:    models.RiskScopeUser,
+				Limit:    halt.Limit,
+				Observed: halt.Loss,
+				Message:  "new positions are halted for today after the daily loss limit was reached; only closing orders are accepted",
+			})
+		}
+	}
+
+	rules, err := s.resolveRules(ctx, o.Account.UserID, o.Instrument.ID)
+	if err != nil {
+		return fmt.Errorf("risk engine: failed to load rules: %w", err)
+	}
+
+	for _, entry := range s.checks {
+		rule, ok := rules[entry.code]
+		if !ok || !rule.Enabled {
+			continue
+		}
+		observed, breached, err := entry.check(ctx, o, rule.Limit)
+		if err != nil {
+			return fmt.Errorf("risk engine: %s check failed: %w", entry.code, err)
+		}
+		if !breached {
+			continue
+		}
+
+		if entry.code == models.RiskRuleDailyLossLimit {
+			today, _ := istDayBounds(utils.GetISTTime())
+			halt := &models.RiskHalt{UserID: o.Account.UserID, TradingDate: today, RuleCode: entry.code, Loss: observed, Limit: rule.Limit}
+			if err := s.ruleRepo.CreateHalt(ctx, halt); err != nil {
+				log.Printf("[RiskEngine] Failed to record halt for user %s: %v", o.Account.UserID.Hex(), err)
+			}
+			s.auditService.Log(o.Account.UserID.Hex(), "System", "SYSTEM", "TRADING_HALTED", o.Account.UserID.Hex(), "USER",
+				fmt.Sprintf("Daily loss ₹%.2f reached limit ₹%.2f; new positions halted for %s", observed, rule.Limit, today.Format("2006-01-02")), nil, halt)
+		}
+
+		return s.reject(ctx, o, &RiskRejectionError{
+			Code:     entry.code,
+			Scope:    rule.Scope,
+			Limit:    rule.Limit,
+			Observed: observed,
+			Message:  fmt.Sprintf(entry.message, observed, rule.Limit),
+		})
+	}
+	return nil
+}
+
+// resolveRules picks one rule per code: USER over INSTRUMENT over GLOBAL
+func (s *RiskEngineService) resolveRules(ctx context.Context, userID, instrumentID primitive.ObjectID) (map[string]models.RiskRule, error) {
+	rules, err := s.ruleRepo.FindApplicableRules(ctx, userID, instrumentID)
+	if err != nil {
+		return nil, err
+	}
+	rank := map[string]int{models.RiskScopeGlobal: 0, models.RiskScopeInstrument: 1, models.RiskScopeUser: 2}
+
+	resolved := make(map[string]models.RiskRule, len(rules))
+	for _, rule := range rules {
+		if current, ok := resolved[rule.Code]; !ok || rank[rule.Scope] > rank[current.Scope] {
+			resolved[rule.Code] = rule
+		}
+	}
+	return resolved, nil
+}
+
+func (s *RiskEngineService) reject(ctx context.Context, o *PreTradeOrder, rejection *RiskRejectionError) error {
+	record := &models.RiskRejection{
+		UserID:       o.Account.UserID,
+		InstrumentID: o.Instrument.ID,
+		Symbol:       o.Instrument.Symbol,
+		Side:         o.Order.Side,
+		Intent:       o.Order.Intent,
+		OrderType:    o.Order.OrderType,
+		Quantity:     o.Order.Quantity,
+		Price:        o.Price,
+		RuleCode:     rejection.Code,
+		RuleScope:    rejection.Scope,
+		Limit:        rejection.Limit,
+		Observed:     rejection.Observed,
+		Message:      rejection.Message,
+	}
+	if err := s.ruleRepo.CreateRejection(ctx, record); err != nil {
+		log.Printf("[RiskEngine] Failed to record rejection for user %s: %v", o.Account.UserID.Hex(), err)
+	}
+	log.Printf("[RiskEngine] REJECTED %s %d %s for user %s: %s", o.Order.Side, o.Order.Quantity, o.Instrument.Symbol, o.Account.UserID.Hex(), rejection.Code)
+	return rejection
+}
+
+func (s *RiskEngineService) checkOrderRate(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	userID := o.Account.UserID.Hex()
+	now := time.Now()
+
+	s.rateMu.Lock()
+	defer s.rateMu.Unlock()
+
+	recent := s.recentOrders[userID][:0]
+	for _, t := range s.recentOrders[userID] {
+		if now.Sub(t) < time.Second {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	s.recentOrders[userID] = recent
+
+	observed := float64(len(recent))
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkOrderQuantity(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	observed := float64(o.Order.Quantity)
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkOrderValue(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	observed := float64(o.Order.Quantity) * o.Price
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkFatFinger(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	var price *float64
+	switch {
+	case o.Order.Price != nil:
+		price = o.Order.Price
+	case o.Order.StopPrice != nil:
+		price = o.Order.StopPrice
+	}
+	if price == nil || o.LastPrice <= 0 {
+		return 0, false, nil // Market orders execute at LTP
+	}
+	observed := math.Abs(*price-o.LastPrice) / o.LastPrice
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkOpenOrders(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	open, err := s.orderRepo.CountOpenByUser(ctx, o.Account.UserID)
+	if err != nil {
+		return 0, false, err
+	}
+	observed := float64(open)
+	if !o.Amending {
+		observed++
+	}
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkPositionValue(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	if !o.opensExposure() {
+		return 0, false, nil
+	}
+	holding, err := s.portfolioRepo.GetHolding(ctx, o.Account.UserID.Hex(), o.Instrument.ID.Hex())
+	if err != nil {
+		return 0, false, err
+	}
+	quantity := o.OpenQuantity
+	if holding != nil && !o.reverses() {
+		quantity += holding.Quantity
+	}
+	observed := float64(quantity) * math.Max(o.Price, o.LastPrice)
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkGrossExposure(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	if !o.opensExposure() {
+		return 0, false, nil
+	}
+	holdings, err := s.portfolioRepo.GetHoldings(ctx, o.Account.UserID.Hex())
+	if err != nil {
+		return 0, false, err
+	}
+
+	gross := float64(o.OpenQuantity) * o.Price
+	for _, h := range holdings {
+		if h.Quantity <= 0 {
+			continue
+		}
+		if o.reverses() && h.InstrumentID == o.Instrument.ID {
+			continue // Closed by this order before the remainder opens
+		}
+		price := h.AvgEntryPrice
+		if data, err := s.marketDataRepo.FindByInstrumentID(ctx, h.InstrumentID.Hex()); err == nil && data != nil && data.LastPrice > 0 {
+			price = data.LastPrice
+		}
+		gross += float64(h.Quantity) * price
+	}
+
+	if o.Account.Balance <= 0 {
+		return math.Inf(1), true, nil
+	}
+	observed := gross / o.Account.Balance
+	return observed, observed > limit, nil
+}
+
+func (s *RiskEngineService) checkDailyLoss(ctx context.Context, o *PreTradeOrder, limit float64) (float64, bool, error) {
+	if !o.opensExposure() {
+		return 0, false, nil
+	}
+	today, _ := istDayBounds(utils.GetISTTime())
+	previous, err := s.portfolioRepo.FindLatestEODBefore(ctx, o.Account.UserID, today)
+	if err != nil || previous == nil {
+		return 0, false, err // No close to measure against yet
+	}
+
+	margin, err := s.marginService.GetAccountMargin(ctx, o.Account.UserID.Hex())
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Deposits and withdrawals since the close are not trading P&L
+	txs, err := s.txRepo.FindCompletedBetween(ctx, o.Account.ID, today, time.Now())
+	if err != nil {
+		return 0, false, err
+	}
+	flows := 0.0
+	for _, tx := range txs {
+		if isExternalFlow(tx.Type) {
+			flows += tx.Amount
+		}
+	}
+
+	observed := previous.TotalEquity + flows - margin.Equity
+	return observed, observed >= limit, nil
+}
+
+// RiskDashboard summarises the engine's activity for the current trading day
+type RiskDashboard struct {
+	TradingDate      time.Time              `json:"tradingDate"`
+	RejectionsByRule map[string]int64       `json:"rejectionsByRule"`
+	TotalRejections  int64                  `json:"totalRejections"`
+	RecentRejections []models.RiskRejection `json:"recentRejections"`
+	Halts            []models.RiskHalt      `json:"halts"`
+	Rules            []models.RiskRule      `json:"rules"`
+}
+
+func (s *RiskEngineService) GetDashboard(ctx context.Context) (*RiskDashboard, error) {
+	today, _ := istDayBounds(utils.GetISTTime())
+
+	counts, err := s.ruleRepo.CountRejectionsByRule(ctx, today)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.ruleRepo.FindRejectionsSince(ctx, today, 100)
+	if err != nil {
+		return nil, err
+	}
+	halts, err := s.ruleRepo.FindHaltsForDate(ctx, today)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.ruleRepo.FindAllRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &RiskDashboard{
+		TradingDate:      today,
+		RejectionsByRule: counts,
+		RecentRejections: recent,
+		Halts:            halts,
+		Rules:            rules,
+	}
+	for _, c := range counts {
+		dashboard.TotalRejections += c
+	}
+	return dashboard, nil
+}
+
+func (s *RiskEngineService) ListRules(ctx context.Context) ([]models.RiskRule, error) {
+	return s.ruleRepo.FindAllRules(ctx)
+}
+
+// UpsertRule creates or edits a rule for a scope; scoped rules override the GLOBAL rule of the same code
+func (s *RiskEngineService) UpsertRule(ctx context.Context, rule models.RiskRule, actorID string) (*models.RiskRule, error) {
+	known := false
+	for _, d := range defaultRiskRules {
+		if d.Code == rule.Code {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown rule code: %s", rule.Code)
+	}
+	switch rule.Scope {
+	case models.RiskScopeGlobal:
+		rule.ScopeID = primitive.NilObjectID
+	case models.RiskScopeUser, models.RiskScopeInstrument:
+		if rule.ScopeID.IsZero() {
+			return nil, fmt.Errorf("scopeId is required for %s rules", rule.Scope)
+		}
+	default:
+		return nil, errors.New("scope must be GLOBAL, INSTRUMENT or USER")
+	}
+	if rule.Limit < 0 {
+		return nil, errors.New("limit cannot be negative")
+	}
+
+	old, err := s.ruleRepo.FindRule(ctx, rule.Code, rule.Scope, rule.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.UpdatedBy, _ = primitive.ObjectIDFromHex(actorID)
+	if err := s.ruleRepo.UpsertRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	saved, err := s.ruleRepo.FindRule(ctx, rule.Code, rule.Scope, rule.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "RISK_RULE_UPDATED", saved.ID.Hex(), "RISK_RULE",
+		fmt.Sprintf("%s (%s) limit %.4f, enabled %t", rule.Code, rule.Scope, rule.Limit, rule.Enabled), old, saved)
+	return saved, nil
+}
+
+// DeleteRule removes a scoped override; GLOBAL rules can only be disabled
+func (s *RiskEngineService) DeleteRule(ctx context.Context, ruleID string) error {
+	id, err := primitive.ObjectIDFromHex(ruleID)
+	if err != nil {
+		return errors.New("invalid rule id")
+	}
+	rule, err := s.ruleRepo.FindRuleByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return errors.New("rule not found")
+	}
+	if rule.Scope == models.RiskScopeGlobal {
+		return errors.New("global rules cannot be deleted; disable them instead")
+	}
+	if err := s.ruleRepo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditService.LogFromContext(ctx, "RISK_RULE_DELETED", rule.ID.Hex(), "RISK_RULE",
+		fmt.Sprintf("Removed %s override for %s %s", rule.Code, rule.Scope, rule.ScopeID.Hex()), rule, nil)
+	return nil
+}
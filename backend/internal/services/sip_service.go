@@ -0,0 +1,509 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SIPService runs systematic investment plans: recurring amount-based buys on trading days
+type SIPService struct {
+	sipRepo             *repositories.SIPRepository
+	instrumentRepo      *repositories.InstrumentRepository
+	marketDataRepo      *repositories.MarketDataRepository
+	marketRepo          *repositories.MarketRepository
+	tradingAccountRepo  *repositories.TradingAccountRepository
+	marketService       *MarketService
+	orderService        *OrderService
+	notificationService *NotificationService
+	auditService        *AuditService
+	running             sync.Mutex // One pass at a time
+	stopChan            chan struct{}
+}
+
+func NewSIPService(
+	sipRepo *repositories.SIPRepository,
+	instrumentRepo *repositories.InstrumentRepository,
+	marketDataRepo *repositories.MarketDataRepository,
+	marketRepo *repositories.MarketRepository,
+	tradingAccountRepo *repositories.TradingAccountRepository,
+	marketService *MarketService,
+	orderService *OrderService,
+	notificationService *NotificationService,
+	auditService *AuditService,
+) *SIPService {
+	return &SIPService{
+		sipRepo:             sipRepo,
+		instrumentRepo:      instrumentRepo,
+		marketDataRepo:      marketDataRepo,
+		marketRepo:          marketRepo,
+		tradingAccountRepo:  tradingAccountRepo,
+		marketService:       marketService,
+		orderService:        orderService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// SIPRequest creates a plan
+type SIPRequest struct {
+	InstrumentID string  `json:"instrumentId"`
+	Amount       float64 `json:"amount"`
+	Frequency    string  `json:"frequency"`
+	DayOfMonth   int     `json:"dayOfMonth"`
+	DayOfWeek    int     `json:"dayOfWeek"`
+	StepUpPct    float64 `json:"stepUpPct"`
+	StepUpEvery  int     `json:"stepUpEvery"`
+	Instalments  int     `json:"instalments"`
+}
+
+// SIPStepUpRequest raises a plan's amount now, by a new amount or a percentage, and can change the automatic step-up
+type SIPStepUpRequest struct {
+	Amount      float64  `json:"amount,omitempty"`
+	Percent     float64  `json:"percent,omitempty"`
+	StepUpPct   *float64 `json:"stepUpPct,omitempty"`
+	StepUpEvery *int     `json:"stepUpEvery,omitempty"`
+}
+
+func (s *SIPService) Start() {
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.RunDuePlans(context.Background())
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("SIP scheduler started (checking every 1m)")
+}
+
+func (s *SIPService) Stop() {
+	close(s.stopChan)
+}
+
+// CreatePlan starts a plan; the first instalment runs on the first scheduled trading day from today
+func (s *SIPService) CreatePlan(ctx context.Context, userID string, req SIPRequest) (*models.SIPPlan, error) {
+	instrument, err := s.instrumentRepo.FindByID(req.InstrumentID)
+	if err != nil || instrument == nil {
+		return nil, errors.New("instrument not found")
+	}
+	if instrument.Status != "ACTIVE" || instrument.Type == models.InstrumentTypeIndex {
+		return nil, errors.New("instrument is not active for trading")
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, instrument.ID.Hex())
+	if err != nil || marketData == nil {
+		return nil, errors.New("market data unavailable for this instrument")
+	}
+	if lotValue := marketData.LastPrice * float64(instrument.LotSize); req.Amount < lotValue {
+		return nil, fmt.Errorf("₹%.2f buys less than one lot of %s (₹%.2f at the current price)", req.Amount, instrument.Symbol, lotValue)
+	}
+
+	today, _ := istDayBounds(time.Now())
+	plan := &models.SIPPlan{
+		InstrumentID: instrument.ID,
+		Symbol:       instrument.Symbol,
+		Exchange:     instrument.Exchange,
+		Amount:       roundPaise(req.Amount),
+		Frequency:    strings.ToUpper(req.Frequency),
+		DayOfMonth:   req.DayOfMonth,
+		DayOfWeek:    req.DayOfWeek,
+		StepUpPct:    req.StepUpPct,
+		StepUpEvery:  req.StepUpEvery,
+		Instalments:  req.Instalments,
+		Status:       models.SIPStatusActive,
+	}
+	if plan.Exchange == "" {
+		plan.Exchange = snapshotExchange
+	}
+	switch plan.Frequency {
+	case models.SIPFrequencyDaily:
+		plan.DayOfMonth, plan.DayOfWeek = 0, 0
+	case models.SIPFrequencyWeekly:
+		if plan.DayOfWeek == 0 {
+			plan.DayOfWeek = isoWeekday(today)
+		}
+		if plan.DayOfWeek < 1 || plan.DayOfWeek > 7 {
+			return nil, errors.New("day of week must be between 1 (Monday) and 7 (Sunday)")
+		}
+		plan.DayOfMonth = 0
+	case models.SIPFrequencyMonthly:
+		if plan.DayOfMonth == 0 {
+			plan.DayOfMonth = int(math.Min(float64(today.Day()), 28))
+		}
+		if plan.DayOfMonth < 1 || plan.DayOfMonth > 28 {
+			return nil, errors.New("day of month must be between 1 and 28")
+		}
+		plan.DayOfWeek = 0
+	default:
+		return nil, errors.New("invalid frequency. Must be DAILY, WEEKLY, or MONTHLY")
+	}
+	if plan.Instalments < 0 {
+		return nil, errors.New("instalments cannot be negative")
+	}
+	if err := validateStepUp(plan.StepUpPct, plan.StepUpEvery); err != nil {
+		return nil, err
+	}
+
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, userID)
+	if err != nil || account == nil {
+		return nil, errors.New("trading account not found")
+	}
+	plan.UserID = account.UserID
+	plan.AccountID = account.ID
+	s.schedule(plan, today.AddDate(0, 0, -1))
+
+	if err := s.sipRepo.Create(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "SIP_CREATED", plan.ID.Hex(), "SIP",
+		fmt.Sprintf("SIP of ₹%.2f in %s, %s", plan.Amount, plan.Symbol, strings.ToLower(plan.Frequency)), nil, plan)
+	return plan, nil
+}
+
+func (s *SIPService) GetUserPlans(ctx context.Context, userID, status string) ([]models.SIPPlan, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sipRepo.FindByUserID(ctx, uid, strings.ToUpper(status))
+}
+
+// GetPlan returns a plan with its execution history
+func (s *SIPService) GetPlan(ctx context.Context, userID, planID string) (*models.SIPPlan, []models.SIPExecution, error) {
+	plan, err := s.getOwned(ctx, userID, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	executions, err := s.sipRepo.FindExecutions(ctx, plan.ID, 500)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plan, executions, nil
+}
+
+func (s *SIPService) PausePlan(ctx context.Context, userID, planID string) (*models.SIPPlan, error) {
+	plan, err := s.getOwned(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.SIPStatusActive {
+		return nil, fmt.Errorf("cannot pause a %s plan", strings.ToLower(plan.Status))
+	}
+	old := *plan
+	now := time.Now()
+	plan.Status = models.SIPStatusPaused
+	plan.PausedAt = &now
+	return plan, s.save(ctx, "SIP_PAUSED", &old, plan, "SIP paused")
+}
+
+// ResumePlan restarts a paused plan from its next scheduled date; instalments missed while paused are not made up
+func (s *SIPService) ResumePlan(ctx context.Context, userID, planID string) (*models.SIPPlan, error) {
+	plan, err := s.getOwned(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.SIPStatusPaused {
+		return nil, errors.New("only paused plans can be resumed")
+	}
+	old := *plan
+	today, _ := istDayBounds(time.Now())
+	plan.Status = models.SIPStatusActive
+	plan.PausedAt = nil
+	s.schedule(plan, today.AddDate(0, 0, -1))
+	return plan, s.save(ctx, "SIP_RESUMED", &old, plan, "SIP resumed")
+}
+
+func (s *SIPService) CancelPlan(ctx context.Context, userID, planID string) (*models.SIPPlan, error) {
+	plan, err := s.getOwned(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.SIPStatusActive && plan.Status != models.SIPStatusPaused {
+		return nil, fmt.Errorf("cannot cancel a %s plan", strings.ToLower(plan.Status))
+	}
+	old := *plan
+	plan.Status = models.SIPStatusCancelled
+	plan.StatusReason = "Cancelled by user"
+	return plan, s.save(ctx, "SIP_CANCELLED", &old, plan, "SIP cancelled")
+}
+
+// StepUpPlan raises the instalment amount and optionally changes the automatic step-up
+func (s *SIPService) StepUpPlan(ctx context.Context, userID, planID string, req SIPStepUpRequest) (*models.SIPPlan, error) {
+	plan, err := s.getOwned(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.SIPStatusActive && plan.Status != models.SIPStatusPaused {
+		return nil, fmt.Errorf("cannot change a %s plan", strings.ToLower(plan.Status))
+	}
+	if req.Amount != 0 && req.Percent != 0 {
+		return nil, errors.New("give either a new amount or a percentage, not both")
+	}
+	old := *plan
+
+	amount := plan.Amount
+	if req.Amount != 0 {
+		amount = req.Amount
+	} else if req.Percent != 0 {
+		if req.Percent < 0 || req.Percent > 100 {
+			return nil, errors.New("step-up percentage must be between 0 and 100")
+		}
+		amount = plan.Amount * (1 + req.Percent/100)
+	}
+	if amount < plan.Amount {
+		return nil, errors.New("a step-up cannot lower the amount")
+	}
+	plan.Amount = roundPaise(amount)
+
+	if req.StepUpPct != nil {
+		plan.StepUpPct = *req.StepUpPct
+	}
+	if req.StepUpEvery != nil {
+		plan.StepUpEvery = *req.StepUpEvery
+	}
+	if err := validateStepUp(plan.StepUpPct, plan.StepUpEvery); err != nil {
+		return nil, err
+	}
+
+	return plan, s.save(ctx, "SIP_STEPPED_UP", &old, plan,
+		fmt.Sprintf("SIP amount ₹%.2f → ₹%.2f", old.Amount, plan.Amount))
+}
+
+// RunDuePlans places the instalments of plans due today whose exchange is open
+func (s *SIPService) RunDuePlans(ctx context.Context) {
+	if !s.running.TryLock() {
+		return
+	}
+	defer s.running.Unlock()
+
+	plans, err := s.sipRepo.FindDue(ctx, time.Now())
+	if err != nil {
+		log.Printf("SIP scheduler error: failed to fetch due plans: %v", err)
+		return
+	}
+
+	open := make(map[string]bool)
+	for i := range plans {
+		plan := &plans[i]
+		isOpen, checked := open[plan.Exchange]
+		if !checked {
+			isOpen, _, _ = s.marketService.IsMarketOpen(plan.Exchange)
+			open[plan.Exchange] = isOpen
+		}
+		if isOpen {
+			s.runInstalment(ctx, plan)
+		}
+	}
+}
+
+// runInstalment sizes the instalment to whole lots at the current price and places a MARKET buy,
+// skipping it when funds are short
+func (s *SIPService) runInstalment(ctx context.Context, plan *models.SIPPlan) {
+	ctx = utils.WithAccountID(ctx, plan.AccountID.Hex())
+	scheduledFor, due := plan.ScheduledFor, plan.NextRunAt
+
+	// Move the schedule past today first so a second pass cannot buy twice; missed dates are not made up
+	today, _ := istDayBounds(time.Now())
+	s.schedule(plan, today)
+	claimed, err := s.sipRepo.ClaimRun(ctx, plan.ID, due, plan.ScheduledFor, plan.NextRunAt)
+	if err != nil || !claimed {
+		return
+	}
+
+	now := time.Now()
+	plan.InstalmentCount++
+	plan.LastRunAt = &now
+	execution := &models.SIPExecution{
+		PlanID:       plan.ID,
+		UserID:       plan.UserID,
+		AccountID:    plan.AccountID,
+		Instalment:   plan.InstalmentCount,
+		ScheduledFor: scheduledFor,
+		Amount:       plan.Amount,
+		Status:       models.SIPExecutionSkipped,
+		ExecutedAt:   now,
+	}
+
+	s.fill(ctx, plan, execution)
+
+	if plan.StepUpPct > 0 && plan.StepUpEvery > 0 && plan.InstalmentCount%plan.StepUpEvery == 0 {
+		plan.Amount = roundPaise(plan.Amount * (1 + plan.StepUpPct/100))
+	}
+	if plan.Instalments > 0 && plan.PlacedCount >= plan.Instalments {
+		plan.Status = models.SIPStatusCompleted
+		plan.StatusReason = fmt.Sprintf("All %d instalments placed", plan.Instalments)
+	}
+
+	if err := s.sipRepo.CreateExecution(ctx, execution); err != nil {
+		log.Printf("SIP scheduler error: failed to record execution for %s: %v", plan.ID.Hex(), err)
+	}
+	if err := s.sipRepo.Update(ctx, plan); err != nil {
+		log.Printf("SIP scheduler error: failed to update plan %s: %v", plan.ID.Hex(), err)
+	}
+
+	s.auditService.Log(plan.UserID.Hex(), "System", "SYSTEM", "SIP_"+execution.Status, plan.ID.Hex(), "SIP",
+		fmt.Sprintf("SIP instalment %d: %s %d %s", execution.Instalment, strings.ToLower(execution.Status), execution.Quantity, plan.Symbol),
+		nil, execution)
+
+	title := map[string]string{
+		models.SIPExecutionPlaced:  "SIP Order Placed",
+		models.SIPExecutionSkipped: "SIP Instalment Skipped",
+		models.SIPExecutionFailed:  "SIP Order Rejected",
+	}[execution.Status]
+	message := fmt.Sprintf("Your SIP in %s placed a buy of %d at about ₹%.2f.", plan.Symbol, execution.Quantity, execution.Price)
+	if execution.Status != models.SIPExecutionPlaced {
+		message = fmt.Sprintf("Your ₹%.2f SIP instalment in %s was not placed: %s", execution.Amount, plan.Symbol, execution.Reason)
+	}
+	go func() {
+		_ = s.notificationService.SendNotification(context.Background(), plan.UserID.Hex(), models.NotificationTypeOrder,
+			title, message, map[string]interface{}{"sipId": plan.ID.Hex(), "symbol": plan.Symbol}, nil)
+	}()
+}
+
+// fill sizes and places the instalment's order, recording the outcome on the execution
+func (s *SIPService) fill(ctx context.Context, plan *models.SIPPlan, execution *models.SIPExecution) {
+	instrument, err := s.instrumentRepo.FindByID(plan.InstrumentID.Hex())
+	if err != nil || instrument == nil || instrument.Status != "ACTIVE" {
+		execution.Status, execution.Reason = models.SIPExecutionFailed, "instrument is not active for trading"
+		return
+	}
+	marketData, err := s.marketDataRepo.FindByInstrumentID(ctx, instrument.ID.Hex())
+	if err != nil || marketData == nil || marketData.LastPrice <= 0 {
+		execution.Status, execution.Reason = models.SIPExecutionFailed, "market data unavailable"
+		return
+	}
+	execution.Price = marketData.LastPrice
+
+	lots := math.Floor(plan.Amount / marketData.LastPrice / float64(instrument.LotSize))
+	execution.Quantity = int(lots) * instrument.LotSize
+	if execution.Quantity == 0 {
+		execution.Reason = fmt.Sprintf("₹%.2f buys less than one lot at ₹%.2f", plan.Amount, marketData.LastPrice)
+		return
+	}
+
+	account, err := s.tradingAccountRepo.FindByUserID(ctx, plan.UserID.Hex())
+	if err != nil || account == nil {
+		execution.Status, execution.Reason = models.SIPExecutionFailed, "trading account not found"
+		return
+	}
+	required := marketData.LastPrice * 1.01 * float64(execution.Quantity) // Same buffer PlaceOrder checks market buys with
+	if available := account.Balance - account.BlockedMargin; available < required {
+		execution.Reason = fmt.Sprintf("insufficient funds (required ₹%.2f, available ₹%.2f)", required, available)
+		return
+	}
+
+	order, err := s.orderService.PlaceOrder(ctx, plan.UserID.Hex(), models.Order{
+		InstrumentID:  instrument.ID,
+		Symbol:        instrument.Symbol,
+		Side:          "BUY",
+		OrderType:     "MARKET",
+		Quantity:      execution.Quantity,
+		Intent:        string(models.IntentOpenLong),
+		ProductType:   models.ProductTypeCNC,
+		Source:        "SIP",
+		ClientOrderID: fmt.Sprintf("SIP-%s-%d", plan.ID.Hex(), execution.Instalment),
+	})
+	if err != nil {
+		execution.Status, execution.Reason = models.SIPExecutionFailed, err.Error()
+		return
+	}
+
+	execution.Status = models.SIPExecutionPlaced
+	execution.OrderID = &order.ID
+	plan.PlacedCount++
+	plan.InvestedAmount = roundPaise(plan.InvestedAmount + execution.Price*float64(execution.Quantity))
+}
+
+// schedule sets the plan's next instalment date after the given IST day and its first trading day
+func (s *SIPService) schedule(plan *models.SIPPlan, after time.Time) {
+	plan.ScheduledFor = nextInstalment(plan, after)
+	plan.NextRunAt = firstSession(plan.ScheduledFor, func(day time.Time) bool {
+		_, ok := exchangeSessionClose(s.marketRepo, plan.Exchange, day)
+		return ok
+	})
+}
+
+// nextInstalment returns the plan's first instalment date after the given IST day
+func nextInstalment(plan *models.SIPPlan, after time.Time) time.Time {
+	next := after.AddDate(0, 0, 1)
+	switch plan.Frequency {
+	case models.SIPFrequencyWeekly:
+		for isoWeekday(next) != plan.DayOfWeek {
+			next = next.AddDate(0, 0, 1)
+		}
+	case models.SIPFrequencyMonthly:
+		next = time.Date(after.Year(), after.Month(), plan.DayOfMonth, 0, 0, 0, 0, after.Location())
+		if !next.After(after) {
+			next = time.Date(after.Year(), after.Month()+1, plan.DayOfMonth, 0, 0, 0, 0, after.Location())
+		}
+	}
+	return next
+}
+
+// firstSession rolls a date past holidays and closed weekdays to the next trading day.
+// If none opens within 15 days the date is kept as is.
+func firstSession(day time.Time, isOpen func(time.Time) bool) time.Time {
+	for i := 0; i < 15; i++ {
+		if candidate := day.AddDate(0, 0, i); isOpen(candidate) {
+			return candidate
+		}
+	}
+	return day
+}
+
+func (s *SIPService) save(ctx context.Context, action string, old, plan *models.SIPPlan, details string) error {
+	if err := s.sipRepo.Update(ctx, plan); err != nil {
+		return err
+	}
+	s.auditService.LogFromContext(ctx, action, plan.ID.Hex(), "SIP", fmt.Sprintf("%s: %s", details, plan.Symbol), old, plan)
+	return nil
+}
+
+func (s *SIPService) getOwned(ctx context.Context, userID, planID string) (*models.SIPPlan, error) {
+	plan, err := s.sipRepo.FindByID(ctx, planID)
+	if err != nil || plan == nil {
+		return nil, errors.New("SIP not found")
+	}
+	if plan.UserID.Hex() != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return plan, nil
+}
+
+func validateStepUp(pct float64, every int) error {
+	if pct < 0 || pct > 100 {
+		return errors.New("step-up percentage must be between 0 and 100")
+	}
+	if pct > 0 && every < 1 {
+		return errors.New("step-up needs the number of instalments between increases")
+	}
+	return nil
+}
+
+// isoWeekday numbers days from 1 (Monday) to 7 (Sunday), like MarketHours
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aequitas/internal/models"
+)
+
+func TestNextInstalment(t *testing.T) {
+	// 2024-01-10 is a Wednesday
+	after := istDay(2024, 1, 10)
+
+	tests := []struct {
+		name string
+		plan models.SIPPlan
+		want time.Time
+	}{
+		{"daily runs the next day", models.SIPPlan{Frequency: models.SIPFrequencyDaily}, istDay(2024, 1, 11)},
+		{"weekly later this week", models.SIPPlan{Frequency: models.SIPFrequencyWeekly, DayOfWeek: 5}, istDay(2024, 1, 12)},
+		{"weekly on the same weekday waits a week", models.SIPPlan{Frequency: models.SIPFrequencyWeekly, DayOfWeek: 3}, istDay(2024, 1, 17)},
+		{"weekly on Sunday", models.SIPPlan{Frequency: models.SIPFrequencyWeekly, DayOfWeek: 7}, istDay(2024, 1, 14)},
+		{"monthly later this month", models.SIPPlan{Frequency: models.SIPFrequencyMonthly, DayOfMonth: 15}, istDay(2024, 1, 15)},
+		{"monthly on the same day waits a month", models.SIPPlan{Frequency: models.SIPFrequencyMonthly, DayOfMonth: 10}, istDay(2024, 2, 10)},
+		{"monthly already passed", models.SIPPlan{Frequency: models.SIPFrequencyMonthly, DayOfMonth: 5}, istDay(2024, 2, 5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextInstalment(&tt.plan, after); !got.Equal(tt.want) {
+				t.Errorf("nextInstalment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("monthly rolls over the year", func(t *testing.T) {
+		plan := models.SIPPlan{Frequency: models.SIPFrequencyMonthly, DayOfMonth: 1}
+		if got, want := nextInstalment(&plan, istDay(2024, 12, 20)), istDay(2025, 1, 1); !got.Equal(want) {
+			t.Errorf("nextInstalment() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestFirstSession(t *testing.T) {
+	holidays := map[string]bool{"2024-01-26": true} // Republic Day, a Friday
+	isOpen := func(day time.Time) bool {
+		if holidays[day.Format("2006-01-02")] {
+			return false
+		}
+		return isoWeekday(day) <= 5
+	}
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want time.Time
+	}{
+		{"trading day is kept", istDay(2024, 1, 24), istDay(2024, 1, 24)},
+		{"Saturday rolls to Monday", istDay(2024, 1, 20), istDay(2024, 1, 22)},
+		{"holiday before a weekend rolls to Monday", istDay(2024, 1, 26), istDay(2024, 1, 29)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstSession(tt.day, isOpen); !got.Equal(tt.want) {
+				t.Errorf("firstSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no session within 15 days keeps the date", func(t *testing.T) {
+		day := istDay(2024, 1, 24)
+		if got := firstSession(day, func(time.Time) bool { return false }); !got.Equal(day) {
+			t.Errorf("firstSession() = %v, want %v", got, day)
+		}
+	})
+}
+
+func TestIsoWeekday(t *testing.T) {
+	for i, want := range []int{1, 2, 3, 4, 5, 6, 7} {
+		day := istDay(2024, 1, 8+i) // 2024-01-08 is a Monday
+		if got := isoWeekday(day); got != want {
+			t.Errorf("isoWeekday(%s) = %d, want %d", day.Weekday(), got, want)
+		}
+	}
+}
+
+func TestValidateStepUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		pct     float64
+		every   int
+		wantErr bool
+	}{
+		{"no step-up", 0, 0, false},
+		{"ten percent every twelve", 10, 12, false},
+		{"full doubling", 100, 1, false},
+		{"negative", -5, 12, true},
+		{"over one hundred", 150, 12, true},
+		{"step-up without interval", 10, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStepUp(tt.pct, tt.every); (err != nil) != tt.wantErr {
+				t.Errorf("validateStepUp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	snapshotExchange        = "NSE"
+	intradaySnapshotEvery   = 30 * time.Minute
+	intradaySnapshotKeep    = 7 * 24 * time.Hour
+	maxSnapshotBackfillDays = 30
+)
+
+// SnapshotService writes one official EOD snapshot per account per trading day,
+// coarse intraday snapshots while the market is open, and backfills days missed during downtime.
+type SnapshotService struct {
+	portfolioService *PortfolioService
+	marketService    *MarketService
+	portfolioRepo    *repositories.PortfolioRepository
+	accountRepo      *repositories.TradingAccountRepository
+	tradeRepo        *repositories.TradeRepository
+	txRepo           *repositories.TransactionRepository
+	candleRepo       *repositories.CandleRepository
+	marketRepo       *repositories.MarketRepository
+	stopChan         chan struct{}
+
+	lastEODRun       string // YYYY-MM-DD
+	lastPruneRun     string // YYYY-MM-DD
+	lastIntradayRun  time.Time
+	backfillComplete bool
+}
+
+func NewSnapshotService(
+	portfolioService *PortfolioService,
+	marketService *MarketService,
+	portfolioRepo *repositories.PortfolioRepository,
+	accountRepo *repositories.TradingAccountRepository,
+	tradeRepo *repositories.TradeRepository,
+	txRepo *repositories.TransactionRepository,
+	candleRepo *repositories.CandleRepository,
+	marketRepo *repositories.MarketRepository,
+) *SnapshotService {
+	return &SnapshotService{
+		portfolioService: portfolioService,
+		marketService:    marketService,
+		portfolioRepo:    portfolioRepo,
+		accountRepo:      accountRepo,
+		tradeRepo:        tradeRepo,
+		txRepo:           txRepo,
+		candleRepo:       candleRepo,
+		marketRepo:       marketRepo,
+		stopChan:         make(chan struct{}),
+	}
+}
+
+// Start begins the snapshot scheduler
+func (s *SnapshotService) Start() {
+	ticker := time.NewTicker(5 * time.Minute)
+	go func() {
+		s.tick()
+		for {
+			select {
+			case <-ticker.C:
+				s.tick()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Println("Portfolio snapshot scheduler started (checks every 5m)")
+}
+
+// Stop gracefully shuts down the scheduler
+func (s *SnapshotService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *SnapshotService) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Minute)
+	defer cancel()
+
+	now := utils.GetISTTime()
+	today, _ := istDayBounds(now)
+	todayKey := today.Format("2006-01-02")
+
+	// 1. Backfill missed trading days once per process lifetime (after restarts/downtime)
+	if !s.backfillComplete {
+		s.backfillAll(ctx, today)
+		s.backfillComplete = true
+	}
+
+	// 2. Official EOD snapshot at session close
+	if closeAt, ok := s.sessionClose(today); ok && !now.Before(closeAt) && s.lastEODRun != todayKey {
+		s.captureEODAll(ctx, today)
+		s.lastEODRun = todayKey
+	}
+
+	// 3. Coarse intraday snapshots while the market is open
+	if isOpen, _, err := s.marketService.IsMarketOpen(snapshotExchange); err == nil && isOpen &&
+		time.Since(s.lastIntradayRun) >= intradaySnapshotEvery {
+		s.captureIntradayAll(ctx)
+		s.lastIntradayRun = time.Now()
+	}
+
+	// 4. Retention for intraday snapshots
+	if s.lastPruneRun != todayKey {
+		deleted, err := s.portfolioRepo.DeleteIntradaySnapshotsBefore(ctx, time.Now().Add(-intradaySnapshotKeep))
+		if err != nil {
+			log.Printf("[Snapshots] Failed to prune intraday snapshots: %v", err)
+		} else {
+			s.lastPruneRun = todayKey
+			if deleted > 0 {
+				log.Printf("[Snapshots] Pruned %d intraday snapshots", deleted)
+			}
+		}
+	}
+}
+
+func (s *SnapshotService) captureEODAll(ctx context.Context, tradingDate time.Time) {
+	accounts, err := s.accountRepo.FindAll(ctx)
+	if err != nil {
+		log.Printf("[Snapshots] Failed to list accounts for EOD: %v", err)
+		return
+	}
+
+	count := 0
+	for _, account := range accounts {
+		accountCtx := utils.WithAccountID(ctx, account.ID.Hex())
+		if _, err := s.portfolioService.CaptureEODSnapshot(accountCtx, account.UserID.Hex(), tradingDate); err != nil {
+			log.Printf("[Snapshots] EOD snapshot failed for user %s: %v", account.UserID.Hex(), err)
+			continue
+		}
+		count++
+	}
+	log.Printf("[Snapshots] Captured %d EOD snapshots for %s", count, tradingDate.Format("2006-01-02"))
+}
+
+func (s *SnapshotService) captureIntradayAll(ctx context.Context) {
+	accounts, err := s.accountRepo.FindAll(ctx)
+	if err != nil {
+		log.Printf("[Snapshots] Failed to list accounts for intraday snapshot: %v", err)
+		return
+	}
+
+	for _, account := range accounts {
+		accountCtx := utils.WithAccountID(ctx, account.ID.Hex())
+		if _, err := s.portfolioService.CaptureSnapshot(accountCtx, account.UserID.Hex()); err != nil {
+			log.Printf("[Snapshots] Intraday snapshot failed for user %s: %v", account.UserID.Hex(), err)
+		}
+	}
+}
+
+// backfillAll reconstructs EOD snapshots for trading days between each account's last EOD and today
+func (s *SnapshotService) backfillAll(ctx context.Context, today time.Time) {
+	accounts, err := s.accountRepo.FindAll(ctx)
+	if err != nil {
+		log.Printf("[Snapshots] Failed to list accounts for backfill: %v", err)
+		return
+	}
+
+	earliest := today.AddDate(0, 0, -maxSnapshotBackfillDays)
+	total := 0
+	for i := range accounts {
+		account := &accounts[i]
+		accountCtx := utils.WithAccountID(ctx, account.ID.Hex())
+
+		start, _ := istDayBounds(account.CreatedAt)
+		latest, err := s.portfolioRepo.FindLatestEODDate(accountCtx, account.UserID)
+		if err != nil {
+			log.Printf("[Snapshots] Failed to read last EOD for user %s: %v", account.UserID.Hex(), err)
+			continue
+		}
+		if latest != nil {
+			start = latest.In(today.Location()).AddDate(0, 0, 1)
+		}
+		if start.Before(earliest) {
+			start = earliest
+		}
+
+		for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+			if _, ok := s.sessionClose(day); !ok {
+				continue
+			}
+			if err := s.backfillDay(accountCtx, account, day); err != nil {
+				log.Printf("[Snapshots] Backfill failed for user %s on %s: %v", account.UserID.Hex(), day.Format("2006-01-02"), err)
+				continue
+			}
+			total++
+		}
+	}
+
+	if total > 0 {
+		log.Printf("[Snapshots] Backfilled %d missing EOD snapshots", total)
+	}
+}
+
+// backfillDay rewinds current cash and positions by everything that happened after the day,
+// then values positions at that day's candle close
+func (s *SnapshotService) backfillDay(ctx context.Context, account *models.TradingAccount, day time.Time) error {
+	userID := account.UserID.Hex()
+	_, dayEnd := istDayBounds(day)
+	now := time.Now()
+
+	// Cash: current balance minus ledger movements after the day
+	laterTxs, err := s.txRepo.FindCompletedBetween(ctx, account.ID, dayEnd, now)
+	if err != nil {
+		return err
+	}
+	cash := account.Balance
+	for _, tx := range laterTxs {
+		cash -= tx.Amount
+	}
+
+	// Positions: signed quantities (long > 0, short < 0) minus trades after the day
+	holdings, err := s.portfolioRepo.GetHoldings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	type position struct {
+		symbol   string
+		qty      int
+		avgEntry float64
+	}
+	positions := make(map[primitive.ObjectID]*position)
+	for _, h := range holdings {
+		qty := h.Quantity
+		if h.PositionType == models.PositionShort {
+			qty = -qty
+		}
+		positions[h.InstrumentID] = &position{symbol: h.Symbol, qty: qty, avgEntry: h.AvgEntryPrice}
+	}
+
+	laterTrades, err := s.tradeRepo.FindByUserIDBetween(ctx, userID, dayEnd, now)
+	if err != nil {
+		return err
+	}
+	for _, t := range laterTrades {
+		p, ok := positions[t.InstrumentID]
+		if !ok {
+			p = &position{symbol: t.Symbol}
+			positions[t.InstrumentID] = p
+		}
+		if t.Side == "BUY" {
+			p.qty -= t.Quantity
+		} else {
+			p.qty += t.Quantity
+		}
+		p.avgEntry = 0 // Historical cost basis is not reconstructable once the position changed
+	}
+
+	var holdingsValue float64
+	breakdown := make([]models.SnapshotHolding, 0, len(positions))
+	for instrumentID, p := range positions {
+		if p.qty == 0 {
+			continue
+		}
+		price := s.closePriceAt(instrumentID, dayEnd)
+		if price == 0 {
+			price = p.avgEntry
+		}
+
+		positionType, qty := models.PositionLong, p.qty
+		if qty < 0 {
+			positionType, qty = models.PositionShort, -qty
+		}
+		line := valueSnapshotHolding(instrumentID, p.symbol, positionType, qty, p.avgEntry, price)
+		if p.avgEntry == 0 {
+			line.UnrealizedPL = 0
+		}
+		holdingsValue += line.MarketValue
+		breakdown = append(breakdown, line)
+	}
+
+	valuedAt, _ := s.sessionClose(day)
+	snapshot := &models.PortfolioSnapshot{
+		UserID:        account.UserID,
+		AccountID:     account.ID,
+		Kind:          models.SnapshotKindEOD,
+		Source:        models.SnapshotSourceBackfill,
+		TradingDate:   &day,
+		Date:          valuedAt,
+		TotalEquity:   cash + holdingsValue,
+		CashBalance:   cash,
+		HoldingsValue: holdingsValue,
+		Holdings:      breakdown,
+		CreatedAt:     now,
+	}
+	return s.portfolioRepo.UpsertEODSnapshot(ctx, snapshot)
+}
+
+// closePriceAt returns the last candle close at or before 'at', preferring daily candles
+func (s *SnapshotService) closePriceAt(instrumentID primitive.ObjectID, at time.Time) float64 {
+	for _, interval := range []CandleInterval{Interval1d, Interval1h, Interval15m, Interval5m, Interval1m} {
+		candles, err := s.candleRepo.GetCandles(instrumentID.Hex(), string(interval), at.AddDate(0, 0, -7), at, 1)
+		if err == nil && len(candles) > 0 {
+			return candles[len(candles)-1].Close
+		}
+	}
+	return 0
+}
+
+// sessionClose returns the market close time for a day, or false if the exchange did not trade
+func (s *SnapshotService) sessionClose(day time.Time) (time.Time, bool) {
+	return exchangeSessionClose(s.marketRepo, snapshotExchange, day)
+}
+
+// exchangeSessionClose returns an exchange's close time for a day, or false if it did not trade
+func exchangeSessionClose(marketRepo *repositories.MarketRepository, exchange string, day time.Time) (time.Time, bool) {
+	if isHoliday, err := marketRepo.IsHoliday(exchange, day); err != nil || isHoliday {
+		return time.Time{}, false
+	}
+
+	dayOfWeek := int(day.Weekday())
+	if dayOfWeek == 0 {
+		dayOfWeek = 7 // Sunday = 7
+	}
+	hours, err := marketRepo.FindMarketHours(exchange, dayOfWeek)
+	if err != nil || hours == nil || hours.IsClosed {
+		return time.Time{}, false
+	}
+
+	closeAt, err := utils.CombineDateTime(day, hours.MarketClose)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return closeAt, true
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
+)
+
+// SquareOffService closes intraday (MIS) positions with system market orders ahead of market close,
+// warning holders beforehand and charging the optional square-off fee.
+type SquareOffService struct {
+	portfolioRepo       *repositories.PortfolioRepository
+	marketRepo          *repositories.MarketRepository
+	orderService        *OrderService
+	accountService      *TradingAccountService
+	notificationService *NotificationService
+	squareOffBefore     time.Duration
+	warnBefore          time.Duration
+	fee                 float64
+	stopChan            chan struct{}
+
+	lastWarningRun string // YYYY-MM-DD
+}
+
+func NewSquareOffService(
+	portfolioRepo *repositories.PortfolioRepository,
+	marketRepo *repositories.MarketRepository,
+	orderService *OrderService,
+	accountService *TradingAccountService,
+	notificationService *NotificationService,
+	squareOffMinutes int,
+	warningMinutes int,
+	fee float64,
+) *SquareOffService {
+	return &SquareOffService{
+		portfolioRepo:       portfolioRepo,
+		marketRepo:          marketRepo,
+		orderService:        orderService,
+		accountService:      accountService,
+		notificationService: notificationService,
+		squareOffBefore:     time.Duration(squareOffMinutes) * time.Minute,
+		warnBefore:          time.Duration(warningMinutes) * time.Minute,
+		fee:                 fee,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start begins the square-off scheduler
+func (s *SquareOffService) Start() {
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		s.tick()
+		for {
+			select {
+			case <-ticker.C:
+				s.tick()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	log.Printf("MIS square-off scheduler started (%s before close)", s.squareOffBefore)
+}
+
+// Stop gracefully shuts down the scheduler
+func (s *SquareOffService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *SquareOffService) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	defer cancel()
+
+	now := utils.GetISTTime()
+	today, _ := istDayBounds(now)
+	closeAt, ok := exchangeSessionClose(s.marketRepo, snapshotExchange, today)
+	if !ok {
+		return
+	}
+	cutoff := closeAt.Add(-s.squareOffBefore)
+
+	// 1. One warning per day before the cutoff
+	todayKey := today.Format("2006-01-02")
+	if !now.Before(cutoff.Add(-s.warnBefore)) && now.Before(cutoff) && s.lastWarningRun != todayKey {
+		s.warnAll(ctx, cutoff)
+		s.lastWarningRun = todayKey
+	}
+
+	// 2. Square off every tick until close so positions opened or left over after the cutoff are caught
+	if !now.Before(cutoff) && now.Before(closeAt) {
+		s.squareOffAll(ctx)
+	}
+}
+
+func (s *SquareOffService) warnAll(ctx context.Context, cutoff time.Time) {
+	holdings, err := s.portfolioRepo.FindOpenByProductType(ctx, models.ProductTypeMIS)
+	if err != nil {
+		log.Printf("[SquareOff] Failed to list MIS positions: %v", err)
+		return
+	}
+
+	symbolsByUser := make(map[string][]string)
+	for _, h := range holdings {
+		userID := h.UserID.Hex()
+		symbolsByUser[userID] = append(symbolsByUser[userID], h.Symbol)
+	}
+
+	message := fmt.Sprintf("Your open intraday (MIS) positions will be squared off at market from %s IST.", cutoff.Format("15:04"))
+	if s.fee > 0 {
+		message += fmt.Sprintf(" A fee of ₹%.2f applies per position squared off.", s.fee)
+	}
+	for userID, symbols := range symbolsByUser {
+		_ = s.notificationService.SendNotification(
+			ctx,
+			userID,
+			models.NotificationTypeAlert,
+			"Intraday Square-off Approaching",
+			fmt.Sprintf("%s Close %s yourself to avoid it.", message, strings.Join(symbols, ", ")),
+			map[string]interface{}{"symbols": symbols, "squareOffAt": cutoff},
+			nil,
+		)
+	}
+	if len(symbolsByUser) > 0 {
+		log.Printf("[SquareOff] Warned %d users with open MIS positions", len(symbolsByUser))
+	}
+}
+
+func (s *SquareOffService) squareOffAll(ctx context.Context) {
+	holdings, err := s.portfolioRepo.FindOpenByProductType(ctx, models.ProductTypeMIS)
+	if err != nil {
+		log.Printf("[SquareOff] Failed to list MIS positions: %v", err)
+		return
+	}
+
+	for i := range holdings {
+		h := &holdings[i]
+		order, err := s.orderService.ForceSquareOff(ctx, h, "intraday auto square-off before market close")
+		if err != nil {
+			log.Printf("[SquareOff] Failed to square off %s for user %s: %v", h.Symbol, h.UserID.Hex(), err)
+			continue
+		}
+
+		message := fmt.Sprintf("Your intraday (MIS) position of %d %s was squared off at market before close.", order.Quantity, h.Symbol)
+		if s.fee > 0 {
+			reference := "MIS_SQUAREOFF_" + order.OrderID
+			if err := s.accountService.ChargeFee(utils.WithAccountID(ctx, h.AccountID.Hex()), h.UserID.Hex(), s.fee, reference,
+				fmt.Sprintf("Auto square-off fee: %s", h.Symbol)); err != nil {
+				log.Printf("[SquareOff] Failed to charge square-off fee for order %s: %v", order.OrderID, err)
+			} else {
+				message += fmt.Sprintf(" A square-off fee of ₹%.2f was charged.", s.fee)
+			}
+		}
+
+		_ = s.notificationService.SendNotification(
+			ctx,
+			h.UserID.Hex(),
+			models.NotificationTypeOrder,
+			"Intraday Position Squared Off",
+			message,
+			map[string]interface{}{"orderId": order.OrderID, "symbol": h.Symbol, "quantity": order.Quantity},
+			nil,
+		)
+	}
+}
@@ -8,12 +8,14 @@ import (
 
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
 )
 
 type StopOrderService struct {
 	orderRepo      *repositories.OrderRepository
 	marketDataRepo *repositories.MarketDataRepository
 	orderService   *OrderService
+	gttService     *GTTService
 	stopChan       chan struct{}
 }
 
@@ -21,11 +23,13 @@ func NewStopOrderService(
 	orderRepo *repositories.OrderRepository,
 	marketDataRepo *repositories.MarketDataRepository,
 	orderService *OrderService,
+	gttService *GTTService,
 ) *StopOrderService {
 	return &StopOrderService{
 		orderRepo:      orderRepo,
 		marketDataRepo: marketDataRepo,
 		orderService:   orderService,
+		gttService:     gttService,
 		stopChan:       make(chan struct{}),
 	}
 }
@@ -38,13 +42,14 @@ func (s *StopOrderService) Start() {
 			select {
 			case <-ticker.C:
 				s.MonitorStopOrders(context.Background())
+				s.gttService.MonitorTriggers(context.Background())
 			case <-s.stopChan:
 				ticker.Stop()
 				return
 			}
 		}
 	}()
-	log.Println("Stop order monitoring service started (polling 3s)")
+	log.Println("Stop order and GTT monitoring service started (polling 3s)")
 }
 
 // Stop gracefully shuts down the monitoring service
@@ -81,10 +86,14 @@ func (s *StopOrderService) MonitorStopOrders(ctx context.Context) {
 		// Handle trailing stops first (they need price updates)
 		if order.OrderType == "TRAILING_STOP" {
 			if s.UpdateTrailingStop(order, currentPrice) {
-				// Trailing stop was updated, save changes
-				if _, err := s.orderRepo.Update(ctx, order); err != nil {
+				// Trailing stop was updated; save only the trailing state so a concurrent amendment isn't overwritten
+				saved, err := s.orderRepo.UpdateTrailingStop(ctx, order)
+				if err != nil {
 					log.Printf("Stop monitor error: failed to update trailing stop %s: %v", order.OrderID, err)
 				}
+				if !saved {
+					continue // Amended, triggered or cancelled since loaded; re-evaluate next pass
+				}
 			}
 		}
 
@@ -199,14 +208,14 @@ func (s *StopOrderService) UpdateTrailingStop(order *models.Order, currentPrice
 
 // TriggerStopOrder converts a PENDING stop order to a MARKET or LIMIT order
 func (s *StopOrderService) TriggerStopOrder(ctx context.Context, order *models.Order, triggerPrice float64) error {
-	// Mark original order as TRIGGERED
-	now := time.Now()
-	order.TriggeredAt = &now
-	order.TriggerPrice = &triggerPrice
-	order.Status = "TRIGGERED"
-
-	if _, err := s.orderRepo.Update(ctx, order); err != nil {
-		return fmt.Errorf("failed to update triggered order: %w", err)
+	ctx = utils.WithAccountID(ctx, order.AccountID.Hex()) // The triggered order trades in the stop's account
+	// Claim the original order as TRIGGERED; an overlapping pass or a cancel may have got there first
+	claimed, err := s.orderRepo.ClaimPending(ctx, order, models.OrderReasonStopTriggered, &triggerPrice)
+	if err != nil {
+		return fmt.Errorf("failed to claim triggered order: %w", err)
+	}
+	if !claimed {
+		return nil
 	}
 
 	// Create new order based on stop type
@@ -223,6 +232,8 @@ func (s *StopOrderService) TriggerStopOrder(ctx context.Context, order *models.O
 			OrderType:     "MARKET",
 			Quantity:      order.Quantity,
 			Intent:        order.Intent, // Critical: Preserve Intent (e.g. CLOSE_SHORT, OPEN_SHORT)
+			Netting:       order.Netting,
+			ProductType:   order.ProductType,
 			Source:        "STOP_TRIGGER",
 			ClientOrderID: fmt.Sprintf("STOP-%s", order.OrderID),
 			ParentOrderID: &order.ID,
@@ -239,6 +250,8 @@ func (s *StopOrderService) TriggerStopOrder(ctx context.Context, order *models.O
 			Quantity:      order.Quantity,
 			Price:         order.LimitPrice, // Use the limit price from stop-limit order
 			Intent:        order.Intent,     // Critical: Preserve Intent
+			Netting:       order.Netting,
+			ProductType:   order.ProductType,
 			Source:        "STOP_TRIGGER",
 			ClientOrderID: fmt.Sprintf("STOP-%s", order.OrderID),
 			ParentOrderID: &order.ID,
@@ -256,6 +269,8 @@ func (s *StopOrderService) TriggerStopOrder(ctx context.Context, order *models.O
 
 		// Mark original order as REJECTED with reason
 		order.Status = "REJECTED"
+		order.StatusReason = models.OrderReasonTriggerFailed
+		order.StatusMessage = err.Error()
 		if _, updateErr := s.orderRepo.Update(ctx, order); updateErr != nil {
 			log.Printf("Failed to mark order as rejected: %v", updateErr)
 		}
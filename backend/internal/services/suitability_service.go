@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"aequitas/internal/models"
+	"aequitas/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SuitabilityService records the onboarding risk questionnaire and disclosure acceptance,
+// and derives which products a user may trade
+type SuitabilityService struct {
+	repo         *repositories.SuitabilityRepository
+	auditService *AuditService
+}
+
+func NewSuitabilityService(repo *repositories.SuitabilityRepository, auditService *AuditService) *SuitabilityService {
+	return &SuitabilityService{
+		repo:         repo,
+		auditService: auditService,
+	}
+}
+
+// SuitabilityStatus is what the onboarding flow needs to render the questionnaire step
+type SuitabilityStatus struct {
+	CurrentVersion     string                     `json:"currentVersion"`
+	RequiresAcceptance bool                       `json:"requiresAcceptance"`
+	Profile            *models.SuitabilityProfile `json:"profile,omitempty"`
+}
+
+func (s *SuitabilityService) GetStatus(ctx context.Context, userID string) (*SuitabilityStatus, error) {
+	profile, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &SuitabilityStatus{
+		CurrentVersion:     models.RiskDisclosureVersion,
+		RequiresAcceptance: !profile.HasCurrentDisclosure(),
+		Profile:            profile,
+	}, nil
+}
+
+// GetProfile returns the user's profile, or nil if the questionnaire has never been completed
+func (s *SuitabilityService) GetProfile(ctx context.Context, userID string) (*models.SuitabilityProfile, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+	return s.repo.FindByUserID(ctx, uid)
+}
+
+// Submit stores questionnaire answers together with acceptance of the current disclosure version
+func (s *SuitabilityService) Submit(ctx context.Context, userID string, answers models.SuitabilityAnswers, acceptedVersion string, ipAddress string) (*models.SuitabilityProfile, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+	if acceptedVersion != models.RiskDisclosureVersion {
+		return nil, fmt.Errorf("the current risk disclosure is version %s; please review and accept it", models.RiskDisclosureVersion)
+	}
+	switch answers.Experience {
+	case models.ExperienceNone, models.ExperienceBeginner, models.ExperienceIntermediate, models.ExperienceExpert:
+	default:
+		return nil, errors.New("invalid experience level")
+	}
+	switch answers.RiskTolerance {
+	case models.RiskToleranceLow, models.RiskToleranceMedium, models.RiskToleranceHigh:
+	default:
+		return nil, errors.New("invalid risk tolerance")
+	}
+
+	old, _ := s.repo.FindByUserID(ctx, uid)
+
+	profile := &models.SuitabilityProfile{
+		UserID:      uid,
+		Answers:     answers,
+		Eligibility: assessSuitability(answers),
+		Disclosure: models.DisclosureAcceptance{
+			Version:    acceptedVersion,
+			AcceptedAt: time.Now(),
+			IPAddress:  ipAddress,
+		},
+	}
+	if err := s.repo.Save(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.FindByUserID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "RISK_DISCLOSURE_ACCEPTED", userID, "USER",
+		fmt.Sprintf("Accepted risk disclosure %s from %s (short selling: %t, max leverage: %.0fx)",
+			acceptedVersion, ipAddress, profile.Eligibility.ShortSelling, profile.Eligibility.MaxLeverage),
+		old, saved)
+	return saved, nil
+}
+
+// assessSuitability maps questionnaire answers to product eligibility
+func assessSuitability(a models.SuitabilityAnswers) models.TradingEligibility {
+	eligibility := models.TradingEligibility{MaxLeverage: 1}
+
+	experienced := a.Experience == models.ExperienceIntermediate || a.Experience == models.ExperienceExpert
+	eligibility.AdvancedOrders = a.Experience != models.ExperienceNone
+
+	// Shorting carries unlimited loss: require experience, appetite, understanding and capacity
+	eligibility.ShortSelling = experienced && a.RiskTolerance != models.RiskToleranceLow &&
+		a.UnderstandsShortSelling && a.CanAffordLosses
+
+	if a.UnderstandsLeverage && a.RiskTolerance != models.RiskToleranceLow {
+		switch a.Experience {
+		case models.ExperienceExpert:
+			eligibility.MaxLeverage = 5
+		case models.ExperienceIntermediate:
+			eligibility.MaxLeverage = 3
+		case models.ExperienceBeginner:
+			eligibility.MaxLeverage = 2
+		}
+	}
+	return eligibility
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"aequitas/internal/models"
 	"aequitas/internal/repositories"
+	"aequitas/internal/utils"
 )
 
 type TradingAccountService struct {
@@ -45,30 +48,159 @@ func NewTradingAccountService(
 
 // CreateForUser creates a trading account for a user (US-0.1.2)
 func (s *TradingAccountService) CreateForUser(ctx context.Context, userID string) (*models.TradingAccount, error) {
+	// Convert userID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
 	// Check if account already exists
-	existing, err := s.repo.FindByUserID(ctx, userID)
+	existing, err := s.repo.CountByUserID(ctx, objectID)
 	if err != nil {
 		return nil, err
 	}
-	if existing != nil {
+	if existing > 0 {
 		return nil, errors.New("trading account already exists for this user")
 	}
 
-	// Convert userID to ObjectID
-	objectID, err := primitive.ObjectIDFromHex(userID)
+	// Create trading account with defaults
+	account := &models.TradingAccount{
+		UserID:    objectID,
+		Name:      models.DefaultAccountName,
+		IsDefault: true,
+		Balance:   0.0,
+		Currency:  "INR",
+		Status:    "ACTIVE",
+	}
+
+	return s.repo.Create(ctx, account)
+}
+
+// ListAccounts returns all of a user's trading accounts, creating the default one if none exist
+func (s *TradingAccountService) ListAccounts(ctx context.Context, userID string) ([]models.TradingAccount, error) {
+	accounts, err := s.repo.FindAllByUserID(ctx, userID)
 	if err != nil {
-		return nil, errors.New("invalid user ID")
+		return nil, err
 	}
+	if len(accounts) == 0 {
+		account, err := s.CreateForUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *account)
+	}
+	return accounts, nil
+}
 
-	// Create trading account with defaults
-	account := &models.TradingAccount{
-		UserID:   objectID,
-		Balance:  0.0,
-		Currency: "INR",
+// CreateAccount opens an additional, empty strategy sub-account for a user
+func (s *TradingAccountService) CreateAccount(ctx context.Context, userID string, name string) (*models.TradingAccount, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 50 {
+		return nil, errors.New("account name is required (max 50 characters)")
+	}
+
+	accounts, err := s.ListAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) >= models.MaxTradingAccountsPerUser {
+		return nil, fmt.Errorf("a user may hold at most %d trading accounts", models.MaxTradingAccountsPerUser)
+	}
+	for _, a := range accounts {
+		if strings.EqualFold(a.Name, name) {
+			return nil, fmt.Errorf("an account named %q already exists", a.Name)
+		}
+	}
+
+	account, err := s.repo.Create(ctx, &models.TradingAccount{
+		UserID:   accounts[0].UserID,
+		Name:     name,
+		Currency: accounts[0].Currency,
 		Status:   "ACTIVE",
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.Create(ctx, account)
+	s.auditService.LogFromContext(ctx, "TRADING_ACCOUNT_CREATED", account.ID.Hex(), "TRADING_ACCOUNT",
+		fmt.Sprintf("Opened sub-account %q", account.Name), nil, account)
+	return account, nil
+}
+
+// TransferFunds moves free cash between two of a user's accounts atomically
+func (s *TradingAccountService) TransferFunds(ctx context.Context, userID string, fromID string, toID string, amount float64) (*models.TradingAccount, *models.TradingAccount, error) {
+	if amount <= 0 {
+		return nil, nil, errors.New("amount must be greater than zero")
+	}
+	if fromID == toID {
+		return nil, nil, errors.New("source and destination accounts must differ")
+	}
+
+	session, err := s.repo.GetDatabase().Client().StartSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var from, to *models.TradingAccount
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		from, err = s.ownedAccount(sessCtx, userID, fromID)
+		if err != nil {
+			return nil, err
+		}
+		to, err = s.ownedAccount(sessCtx, userID, toID)
+		if err != nil {
+			return nil, err
+		}
+		if from.Currency != to.Currency {
+			return nil, errors.New("cannot transfer between accounts in different currencies")
+		}
+
+		available := from.Balance - from.BlockedMargin
+		if available < amount {
+			return nil, fmt.Errorf("insufficient funds. Available: %0.2f, Requested: %0.2f", available, amount)
+		}
+
+		from.Balance -= amount
+		to.Balance += amount
+		if err := s.repo.UpdateBalance(sessCtx, from.ID, from.Balance); err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateBalance(sessCtx, to.ID, to.Balance); err != nil {
+			return nil, err
+		}
+
+		reference := fmt.Sprintf("XFER-%d", time.Now().UnixNano())
+		for _, tx := range []*models.Transaction{
+			{AccountID: from.ID, UserID: from.UserID, Type: "TRANSFER_OUT", Amount: -amount, Currency: from.Currency, Status: "COMPLETED", Reference: reference},
+			{AccountID: to.ID, UserID: to.UserID, Type: "TRANSFER_IN", Amount: amount, Currency: to.Currency, Status: "COMPLETED", Reference: reference},
+		} {
+			if _, err := s.txRepo.Create(sessCtx, tx); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.auditService.LogFromContext(ctx, "FUNDS_TRANSFERRED", from.ID.Hex(), "TRADING_ACCOUNT",
+		fmt.Sprintf("TRANSFER ₹%.2f from %q to %q", amount, from.Name, to.Name), nil,
+		map[string]interface{}{"fromAccountId": from.ID, "toAccountId": to.ID, "amount": amount})
+	return from, to, nil
+}
+
+// ownedAccount loads an account and checks it belongs to the user
+func (s *TradingAccountService) ownedAccount(ctx context.Context, userID string, accountID string) (*models.TradingAccount, error) {
+	account, err := s.repo.FindByID(ctx, accountID)
+	if err != nil || account == nil || account.UserID.Hex() != userID {
+		return nil, errors.New("trading account not found")
+	}
+	if account.Status != "ACTIVE" {
+		return nil, fmt.Errorf("trading account %q is %s", account.Name, account.Status)
+	}
+	return account, nil
 }
 
 // GetByUserID retrieves a trading account by user ID
@@ -78,6 +210,9 @@ func (s *TradingAccountService) GetByUserID(ctx context.Context, userID string)
 		return nil, err
 	}
 	if account == nil {
+		if utils.AccountIDFromContext(ctx) != "" {
+			return nil, errors.New("trading account not found")
+		}
 		// Lazily create account if it doesn't exist (US-0.1.2)
 		// This handles legacy users or registration failures
 		return s.CreateForUser(ctx, userID)
@@ -209,8 +344,8 @@ func (s *TradingAccountService) CompleteDeposit(ctx context.Context, userID stri
 		return nil, errors.New("invalid or already processed transaction")
 	}
 
-	// 3. Update balance and transaction status
-	account, err := s.repo.FindByUserID(ctx, userID)
+	// 3. Update balance and transaction status on the account the deposit was started for
+	account, err := s.repo.FindByUserID(utils.WithAccountID(ctx, tx.AccountID.Hex()), userID)
 	if err != nil {
 		fmt.Printf("[Deposit Complete Error] Account find error: %v\n", err)
 		return nil, err
@@ -268,6 +403,15 @@ func (s *TradingAccountService) GetTransactions(ctx context.Context, userID stri
 
 // SettleTrade updates the balance and creates a transaction for a filled trade
 func (s *TradingAccountService) SettleTrade(ctx context.Context, userID string, netAmount float64, tradeID string, side string) error {
+	return s.settleTrade(ctx, userID, netAmount, tradeID, side, false)
+}
+
+// SettleForcedTrade settles a system square-off; the balance may go negative as the loss has already been incurred
+func (s *TradingAccountService) SettleForcedTrade(ctx context.Context, userID string, netAmount float64, tradeID string, side string) error {
+	return s.settleTrade(ctx, userID, netAmount, tradeID, side, true)
+}
+
+func (s *TradingAccountService) settleTrade(ctx context.Context, userID string, netAmount float64, tradeID string, side string, allowDeficit bool) error {
 	account, err := s.repo.FindByUserID(ctx, userID)
 	if err != nil {
 		return err
@@ -286,7 +430,10 @@ func (s *TradingAccountService) SettleTrade(ctx context.Context, userID string,
 	}
 
 	if newBalance < 0 {
-		return errors.New("insufficient balance for settlement")
+		if !allowDeficit {
+			return errors.New("insufficient balance for settlement")
+		}
+		log.Printf("[Account] Forced settlement %s leaves user %s with a debit balance of ₹%.2f", tradeID, userID, newBalance)
 	}
 
 	err = s.repo.UpdateBalance(ctx, account.ID, newBalance)
@@ -322,6 +469,66 @@ func (s *TradingAccountService) SettleTrade(ctx context.Context, userID string,
 	return nil
 }
 
+// ChargeFee debits a platform fee (e.g. borrow fees) to the cash ledger; fees accrue even without free cash.
+// The debit and its FEE row are written together, joining the caller's transaction if ctx carries one.
+func (s *TradingAccountService) ChargeFee(ctx context.Context, userID string, amount float64, reference string, description string) error {
+	account, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return errors.New("trading account not found")
+	}
+
+	tx := &models.Transaction{
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Type:      "FEE",
+		Amount:    -amount,
+		Currency:  account.Currency,
+		Status:    "COMPLETED",
+		Reference: reference,
+	}
+	var newBalance float64
+	err = s.withTransaction(ctx, func(txCtx context.Context) error {
+		balance, err := s.repo.AddToBalance(txCtx, account.ID, -amount)
+		if err != nil {
+			return err
+		}
+		newBalance = balance
+		_, err = s.txRepo.Create(txCtx, tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if newBalance < 0 {
+		log.Printf("[Account] Fee %s leaves user %s with a debit balance of ₹%.2f", reference, userID, newBalance)
+	}
+
+	s.auditService.Log(userID, "System", "SYSTEM", "FEE_CHARGED", account.ID.Hex(), "TRADING_ACCOUNT",
+		fmt.Sprintf("%s: ₹%.2f", description, amount), nil, tx)
+	return nil
+}
+
+// withTransaction runs fn in the transaction ctx already carries, or in a new one
+func (s *TradingAccountService) withTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := s.repo.GetDatabase().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
 // UpdateRealizedPL adds the profit/loss from a closed trade to the total realized P&L
 func (s *TradingAccountService) UpdateRealizedPL(ctx context.Context, userID string, amount float64) error {
 	account, err := s.repo.FindByUserID(ctx, userID)
@@ -1,5 +1,7 @@
 package utils
 
+import "context"
+
 type ContextKey string
 
 const (
@@ -10,4 +12,16 @@ const (
 	UserEmailKey      ContextKey = "userEmail"
 	StepUpVerifiedKey ContextKey = "stepUpVerified"
 	CorrelationIDKey  ContextKey = "correlationID"
+	AccountIDKey      ContextKey = "accountID"
 )
+
+// WithAccountID scopes a context to one of the user's trading accounts
+func WithAccountID(ctx context.Context, accountID string) context.Context {
+	return context.WithValue(ctx, AccountIDKey, accountID)
+}
+
+// AccountIDFromContext returns the trading account a context is scoped to, or "" for the user's default account
+func AccountIDFromContext(ctx context.Context) string {
+	accountID, _ := ctx.Value(AccountIDKey).(string)
+	return accountID
+}